@@ -0,0 +1,285 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Package contract runs the TnT chaincode on the Fabric contract API, for peers without the legacy
+// Init/Invoke/Query shim. Assemblies and packages have typed contract methods; every chaincode
+// function, those included, still answers to its legacy name with the legacy arguments.
+package contract
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/GHSagarnil/TracknTrace3/chaincode/tnt"
+	"github.com/hyperledger/fabric-chaincode-go/v2/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Contract is the TnT chaincode as a contract API contract
+type Contract struct {
+	contractapi.Contract
+	cc *tnt.TnT
+}
+
+// New returns the contract, named "tnt". Legacy names such as "createAssembly" are not contract
+// methods, they reach the chaincode through the unknown transaction handler. The ledger is set up
+// with InitLedger, legacy "init" is not a function.
+func New() *Contract {
+	c := &Contract{cc: new(tnt.TnT)}
+	c.Name = "tnt"
+	c.UnknownTransaction = c.legacy
+	return c
+}
+
+// legacy runs the transaction's function by its legacy name, returning the legacy result
+func (c *Contract) legacy(ctx contractapi.TransactionContextInterface) (string, error) {
+	function, args := ctx.GetStub().GetFunctionAndParameters()
+	if i := strings.LastIndex(function, ":"); i >= 0 {
+		function = function[i+1:]
+	}
+	payload, err := c.cc.Call(newStateStub(ctx), function, args)
+	return string(payload), err
+}
+
+// AdminAttribute is the certificate attribute, set on enrollment with the Fabric CA, of the
+// identities administering the ledger: with the value "true" they may set it up and register users.
+const AdminAttribute = "tnt.admin"
+
+// stateStub is the peer's stub with the rich queries of CouchDB state, see tnt.RichQueryStub,
+// and the submitting identity's AdminAttribute. On LevelDB the query fails with
+// tnt.ErrNoRichQuery and the chaincode scans instead.
+type stateStub struct {
+	shim.ChaincodeStubInterface
+	identity cid.ClientIdentity
+}
+
+func newStateStub(ctx contractapi.TransactionContextInterface) stateStub {
+	return stateStub{ctx.GetStub(), ctx.GetClientIdentity()}
+}
+
+func (s stateStub) IsAdmin() (bool, error) {
+	if s.identity == nil {
+		return false, nil
+	}
+	value, found, err := s.identity.GetAttributeValue(AdminAttribute)
+	if err != nil {
+		return false, err
+	}
+	return found && value == "true", nil
+}
+
+// levelDBNoQuery is in the error of the peer running a rich query on LevelDB state,
+// "ExecuteQuery not supported for leveldb"
+const levelDBNoQuery = "not supported for leveldb"
+
+func (s stateStub) QueryState(query string) ([]tnt.Query_Result, error) {
+	it, err := s.GetQueryResult(query)
+	if err != nil {
+		if strings.Contains(err.Error(), levelDBNoQuery) {
+			return nil, tnt.ErrNoRichQuery
+		}
+		return nil, err
+	}
+	defer it.Close()
+
+	var results []tnt.Query_Result
+	for it.HasNext() {
+		kv, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, tnt.Query_Result{Key: kv.Key, Value: kv.Value})
+	}
+	return results, nil
+}
+
+// InitLedger sets up a new ledger, registering users as user, role pairs. Only an administrator
+// (see AdminAttribute) can, and only once - on a ledger set up already, e.g. one upgraded from the
+// legacy chaincode, it fails. Later users are added with RegisterUser.
+func (c *Contract) InitLedger(ctx contractapi.TransactionContextInterface, usersAndRoles []string) error {
+	_, err := c.cc.InitNewLedger(newStateStub(ctx), usersAndRoles)
+	return err
+}
+
+// RegisterUser registers a user with its role, bound to the identity the user submits with as the
+// user's getCallerIdentity returns it. Only an administrator can (legacy registerUser).
+func (c *Contract) RegisterUser(ctx contractapi.TransactionContextInterface, name string, role string, identity string) error {
+	return c.invoke(ctx, "registerUser", name, role, identity)
+}
+
+func (c *Contract) invoke(ctx contractapi.TransactionContextInterface, function string, args ...string) error {
+	_, err := c.cc.Call(newStateStub(ctx), function, args)
+	return err
+}
+
+// query runs function and decodes its result into result, which is left untouched when the chaincode returned nothing
+func (c *Contract) query(ctx contractapi.TransactionContextInterface, result interface{}, function string, args ...string) error {
+	payload, err := c.cc.Call(newStateStub(ctx), function, args)
+	if err != nil || len(payload) == 0 {
+		return err
+	}
+	if err := json.Unmarshal(payload, result); err != nil {
+		return &tnt.TnT_Error{Code: tnt.ERR_CORRUPT_STATE, Message: "Corrupt result of " + function}
+	}
+	return nil
+}
+
+// Assembly is the input of CreateAssembly and UpdateAssembly
+type Assembly struct {
+	AssemblyId          string `json:"assemblyId"`
+	DeviceSerialNo      string `json:"deviceSerialNo" metadata:",optional"`
+	DeviceType          string `json:"deviceType" metadata:",optional"`
+	FilamentBatchId     string `json:"filamentBatchId" metadata:",optional"`
+	LedBatchId          string `json:"ledBatchId" metadata:",optional"`
+	CircuitBoardBatchId string `json:"circuitBoardBatchId" metadata:",optional"`
+	WireBatchId         string `json:"wireBatchId" metadata:",optional"`
+	CasingBatchId       string `json:"casingBatchId" metadata:",optional"`
+	AdaptorBatchId      string `json:"adaptorBatchId" metadata:",optional"`
+	StickPodBatchId     string `json:"stickPodBatchId" metadata:",optional"`
+	ManufacturingPlant  string `json:"manufacturingPlant" metadata:",optional"`
+	AssemblyStatus      string `json:"assemblyStatus"`
+	AssemblyDate        string `json:"assemblyDate"`
+	AssemblyPackage     string `json:"assemblyPackage" metadata:",optional"`
+	AssemblyInfo1       string `json:"assemblyInfo1" metadata:",optional"`
+	AssemblyInfo2       string `json:"assemblyInfo2" metadata:",optional"`
+}
+
+func (a Assembly) args(user string) []string {
+	return []string{a.AssemblyId, a.DeviceSerialNo, a.DeviceType, a.FilamentBatchId, a.LedBatchId, a.CircuitBoardBatchId,
+		a.WireBatchId, a.CasingBatchId, a.AdaptorBatchId, a.StickPodBatchId, a.ManufacturingPlant, a.AssemblyStatus,
+		a.AssemblyDate, a.AssemblyPackage, a.AssemblyInfo1, a.AssemblyInfo2, user}
+}
+
+// Package is the input of CreatePackage and UpdatePackage. The shipping address is not part of it, it is
+// sent in the transient map as shippingToAddress, with a random customerDetailsSalt, so it never appears
+// in the transaction; the legacy functions keep an empty argument in its place.
+type Package struct {
+	CaseId            string `json:"caseId"`
+	HolderAssemblyId  string `json:"holderAssemblyId" metadata:",optional"`
+	ChargerAssemblyId string `json:"chargerAssemblyId" metadata:",optional"`
+	PackageStatus     string `json:"packageStatus"`
+	PackagingDate     string `json:"packagingDate"`
+	AssemblyStatus    string `json:"assemblyStatus" metadata:",optional"`
+	PackageInfo1      string `json:"packageInfo1" metadata:",optional"`
+	PackageInfo2      string `json:"packageInfo2" metadata:",optional"`
+}
+
+func (p Package) args(user string) []string {
+	return []string{p.CaseId, p.HolderAssemblyId, p.ChargerAssemblyId, p.PackageStatus, p.PackagingDate, "",
+		p.AssemblyStatus, p.PackageInfo1, p.PackageInfo2, user}
+}
+
+// CreateAssembly records a new assembly (legacy createAssembly)
+func (c *Contract) CreateAssembly(ctx contractapi.TransactionContextInterface, assembly Assembly, user string) error {
+	return c.invoke(ctx, "createAssembly", assembly.args(user)...)
+}
+
+// UpdateAssembly writes a new version of an assembly (legacy updateAssemblyByID)
+func (c *Contract) UpdateAssembly(ctx contractapi.TransactionContextInterface, assembly Assembly, user string) error {
+	return c.invoke(ctx, "updateAssemblyByID", assembly.args(user)...)
+}
+
+// CancelAssembly cancels an assembly (legacy cancelAssembly)
+func (c *Contract) CancelAssembly(ctx contractapi.TransactionContextInterface, assemblyId string, reasonCode string, comment string, user string) error {
+	return c.invoke(ctx, "cancelAssembly", assemblyId, reasonCode, comment, user)
+}
+
+// GetAssembly returns the current version of an assembly (legacy getAssemblyByID)
+func (c *Contract) GetAssembly(ctx contractapi.TransactionContextInterface, assemblyId string, user string) (*tnt.AssemblyLine, error) {
+	assembly := &tnt.AssemblyLine{}
+	if err := c.query(ctx, assembly, "getAssemblyByID", assemblyId, user); err != nil {
+		return nil, err
+	}
+	return assembly, nil
+}
+
+// GetAllAssemblies returns every assembly, cancelled ones on request (legacy getAllAssemblies)
+func (c *Contract) GetAllAssemblies(ctx contractapi.TransactionContextInterface, user string, includeCancelled bool) ([]*tnt.AssemblyLine, error) {
+	assemblies := []*tnt.AssemblyLine{}
+	err := c.query(ctx, &assemblies, "getAllAssemblies", user, strconv.FormatBool(includeCancelled))
+	return assemblies, err
+}
+
+// GetAssemblyHistory returns every version of an assembly, oldest first (legacy getAssemblyLineHistoryByID)
+func (c *Contract) GetAssemblyHistory(ctx contractapi.TransactionContextInterface, assemblyId string, user string) ([]tnt.AssemblyLine, error) {
+	var history tnt.AssemblyLine_Holder
+	err := c.query(ctx, &history, "getAssemblyLineHistoryByID", assemblyId, user)
+	if history.AssemblyLines == nil {
+		history.AssemblyLines = []tnt.AssemblyLine{}
+	}
+	return history.AssemblyLines, err
+}
+
+// CreatePackage packs assemblies into a case (legacy createPackage)
+func (c *Contract) CreatePackage(ctx contractapi.TransactionContextInterface, pack Package, user string) error {
+	return c.invoke(ctx, "createPackage", pack.args(user)...)
+}
+
+// UpdatePackage writes a new version of a package (legacy updatePackage)
+func (c *Contract) UpdatePackage(ctx contractapi.TransactionContextInterface, pack Package, user string) error {
+	return c.invoke(ctx, "updatePackage", pack.args(user)...)
+}
+
+// UpdatePackageCustomerDetails stores the customer details of a package, sent in the transient map as
+// customerName, customerPhone, customerEmail and shippingToAddress with a random customerDetailsSalt,
+// privately (legacy updatePackageCustomerDetails)
+func (c *Contract) UpdatePackageCustomerDetails(ctx contractapi.TransactionContextInterface, caseId string, user string) error {
+	return c.invoke(ctx, "updatePackageCustomerDetails", caseId, "", "", "", "", user)
+}
+
+// GetPackageCustomerDetails returns the customer details of a package to its shipper and recipients,
+// the user being the one the submitting identity is registered with (legacy getPackageCustomerDetails)
+func (c *Contract) GetPackageCustomerDetails(ctx contractapi.TransactionContextInterface, caseId string) (*tnt.Customer_Details, error) {
+	details := &tnt.Customer_Details{}
+	if err := c.query(ctx, details, "getPackageCustomerDetails", caseId); err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+// CancelPackage cancels a package and frees its assemblies (legacy cancelPackage)
+func (c *Contract) CancelPackage(ctx contractapi.TransactionContextInterface, caseId string, reasonCode string, comment string, user string) error {
+	return c.invoke(ctx, "cancelPackage", caseId, reasonCode, comment, user)
+}
+
+// GetPackage returns the current version of a package (legacy getPackageByID)
+func (c *Contract) GetPackage(ctx contractapi.TransactionContextInterface, caseId string) (*tnt.PackageLine, error) {
+	pack := &tnt.PackageLine{}
+	if err := c.query(ctx, pack, "getPackageByID", caseId); err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+// GetAllPackages returns every package, cancelled ones on request (legacy getAllPackages)
+func (c *Contract) GetAllPackages(ctx contractapi.TransactionContextInterface, user string, includeCancelled bool) ([]*tnt.PackageLine, error) {
+	packages := []*tnt.PackageLine{}
+	err := c.query(ctx, &packages, "getAllPackages", user, strconv.FormatBool(includeCancelled))
+	return packages, err
+}
+
+// GetPackageHistory returns every version of a package, oldest first (legacy getPackageLineHistoryByID)
+func (c *Contract) GetPackageHistory(ctx contractapi.TransactionContextInterface, caseId string, user string) ([]tnt.PackageLine, error) {
+	var history tnt.PackageLine_Holder
+	err := c.query(ctx, &history, "getPackageLineHistoryByID", caseId, user)
+	if history.PackageLines == nil {
+		history.PackageLines = []tnt.PackageLine{}
+	}
+	return history.PackageLines, err
+}
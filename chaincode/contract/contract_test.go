@@ -0,0 +1,193 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package contract
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/GHSagarnil/TracknTrace3/chaincode/tnt"
+	"github.com/hyperledger/fabric-chaincode-go/v2/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+)
+
+// testStub is an in memory ledger on LevelDB, running the transaction function with args.
+// The stub methods the chaincode doesn't use are left to the nil interface.
+type testStub struct {
+	shim.ChaincodeStubInterface
+	state    map[string][]byte
+	private  map[string][]byte
+	function string
+	args     []string
+}
+
+func newTestStub() *testStub {
+	return &testStub{state: make(map[string][]byte), private: make(map[string][]byte)}
+}
+
+func (s *testStub) GetState(key string) ([]byte, error) {
+	return s.state[key], nil
+}
+
+func (s *testStub) PutState(key string, value []byte) error {
+	s.state[key] = value
+	return nil
+}
+
+func (s *testStub) DelState(key string) error {
+	delete(s.state, key)
+	return nil
+}
+
+func (s *testStub) GetPrivateData(collection, key string) ([]byte, error) {
+	return s.private[collection+"|"+key], nil
+}
+
+func (s *testStub) PutPrivateData(collection, key string, value []byte) error {
+	s.private[collection+"|"+key] = value
+	return nil
+}
+
+func (s *testStub) GetCreator() ([]byte, error) {
+	return nil, nil
+}
+
+func (s *testStub) GetTransient() (map[string][]byte, error) {
+	return nil, nil
+}
+
+func (s *testStub) GetFunctionAndParameters() (string, []string) {
+	return s.function, s.args
+}
+
+func (s *testStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	return nil, errors.New("ExecuteQuery not supported for leveldb")
+}
+
+// testIdentity has the AdminAttribute when admin
+type testIdentity struct {
+	cid.ClientIdentity
+	admin bool
+}
+
+func (id testIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	if attrName == AdminAttribute && id.admin {
+		return "true", true, nil
+	}
+	return "", false, nil
+}
+
+// testContext is the transaction context of stub, submitted by identity
+type testContext struct {
+	stub     *testStub
+	identity cid.ClientIdentity
+}
+
+func (ctx testContext) GetStub() shim.ChaincodeStubInterface {
+	return ctx.stub
+}
+
+func (ctx testContext) GetClientIdentity() cid.ClientIdentity {
+	return ctx.identity
+}
+
+// newTestLedger returns the contract and a context on a ledger set up with an assembly line user
+func newTestLedger(t *testing.T) (*Contract, testContext) {
+	t.Helper()
+	c := New()
+	ctx := testContext{stub: newTestStub(), identity: testIdentity{admin: true}}
+	if err := c.InitLedger(ctx, []string{"al", tnt.ASSEMBLYLINE_ROLE}); err != nil {
+		t.Fatal(err)
+	}
+	ctx.identity = testIdentity{}
+	return c, ctx
+}
+
+var assemblyArgs = []string{"A1", "S1", "HOLDER", "F1", "L1", "C1", "W1", "K1", "A1", "S1", "Plant1", "1", "20170608101500", "", "", "", "al"}
+
+func TestLegacyNames(t *testing.T) {
+	c, ctx := newTestLedger(t)
+	if c.Name != "tnt" || c.UnknownTransaction == nil {
+		t.Fatalf("contract %q has no unknown transaction handler", c.Name)
+	}
+
+	// Legacy names are not contract methods, the unknown transaction handler runs them
+	ctx.stub.function, ctx.stub.args = "createAssembly", assemblyArgs
+	if _, err := c.legacy(ctx); err != nil {
+		t.Fatalf("createAssembly: %v", err)
+	}
+	assembly, err := c.GetAssembly(ctx, "A1", "al")
+	if err != nil || assembly.DeviceSerialNo != "S1" {
+		t.Fatalf("GetAssembly after legacy createAssembly = %+v, %v", assembly, err)
+	}
+
+	// The contract name may prefix the function
+	ctx.stub.function, ctx.stub.args = "tnt:getAssemblyByID", []string{"A1", "al"}
+	payload, err := c.legacy(ctx)
+	if err != nil {
+		t.Fatalf("tnt:getAssemblyByID: %v", err)
+	}
+	var legacy tnt.AssemblyLine
+	if err := json.Unmarshal([]byte(payload), &legacy); err != nil || legacy.AssemblyId != "A1" {
+		t.Errorf("legacy getAssemblyByID = %s, %v", payload, err)
+	}
+
+	ctx.stub.function, ctx.stub.args = "getAllAssemblies", []string{"al"}
+	if payload, err := c.legacy(ctx); err != nil {
+		t.Errorf("getAllAssemblies on LevelDB: %v", err)
+	} else {
+		var assemblies []tnt.AssemblyLine
+		if err := json.Unmarshal([]byte(payload), &assemblies); err != nil || len(assemblies) != 1 {
+			t.Errorf("getAllAssemblies = %s, %v, want the scan's one assembly", payload, err)
+		}
+	}
+
+	ctx.stub.function, ctx.stub.args = "tnt:noSuchFunction", nil
+	if _, err := c.legacy(ctx); err == nil {
+		t.Error("unknown function ran")
+	}
+}
+
+func TestInitLedger(t *testing.T) {
+	c := New()
+	ctx := testContext{stub: newTestStub(), identity: testIdentity{}}
+	if err := c.InitLedger(ctx, []string{"al", tnt.ASSEMBLYLINE_ROLE}); err == nil {
+		t.Error("InitLedger by an identity without the admin attribute")
+	}
+	ctx.identity = testIdentity{admin: true}
+	if err := c.InitLedger(ctx, []string{"al", tnt.ASSEMBLYLINE_ROLE}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.InitLedger(ctx, []string{"al", tnt.ASSEMBLYLINE_ROLE}); err == nil {
+		t.Error("InitLedger ran twice")
+	}
+
+	// Legacy init is not a function
+	ctx.stub.function, ctx.stub.args = "init", []string{"mallory", tnt.ASSEMBLYLINE_ROLE}
+	if _, err := c.legacy(ctx); err == nil {
+		t.Error("legacy init ran through the unknown transaction handler")
+	}
+}
+
+func TestQueryStateOnLevelDB(t *testing.T) {
+	_, ctx := newTestLedger(t)
+	if _, err := newStateStub(ctx).QueryState(`{"selector":{}}`); err != tnt.ErrNoRichQuery {
+		t.Errorf("QueryState on LevelDB: err = %v, want ErrNoRichQuery", err)
+	}
+}
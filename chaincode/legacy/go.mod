@@ -0,0 +1,10 @@
+module github.com/GHSagarnil/TracknTrace3/chaincode/legacy
+
+go 1.22.0
+
+require (
+	github.com/GHSagarnil/TracknTrace3 v0.0.0
+	github.com/hyperledger/fabric v0.6.1-preview
+)
+
+replace github.com/GHSagarnil/TracknTrace3 => ../..
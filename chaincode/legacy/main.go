@@ -0,0 +1,63 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Chaincode binary for Fabric peers on the legacy Init/Invoke/Query shim. Peers on the contract API run chaincode/v2.
+// It is a module of its own, so the v0.6 Fabric tree the shim comes from is no dependency of the rest of the repository
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/GHSagarnil/TracknTrace3/chaincode/tnt"
+)
+
+// TnT chaincode on the legacy shim
+type legacyChaincode struct {
+	cc *tnt.TnT
+}
+
+// Init initializes the smart contracts - run by the peer on instantiate and upgrade
+func (c *legacyChaincode) Init(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	return c.cc.InitLedger(stub, args)
+}
+
+// Invoke callback representing the invocation of a chaincode
+func (c *legacyChaincode) Invoke(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	fmt.Printf("Invoke called, determining function")
+
+	invoke, ok := tnt.IsInvokeFunction(function)
+	if !ok || !invoke { return nil, &tnt.TnT_Error{Code: tnt.ERR_INVALID_ARGUMENT, Field: "function", Message: "Received unknown function invocation"} }
+	return c.cc.Call(stub, function, args)
+}
+
+// Query queries the chaincode
+func (c *legacyChaincode) Query(stub shim.ChaincodeStubInterface, function string, args []string) ([]byte, error) {
+	fmt.Printf("Query called, determining function")
+
+	invoke, ok := tnt.IsInvokeFunction(function)
+	if !ok || invoke { return nil, &tnt.TnT_Error{Code: tnt.ERR_INVALID_ARGUMENT, Field: "function", Message: "Received unknown function query"} }
+	return c.cc.Call(stub, function, args)
+}
+
+//main function
+func main() {
+	err := shim.Start(&legacyChaincode{cc: new(tnt.TnT)})
+	if err != nil {
+		fmt.Printf("Error starting Simple chaincode: %s", err)
+	}
+}
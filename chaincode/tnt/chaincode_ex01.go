@@ -0,0 +1,7495 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Package tnt is the TnT chaincode. The chaincode binaries - the parent directory on the legacy shim, v2 on the
+// Fabric contract API - only start it, so client tools can run it in process against a mock stub.
+package tnt
+
+import (
+	"fmt"
+	"errors"
+	"time"
+	"strconv"
+	"strings"
+	"regexp"
+	"sort"
+	"reflect"
+	"math"
+	"sync"
+	_ "time/tzdata"
+	
+	"encoding/json"
+	"encoding/hex"
+	"crypto/sha256"
+	"crypto/hmac"
+	"github.com/GHSagarnil/TracknTrace3/merkle"
+	"github.com/GHSagarnil/TracknTrace3/csvexport"
+	
+)
+
+// TnT is a high level smart contract that collaborate together business artifact based smart contracts
+type TnT struct {
+}
+
+//Ledger state the chaincode functions work on - the legacy shim stub (see chaincode/legacy) and the stub of the
+//Fabric contract API (see the contract package) both have it. Stubs with GetPrivateData/PutPrivateData get private data too
+type Stub interface {
+	GetState(key string) ([]byte, error)
+	PutState(key string, value []byte) error
+	DelState(key string) error
+}
+
+
+//==============================================================================================================================
+//	 Participant types - Each participant type is mapped to an integer which we use to compare to the value stored in a
+//						 user's eCert and Specific Assembly Statuses
+//==============================================================================================================================
+const   ASSEMBLYLINE_ROLE  		=	"assemblyline_role"
+const   PACKAGELINE_ROLE   		=	"packageline_role"
+const 	QA_VIEWER_ROLE 			= 	"qaviewer_role" // new role for viewing purpose
+const 	CONSUMER_ROLE 			= 	"consumer_role" // customer facing role - public view only
+const 	QA_INSPECTOR_ROLE 		= 	"qainspector_role" // records QA inspections
+const 	CARRIER_ROLE 			= 	"carrier_role" // shipment handlers
+const 	DISTRIBUTOR_ROLE 		= 	"distributor_role"
+const 	RETAILER_ROLE 			= 	"retailer_role"
+const   ASSEMBLYSTATUS_RFP   	=	"6" //Ready For Packaging"
+const  	ASSEMBLYSTATUS_PKG 		=	"7" //Packaged" 
+const  	ASSEMBLYSTATUS_CAN 		=	"8" //Cancelled"
+const  	ASSEMBLYSTATUS_QAF 		=	"2" //QA Failed"
+const  	ASSEMBLYSTATUS_RET 		=	"9" //Returned"
+const  	ASSEMBLYSTATUS_SCR 		=	"10" //Scrapped"
+const  	PACKAGESTATUS_PKG 		=	"7" //Packaged"
+const  	PACKAGESTATUS_CAN 		=	"8" //Cancelled"
+const  	PACKAGESTATUS_RET 		=	"9" //Returned"
+const   FIL_BATCH  				=	"FilamentBatchId"	
+const   LED_BATCH  				=	"LedBatchId"
+const   CIR_BATCH  				=	"CircuitBoardBatchId"
+const   WRE_BATCH  				=	"WireBatchId"
+const   CAS_BATCH  				=	"CasingBatchId"
+const   ADP_BATCH  				=	"AdaptorBatchId"
+const   STK_BATCH  				=	"StickPodBatchId"
+const   HLD_ASSMB_TYP  			=	"HolderAssemblyId"
+const 	CHG_ASSMB_TYP 			= 	"ChargerAssemblyId"
+const   INSPECTION_PASS  		=	"PASS"
+const   INSPECTION_FAIL  		=	"FAIL"
+const   REWORK_PENDING_QA  		=	"PENDING_QA"
+const   REWORK_QA_PASSED  		=	"QA_PASSED"
+const   RMA_REQUESTED  			=	"REQUESTED"
+const   RMA_RECEIVED  			=	"RECEIVED"
+const   RMA_INSPECTED  			=	"INSPECTED"
+const   RMA_REFURBISHED  		=	"REFURBISHED"
+const   RMA_SCRAPPED  			=	"SCRAPPED"
+const   RMA_REPLACED  			=	"REPLACED"
+const   SHIPMENT_CREATED  		=	"CREATED"
+const   SHIPMENT_IN_TRANSIT  	=	"IN_TRANSIT"
+const   SHIPMENT_DELIVERED  	=	"DELIVERED"
+const   CANCEL_DUPLICATE  		=	"DUPLICATE"
+const   CANCEL_DATA_ERROR  		=	"DATA_ERROR"
+const   CANCEL_DAMAGED  		=	"DAMAGED"
+const   CANCEL_ORDER_WITHDRAWN  =	"ORDER_WITHDRAWN"
+const   CANCEL_OTHER  			=	"OTHER"
+const   SCRAP_ASSEMBLY  		=	"ASSEMBLY"
+const   SCRAP_BATCH  			=	"BATCH"
+const   STATS_PLANT  			=	"plant"
+const   STATS_DEVICE_TYPE  		=	"deviceType"
+const   STATS_STATUS  			=	"status"
+const   STATS_DAY  				=	"day"
+const   STATS_WEEK  			=	"week"
+const   CYCLE_ASSEMBLY_TO_RFP  	=	"ASSEMBLY_TO_RFP"
+const   CYCLE_RFP_TO_PACKAGED  	=	"RFP_TO_PACKAGED"
+const   CYCLE_PACKAGED_TO_SHIPPED	=	"PACKAGED_TO_SHIPPED"
+const   CSV_MAX_PAGE_SIZE  		=	500 // Records (or histories) per CSV export page
+const   CUSTOMER_DETAILS_COLLECTION	=	"customerDetailsCollection" // Private data collection - see collections_config.json
+const   CUSTOMER_DETAILS_SALT_MIN	=	16 // Bytes of the customerDetailsSalt of the transient map, see customerDetailsSalt
+const   ERR_NOT_FOUND  			=	"NOT_FOUND"
+const   ERR_ALREADY_EXISTS  	=	"ALREADY_EXISTS"
+const   ERR_FORBIDDEN  			=	"FORBIDDEN"
+const   ERR_INVALID_ARGUMENT  	=	"INVALID_ARGUMENT"
+const   ERR_INVALID_TRANSITION  =	"INVALID_TRANSITION" // Not allowed in the current status of the record
+const   ERR_CORRUPT_STATE  		=	"CORRUPT_STATE" // Ledger read/write failed or the stored record can't be decoded
+const   SCHEMA_VERSION  		=	3 // Version of the stored records - see Schema section
+const   MIGRATION_MAX_PAGE_SIZE	=	100 // Assemblies, Packages, RMAs or Shipments per migrateSchema run
+const   RICH_QUERY_INDEX_DDOC  	=	"tnt-" // CouchDB design document of a shipped index is "tnt-" + the indexed field
+const   DATE_FORMAT  			=	"20060102150405" // Stored dates - YYYYMMDDHHMMSS in UTC, see Dates section
+
+
+/* Error section */
+
+//Error returned by every function - the error text is the JSON of the struct, e.g.
+//{"code":"NOT_FOUND","message":"Assembly doesn't exists","field":"assemblyId"}
+type TnT_Error struct {
+	Code 		string `json:"code"`
+	Message 	string `json:"message"`
+	Field 		string `json:"field,omitempty"` // Argument at fault
+}
+
+func (e *TnT_Error) Error() string {
+	bytes, _ := json.Marshal(e)
+	return string(bytes)
+}
+
+func tntError(_code string, _field string, _message string) error {
+	return &TnT_Error{Code: _code, Message: _message, Field: _field}
+}
+
+//Checks the number of arguments against the allowed counts
+func checkArgCount(args []string, counts ...int) error {
+	var expecting []string
+	for _, count := range counts {
+		if len(args) == count { return nil }
+		expecting = append(expecting, strconv.Itoa(count))
+	}
+	return tntError(ERR_INVALID_ARGUMENT, "args", "Incorrect number of arguments. Expecting " + strings.Join(expecting, " or ") + ". Got: " + strconv.Itoa(len(args)) + ".")
+}
+
+
+// Assembly Line Structure
+type AssemblyLine struct{	
+	AssemblyId string `json:"assemblyId"`
+	DeviceSerialNo string `json:"deviceSerialNo"`
+	DeviceType string `json:"deviceType"`
+	FilamentBatchId string `json:"filamentBatchId"`
+	LedBatchId string `json:"ledBatchId"`
+	CircuitBoardBatchId string `json:"circuitBoardBatchId"`
+	WireBatchId string `json:"wireBatchId"`
+	CasingBatchId string `json:"casingBatchId"`
+	AdaptorBatchId string `json:"adaptorBatchId"`
+	StickPodBatchId string `json:"stickPodBatchId"`
+	ManufacturingPlant string `json:"manufacturingPlant"`
+	AssemblyStatus string `json:"assemblyStatus"`
+	AssemblyDate string `json:"assemblyDate"` // New
+	AssemblyDateLocal string `json:"assemblyDateLocal"` // AssemblyDate in the time zone of the ManufacturingPlant, not part of the hash
+	AssemblyCreationDate string `json:"assemblyCreationDate"`
+	AssemblyLastUpdatedOn string `json:"assemblyLastUpdateOn"`
+	AssemblyCreatedBy string `json:"assemblyCreatedBy"`
+	AssemblyLastUpdatedBy string `json:"assemblyLastUpdatedBy"`
+	AssemblyPackage string `json:"assemblyPackage"`
+	AssemblyInfo1 string `json:"assemblyInfo1"`
+	AssemblyInfo2 string `json:"assemblyInfo2"`
+	AssemblyHash string `json:"assemblyHash"` // Content hash computed by the chaincode
+	SchemaVersion Schema_Version `json:"schemaVersion"` // Not part of the hash
+	//_assemblyPackage,_assemblyInfo1,_assemblyInfo2
+	}
+
+
+//AssemblyID Holder
+type AssemblyID_Holder struct {
+	AssemblyIDs 	[]string `json:"assemblyIDs"`
+	SchemaVersion 	Schema_Version `json:"schemaVersion"`
+}
+
+//AssemblyLine Holder
+type AssemblyLine_Holder struct {
+	AssemblyLines 	[]AssemblyLine `json:"assemblyLines"`
+	SchemaVersion 	Schema_Version `json:"schemaVersion"`
+}
+
+func historyKey(_id string) string {
+	return "HISTORY|" + _id // Indicates history key, AssemblyId or CaseId
+}
+
+//Reads the history of an Assembly (AssemblyLine_Holder) or Package (PackageLine_Holder), under the ID with an "H"
+//suffix while migrateSchema hasn't moved it - see getMovedState
+func getHistoryState(stub Stub, _id string, history interface{}) ([]byte, error) {
+	return getMovedState(stub, historyKey(_id), _id + "H", history, _id)
+}
+
+// Package Line Structure
+type PackageLine struct{	
+	CaseId string `json:"caseId"`
+	HolderAssemblyId string `json:"holderAssemblyId"`
+	ChargerAssemblyId string `json:"chargerAssemblyId"`
+	PackageStatus string `json:"packageStatus"`
+	PackagingDate string `json:"packagingDate"`
+	PackagingDateLocal string `json:"packagingDateLocal"` // PackagingDate in the time zone of the packed Assemblies' plant, not part of the hash
+	ShippingToAddress string `json:"shippingToAddress"` // Not stored publicly - kept in the Customer details private data
+	PackageCreationDate string `json:"packageCreationDate"`
+	PackageLastUpdatedOn string `json:"packageLastUpdateOn"`
+	PackageCreatedBy string `json:"packageCreatedBy"`
+	PackageLastUpdatedBy string `json:"packageLastUpdatedBy"`
+	PackageInfo1 string `json:"packageInfo1"`
+	PackageInfo2 string `json:"packageInfo2"`
+	PackageHash string `json:"packageHash"` // Content hash computed by the chaincode
+	PackageMerkleRoot string `json:"packageMerkleRoot"` // Merkle root over the packed assemblies
+	PackageRMAId string `json:"packageRMAId"` // RMA the package was returned under
+	CustomerDetailsHash string `json:"customerDetailsHash"` // Hash of the private Customer details
+	PrivatisedFromHash string `json:"privatisedFromHash,omitempty"` // PackageHash of the version the clear text ShippingToAddress was moved out of
+	ShippingToAddressRedacted bool `json:"shippingToAddressRedacted,omitempty"` // History version whose hash covers a ShippingToAddress since removed, not part of the hash
+	SchemaVersion Schema_Version `json:"schemaVersion"` // Not part of the hash
+	}
+
+
+
+
+/* Assembly Section */
+
+type PackageCaseID_Holder struct {
+	PackageCaseIDs 	[]string `json:"packageCaseIDs"`
+	SchemaVersion 		Schema_Version `json:"schemaVersion"`
+}
+
+//PackageLine Holder
+type PackageLine_Holder struct {
+	PackageLines 	[]PackageLine `json:"packageLines"`
+	SchemaVersion 	Schema_Version `json:"schemaVersion"`
+}
+
+//Merkle tree leaves of a Package - stored against packageMerkleKey
+type PackageMerkle_Holder struct {
+	CaseId 		string `json:"caseId"`
+	MerkleRoot 	string `json:"merkleRoot"`
+	Leaves 		[]merkle.AssemblyLeaf `json:"leaves"`
+	SchemaVersion 	Schema_Version `json:"schemaVersion"`
+}
+
+func packageMerkleKey(_caseId string) string {
+	return "MERKLE|" + _caseId // Indicates Merkle key
+}
+
+//Inclusion proof of an Assembly in a Package
+type Inclusion_Proof struct {
+	CaseId 		string `json:"caseId"`
+	MerkleRoot 	string `json:"merkleRoot"`
+	LeafIndex 	int `json:"leafIndex"`
+	Leaf 		merkle.AssemblyLeaf `json:"leaf"`
+	Proof 		[]merkle.ProofStep `json:"proof"`
+}
+
+
+//API to create an assembly
+//"args": [ "ASM0101","DEV0101","HOLDER","FIL0002","LED0002","CIR0002","WIR0002","CAS0002","ADA0002","STK0002","MAN0002","1","20170608","aluser1"]
+//_assemblyId,_deviceSerialNo,_deviceType,_filamentBatchId,_ledBatchId,_circuitBoardBatchId,_wireBatchId,_casingBatchId,_adaptorBatchId,_stickPodBatchId,_manufacturingPlant,_assemblyStatus _assemblyDate,_assemblyPackage,_assemblyInfo1,_assemblyInfo2 ,user_name
+func (t *TnT) createAssembly(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[16]
+
+		_assemblyId := args[0]
+		_deviceSerialNo:= args[1]
+		_deviceType:=args[2]
+		_filamentBatchId:=args[3]
+		_ledBatchId:=args[4]
+		_circuitBoardBatchId:=args[5]
+		_wireBatchId:=args[6]
+		_casingBatchId:=args[7]
+		_adaptorBatchId:=args[8]
+		_stickPodBatchId:=args[9]
+		_manufacturingPlant:=args[10]
+		_assemblyStatus:= args[11]
+		_assemblyPackage:= args[13]
+		_assemblyInfo1:= args[14]
+		_assemblyInfo2:= args[15]
+		_time:= time.Now().UTC()
+
+		//AssemblyDate in UTC - checked by checkCreateAssembly
+		_assemblyDate, err := canonicalDate(args[12], "assemblyDate")
+		if err != nil { return nil, err }
+		zones, err := getPlantTimeZones(stub)
+		if err != nil { return nil, err }
+
+		_assemblyCreationDate := _time.Format(DATE_FORMAT)
+		_assemblyLastUpdatedOn := _time.Format(DATE_FORMAT)
+		_assemblyCreatedBy := user_name
+		_assemblyLastUpdatedBy := user_name
+
+		/* AssemblyLine history -----------------Starts */
+		var assemLine_HolderInit AssemblyLine_Holder
+		assemLine_HolderInit.SchemaVersion = SCHEMA_VERSION
+
+		assemLine_HolderKey := historyKey(_assemblyId) // Indicates history key
+		bytesAssemblyLinesInit, err := json.Marshal(assemLine_HolderInit)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating assemID_Holder record") }
+		err = stub.PutState(assemLine_HolderKey, bytesAssemblyLinesInit)
+		/* AssemblyLine history -----------------Ends */
+
+
+		//setting the AssemblyLine to create
+		assem := AssemblyLine{}
+		assem.AssemblyId = _assemblyId
+		assem.DeviceSerialNo = _deviceSerialNo
+		assem.DeviceType = _deviceType
+		assem.FilamentBatchId = _filamentBatchId
+		assem.LedBatchId = _ledBatchId
+		assem.CircuitBoardBatchId = _circuitBoardBatchId
+		assem.WireBatchId = _wireBatchId
+		assem.CasingBatchId = _casingBatchId
+		assem.AdaptorBatchId = _adaptorBatchId
+		assem.StickPodBatchId = _stickPodBatchId
+		assem.ManufacturingPlant = _manufacturingPlant
+		assem.AssemblyStatus = _assemblyStatus
+		assem.AssemblyDate = _assemblyDate
+		assem.AssemblyDateLocal = zones.localDate(_assemblyDate, _manufacturingPlant)
+		assem.AssemblyCreationDate = _assemblyCreationDate
+		assem.AssemblyLastUpdatedOn = _assemblyLastUpdatedOn
+		assem.AssemblyCreatedBy = _assemblyCreatedBy
+		assem.AssemblyLastUpdatedBy = _assemblyLastUpdatedBy
+		assem.AssemblyPackage = _assemblyPackage
+		assem.AssemblyInfo1 = _assemblyInfo1
+		assem.AssemblyInfo2 = _assemblyInfo2
+		assem.SchemaVersion = SCHEMA_VERSION
+		
+		
+
+		assem.AssemblyHash = computeAssemblyHash(assem)
+		bytes, err := json.Marshal(assem)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting Assembly record") }
+
+		err = stub.PutState(_assemblyId, bytes)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing Assembly record") }
+
+		err = registerSerialNo(stub, _deviceSerialNo, _deviceType, _assemblyId)
+		if err != nil { return nil, err }
+
+		/* GetAll changes-------------------------starts--------------------------*/
+		// Holding the AssemblyIDs in State separately
+		bytesAssemHolder, err := stub.GetState("Assemblies")
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+		var assemID_Holder AssemblyID_Holder
+
+		err = json.Unmarshal(bytesAssemHolder, &assemID_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Assemblies record") }
+
+		assemID_Holder.AssemblyIDs = append(assemID_Holder.AssemblyIDs, _assemblyId)
+		
+		bytesAssemHolder, err = json.Marshal(assemID_Holder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating Assembly_Holder record") }
+
+		err = stub.PutState("Assemblies", bytesAssemHolder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+		/* GetAll changes---------------------------ends------------------------ */
+
+		/* AssemblyLine history ------------------------------------------Starts */
+		bytesAssemblyLines, err := getHistoryState(stub, _assemblyId, &AssemblyLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+		var assemLine_Holder AssemblyLine_Holder
+
+		err = json.Unmarshal(bytesAssemblyLines, &assemLine_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt AssemblyLines record") }
+
+		assemLine_Holder.AssemblyLines = append(assemLine_Holder.AssemblyLines, assem) //appending the newly created AssemblyLine
+		
+		bytesAssemblyLines, err = json.Marshal(assemLine_Holder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating AssemblyLine_Holder record") }
+
+		err = stub.PutState(assemLine_HolderKey, bytesAssemblyLines)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+		/* AssemblyLine history ------------------------------------------Ends */
+		
+		fmt.Println("Created Assembly successfully")
+		
+		return nil, nil
+
+}
+
+//Update Assembly based on Id - All except AssemblyId, DeviceSerialNo,DeviceType and AssemblyCreationDate and AssemblyCreatedBy
+//"args": [ "ASM0101","DEV0101","HOLDER","FIL0002","LED0002","CIR0002","WIR0002","CAS0002","ADA0002","STK0002","MAN0002","1","20170608","CASE0001","INFO1","INFO2"aluser1"]
+//_assemblyId,_deviceSerialNo,_deviceType,_filamentBatchId,_ledBatchId,_circuitBoardBatchId,_wireBatchId,_casingBatchId,_adaptorBatchId,_stickPodBatchId,_manufacturingPlant,_assemblyStatus _assemblyDate,_assemblyPackage,_assemblyInfo1,_assemblyInfo2 ,user_name
+func (t *TnT) updateAssemblyByID(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[16]
+
+		_assemblyId := args[0]
+		_deviceSerialNo:= args[1]
+		//_deviceType:=args[2] - No Change
+		_filamentBatchId:=args[3]
+		_ledBatchId:=args[4]
+		_circuitBoardBatchId:=args[5]
+		_wireBatchId:=args[6]
+		_casingBatchId:=args[7]
+		_adaptorBatchId:=args[8]
+		_stickPodBatchId:=args[9]
+		_manufacturingPlant:=args[10]
+		_assemblyStatus:= args[11]
+		_assemblyPackage:= args[13]
+		_assemblyInfo1:= args[14]
+		_assemblyInfo2:= args[15]
+		
+		_time:= time.Now().UTC()
+		//_assemblyCreationDate - No change
+		_assemblyLastUpdatedOn := _time.Format(DATE_FORMAT)
+		//_assemblyCreatedBy - No change
+		_assemblyLastUpdatedBy := user_name
+
+		//AssemblyDate in UTC - checked by checkUpdateAssembly
+		_assemblyDate, err := canonicalDate(args[12], "assemblyDate")
+		if err != nil { return nil, err }
+		zones, err := getPlantTimeZones(stub)
+		if err != nil { return nil, err }
+
+		//get the Assembly - checked by checkUpdateAssembly
+		assemblyAsBytes, err := stub.GetState(_assemblyId)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id")	}
+
+		assem := AssemblyLine{}
+		json.Unmarshal(assemblyAsBytes, &assem)
+
+		//update the AssemblyLine 
+		//assem.AssemblyId = _assemblyId
+		assem.DeviceSerialNo = _deviceSerialNo
+		//assem.DeviceType = _deviceType
+		assem.FilamentBatchId = _filamentBatchId
+		assem.LedBatchId = _ledBatchId
+		assem.CircuitBoardBatchId = _circuitBoardBatchId
+		assem.WireBatchId = _wireBatchId
+		assem.CasingBatchId = _casingBatchId
+		assem.AdaptorBatchId = _adaptorBatchId
+		assem.StickPodBatchId = _stickPodBatchId
+		assem.ManufacturingPlant = _manufacturingPlant
+		assem.AssemblyStatus = _assemblyStatus
+		assem.AssemblyDate = _assemblyDate
+		assem.AssemblyDateLocal = zones.localDate(_assemblyDate, _manufacturingPlant)
+		//assem.AssemblyCreationDate = _assemblyCreationDate
+		assem.AssemblyLastUpdatedOn = _assemblyLastUpdatedOn
+		//assem.AssemblyCreatedBy = _assemblyCreatedBy
+		assem.AssemblyLastUpdatedBy = _assemblyLastUpdatedBy
+		assem.AssemblyPackage = _assemblyPackage
+		assem.AssemblyInfo1 = _assemblyInfo1
+		assem.AssemblyInfo2 = _assemblyInfo2
+
+
+		
+		assem.AssemblyHash = computeAssemblyHash(assem)
+		bytes, err := json.Marshal(assem)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting Assembly record") }
+
+		err = stub.PutState(_assemblyId, bytes)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing Assembly record") }
+
+
+		/* AssemblyLine history ------------------------------------------Starts */
+		// assemLine_HolderKey := _assemblyId + "H" // Indicates history key
+		assemLine_HolderKey := historyKey(_assemblyId) // Indicates History Key for Assembly with ID = _assemblyId
+		bytesAssemblyLines, err := getHistoryState(stub, _assemblyId, &AssemblyLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+		var assemLine_Holder AssemblyLine_Holder
+
+		err = json.Unmarshal(bytesAssemblyLines, &assemLine_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt AssemblyLines record") }
+
+		assemLine_Holder.AssemblyLines = append(assemLine_Holder.AssemblyLines, assem) //appending the updated AssemblyLine
+		
+		bytesAssemblyLines, err = json.Marshal(assemLine_Holder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating AssemblyLine_Holder record") }
+
+		err = stub.PutState(assemLine_HolderKey, bytesAssemblyLines)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+		/* AssemblyLine history ------------------------------------------Ends */
+
+		return nil, nil
+			
+}
+
+
+//Update Assembly Info2 - HashCode based on Id 
+// Parameters = ASM0001, HASCODE, USERNAME
+func (t *TnT) updateAssemblyInfo2ByID(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[2]
+
+		_assemblyId := args[0]
+		_assemblyInfo2:= args[1]
+		
+		_time:= time.Now().UTC()
+		_assemblyLastUpdatedOn := _time.Format(DATE_FORMAT)
+		_assemblyLastUpdatedBy := user_name
+
+		//get the Assembly
+		assemblyAsBytes, err := stub.GetState(_assemblyId)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id")	}
+		if assemblyAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+		assem := AssemblyLine{}
+		json.Unmarshal(assemblyAsBytes, &assem)
+
+		// Update Assembly only if the hashcode is not already set
+		if len(assem.AssemblyInfo2) == 0 {
+			//update the AssemblyLine Info2
+			assem.AssemblyInfo2 = _assemblyInfo2
+			assem.AssemblyLastUpdatedOn = _assemblyLastUpdatedOn
+			assem.AssemblyLastUpdatedBy = _assemblyLastUpdatedBy
+
+			
+			assem.AssemblyHash = computeAssemblyHash(assem)
+			bytes, err := json.Marshal(assem)
+			if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting Assembly record") }
+
+			err = stub.PutState(_assemblyId, bytes)
+			if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing Assembly record") }
+
+			/* AssemblyLine history ------------------------------------------Starts */
+			// For HashCode update don't store an Assembly History but update the last History with Info2
+			// assemLine_HolderKey := _assemblyId + "H" // Indicates history key
+			assemLine_HolderKey := historyKey(_assemblyId) // Indicates History Key for Assembly with ID = _assemblyId
+			bytesAssemblyLines, err := getHistoryState(stub, _assemblyId, &AssemblyLine_Holder{})
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+			var assemLine_Holder AssemblyLine_Holder
+
+			err = json.Unmarshal(bytesAssemblyLines, &assemLine_Holder)
+			if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt AssemblyLines record") }
+
+			//assemLine_Holder.AssemblyLines = append(assemLine_Holder.AssemblyLines, assem) //appending the updated AssemblyLine
+
+			//Overwrite exisitng last element with the updated element - Don't apend but overwrite
+			latestIndex := len(assemLine_Holder.AssemblyLines)
+			assemLine_Holder.AssemblyLines[latestIndex-1] = assem
+			
+			bytesAssemblyLines, err = json.Marshal(assemLine_Holder)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating AssemblyLine_Holder record") }
+
+			err = stub.PutState(assemLine_HolderKey, bytesAssemblyLines)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+			/* AssemblyLine history ------------------------------------------Ends */
+		} // AssemblyInfo2 lenght check ends	
+
+		return nil, nil
+			
+}
+
+//get the Assembly against ID
+func (t *TnT) getAssemblyByID(stub Stub, args []string) ([]byte, error) {
+
+	_assemblyId := args[0]
+
+	//get the var from chaincode state
+	valAsbytes, err := stub.GetState(_assemblyId)									
+	if err != nil {
+		return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get state for " + _assemblyId)
+	}
+	if valAsbytes == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+	//Read through AssemblyLine to upconvert older schema versions
+	assem := AssemblyLine{}
+	err = json.Unmarshal(valAsbytes, &assem)
+	if err != nil || assem.AssemblyId != _assemblyId { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+	mapB, _ := json.Marshal(assem)
+	return mapB, nil
+
+}
+
+//get all Assemblies
+func (t *TnT) getAllAssemblies(stub Stub, args []string) ([]byte, error) {
+
+	
+	_includeCancelled, err := includeCancelledArg(args, 1)
+	if err != nil { return nil, err }
+
+	bytes, err := stub.GetState("Assemblies")
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+	var assemID_Holder AssemblyID_Holder
+
+	err = json.Unmarshal(bytes, &assemID_Holder)
+	if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Assemblies") }
+
+	res2E:= []*AssemblyLine{}	
+
+	for _, assemblyId := range assemID_Holder.AssemblyIDs {
+
+		//Get the existing AssemblyLine
+		assemblyAsBytes, err := stub.GetState(assemblyId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Assembly")}
+
+		if assemblyAsBytes != nil { 
+		res := new(AssemblyLine)
+		json.Unmarshal(assemblyAsBytes, &res)
+		if !_includeCancelled && res.AssemblyStatus == ASSEMBLYSTATUS_CAN { continue }
+
+		// Append Assembly to Assembly Array
+		res2E=append(res2E,res)
+		} // If ends
+		} // For ends
+
+    mapB, _ := json.Marshal(res2E)
+    //fmt.Println(string(mapB))
+	return mapB, nil
+}
+
+//get all Assemblies based on Type & BatchNo
+func (t *TnT) getAssembliesByBatchNumber(stub Stub, args []string) ([]byte, error) {
+
+	
+	_includeCancelled, err := includeCancelledArg(args, 3)
+	if err != nil { return nil, err }
+
+	_batchType:= args[0]
+	_batchNumber:= args[1]
+	_assemblyFlag:= 0
+
+	//Assemblies with the batch fitted, or reworked out of it
+	_reworkedIds, err := reworkedAssemblyIds(stub, _batchType, _batchNumber)
+	if err != nil { return nil, err }
+
+	assemblies, err := selectAssemblies(stub, map[string]interface{}{jsonField(_batchType): _batchNumber}, jsonField(_batchType), _reworkedIds)
+	if err != nil { return nil, err }
+
+	res2E:= []*AssemblyLine{}	
+
+	for _, res := range assemblies {
+		if !_includeCancelled && res.AssemblyStatus == ASSEMBLYSTATUS_CAN { continue }
+
+		//Check the filter condition
+		if 		   _batchType == FIL_BATCH					&&
+					res.FilamentBatchId == _batchNumber		{ 
+					_assemblyFlag = 1
+		} else if  _batchType == LED_BATCH					&&
+					res.LedBatchId == _batchNumber			{ 
+					_assemblyFlag = 1
+		} else if  _batchType == CIR_BATCH					&&
+					res.CircuitBoardBatchId == _batchNumber	{ 
+					_assemblyFlag = 1
+		} else if  _batchType == WRE_BATCH					&&
+					res.WireBatchId == _batchNumber			{ 
+					_assemblyFlag = 1
+		} else if  _batchType == CAS_BATCH					&&
+					res.CasingBatchId == _batchNumber		{ 
+					_assemblyFlag = 1
+		} else if  _batchType == ADP_BATCH					&&
+					res.AdaptorBatchId == _batchNumber		{ 
+					_assemblyFlag = 1
+		} else if  _batchType == STK_BATCH					&&
+					res.StickPodBatchId == _batchNumber		{ 
+					_assemblyFlag = 1
+		}
+
+		// Reworked Assemblies are still traced to the batches they were built with
+		if _assemblyFlag == 0 {
+			_replaced, err := wasBatchReplaced(stub, res.AssemblyId, _batchType, _batchNumber)
+			if err != nil { return nil, err }
+			if _replaced { _assemblyFlag = 1 }
+		}
+
+		// Append Assembly to Assembly Array if the flag is 1 (indicates valid for filter criteria)
+		if _assemblyFlag == 1 {
+			res2E=append(res2E,res)
+		}
+		//re-setting the flag to 0
+		_assemblyFlag = 0
+	} // For ends
+
+    mapB, _ := json.Marshal(res2E)
+    //fmt.Println(string(mapB))
+	return mapB, nil
+}
+
+//get all Assemblies based on FromDate & ToDate
+func (t *TnT) getAssembliesByDate(stub Stub, args []string) ([]byte, error) {
+
+	
+	_includeCancelled, err := includeCancelledArg(args, 3)
+	if err != nil { return nil, err }
+
+	// YYYYMMDDHHMMSS (e.g. 20170612235959) handled as Int64
+	//var _fromDate int64
+	//var _toDate int64
+	
+	_fromDate, err := parseDateArg(args[0], "fromDate")
+	if err != nil { return nil, err }
+	
+	_toDate, err := parseDateArg(args[1], "toDate")
+	if err != nil { return nil, err }
+	
+	_assemblyFlag:= 0
+
+	var _assemblyDateInt64 int64
+
+	assemblies, err := selectAssemblies(stub, dateSelector("assemblyDate", _fromDate, _toDate), "assemblyDate", nil)
+	if err != nil { return nil, err }
+
+	res2E:= []*AssemblyLine{}	
+
+	for _, res := range assemblies {
+
+		if !_includeCancelled && res.AssemblyStatus == ASSEMBLYSTATUS_CAN { continue }
+
+		//fmt.Printf("%T, %v\n", _fromDate, _fromDate)
+		//fmt.Printf("%T, %v\n", _toDate, _toDate)
+		//if _fromDate == _toDate { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Assembly")}
+		
+		//Check the filter condition YYYYMMDDHHMMSS
+		if len(res.AssemblyDate) != 14 {return nil, tntError(ERR_INVALID_ARGUMENT, "assemblyDate", "AssemblyDate must be 14 digit datetime field.")}
+		if _assemblyDateInt64, err = strconv.ParseInt(res.AssemblyDate, 10, 64); err != nil { return nil, tntError(ERR_CORRUPT_STATE, "assemblyDate", "Error in converting AssemblyDate to int64")}
+		if	_assemblyDateInt64 >= _fromDate		&&
+			_assemblyDateInt64 <= _toDate		{ 
+			_assemblyFlag = 1
+		} 
+					
+		// Append Assembly to Assembly Array if the flag is 1 (indicates valid for filter criteria)
+		if _assemblyFlag == 1 {
+			res2E=append(res2E,res)
+		}
+	//re-setting the flag and AssemblyDate
+		_assemblyFlag = 0
+		_assemblyDateInt64 = 0
+	} // For ends
+
+    mapB, _ := json.Marshal(res2E)
+    //fmt.Println(string(mapB))
+	return mapB, nil
+}
+
+//get all Assemblies based on Type & BatchNo & From & To Date
+func (t *TnT) getAssembliesByBatchNumberAndByDate(stub Stub, args []string) ([]byte, error) {
+
+	
+	_includeCancelled, err := includeCancelledArg(args, 5)
+	if err != nil { return nil, err }
+
+	_batchType:= args[0]
+	_batchNumber:= args[1]
+	_assemblyFlag:= 0
+
+	_fromDate, err := parseDateArg(args[2], "fromDate")
+	if err != nil { return nil, err }
+	
+	_toDate, err := parseDateArg(args[3], "toDate")
+	if err != nil { return nil, err }
+
+	var _assemblyDateInt64 int64
+
+	//Assemblies with the batch fitted, or reworked out of it
+	_reworkedIds, err := reworkedAssemblyIds(stub, _batchType, _batchNumber)
+	if err != nil { return nil, err }
+
+	selector := dateSelector("assemblyDate", _fromDate, _toDate)
+	selector[jsonField(_batchType)] = _batchNumber
+	assemblies, err := selectAssemblies(stub, selector, jsonField(_batchType), _reworkedIds)
+	if err != nil { return nil, err }
+
+	res2E:= []*AssemblyLine{}	
+
+	for _, res := range assemblies {
+		if !_includeCancelled && res.AssemblyStatus == ASSEMBLYSTATUS_CAN { continue }
+
+		//Check the filter condition
+		if len(res.AssemblyDate) == 14 {
+			if _assemblyDateInt64, err = strconv.ParseInt(res.AssemblyDate, 10, 64); err == nil { 
+				if	_assemblyDateInt64 >= _fromDate		&&
+					_assemblyDateInt64 <= _toDate		{
+						if 		   _batchType == FIL_BATCH					&&
+									res.FilamentBatchId == _batchNumber		{ 
+									_assemblyFlag = 1
+						} else if  _batchType == LED_BATCH					&&
+									res.LedBatchId == _batchNumber			{ 
+									_assemblyFlag = 1
+						} else if  _batchType == CIR_BATCH					&&
+									res.CircuitBoardBatchId == _batchNumber	{ 
+									_assemblyFlag = 1
+						} else if  _batchType == WRE_BATCH					&&
+									res.WireBatchId == _batchNumber			{ 
+									_assemblyFlag = 1
+						} else if  _batchType == CAS_BATCH					&&
+									res.CasingBatchId == _batchNumber		{ 
+									_assemblyFlag = 1
+						} else if  _batchType == ADP_BATCH					&&
+									res.AdaptorBatchId == _batchNumber		{ 
+									_assemblyFlag = 1
+						} else if  _batchType == STK_BATCH					&&
+									res.StickPodBatchId == _batchNumber		{ 
+									_assemblyFlag = 1
+						}
+
+						// Reworked Assemblies are still traced to the batches they were built with
+						if _assemblyFlag == 0 {
+							_replaced, err := wasBatchReplaced(stub, res.AssemblyId, _batchType, _batchNumber)
+							if err != nil { return nil, err }
+							if _replaced { _assemblyFlag = 1 }
+						}
+					}// from date and to date check
+			}// if date parse
+		}// if date lenght
+		
+
+		// Append Assembly to Assembly Array if the flag is 1 (indicates valid for filter criteria)
+		if _assemblyFlag == 1 {
+			res2E=append(res2E,res)
+		}
+		//re-setting the flag to 0
+		_assemblyFlag = 0
+		_assemblyDateInt64 = 0
+	} // For ends
+
+    mapB, _ := json.Marshal(res2E)
+    //fmt.Println(string(mapB))
+	return mapB, nil
+}
+
+
+//get all Assemblies History based on FromDate & ToDate
+func (t *TnT) getAssembliesHistoryByDate(stub Stub, args []string) ([]byte, error) {
+
+	
+	_includeCancelled, err := includeCancelledArg(args, 3)
+	if err != nil { return nil, err }
+
+	// YYYYMMDDHHMMSS (e.g. 20170612235959) handled as Int64
+	//var _fromDate int64
+	//var _toDate int64
+	
+	_fromDate, err := parseDateArg(args[0], "fromDate")
+	if err != nil { return nil, err }
+	
+	_toDate, err := parseDateArg(args[1], "toDate")
+	if err != nil { return nil, err }
+	
+	_assemblyFlag:= 0
+
+	bytes, err := stub.GetState("Assemblies")
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+	var assemID_Holder AssemblyID_Holder
+	var _assemblyDateInt64 int64
+	
+	err = json.Unmarshal(bytes, &assemID_Holder)
+	if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Assemblies") }
+
+	// Array of filtered Assemblies
+	res2E:= []AssemblyLine{}	
+	// Filtered Assembly
+	//res := new(AssemblyLine)
+	
+	//Looping through the array of assemblyids
+	for _, assemblyId := range assemID_Holder.AssemblyIDs {
+
+		//Get the AssemblyLine History for each AssemblyID
+		bytesAssemblyLinesHistoryByID, err := getHistoryState(stub, assemblyId, &AssemblyLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get bytesAssemblyLinesHistoryByID") }
+
+		var assemLineHistory_Holder AssemblyLine_Holder
+
+		err = json.Unmarshal(bytesAssemblyLinesHistoryByID, &assemLineHistory_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt assemLineHistory_Holder record") }
+
+		//Skip Assemblies cancelled since - the latest version is the current status
+		_latest := len(assemLineHistory_Holder.AssemblyLines)
+		if !_includeCancelled && _latest > 0 && assemLineHistory_Holder.AssemblyLines[_latest-1].AssemblyStatus == ASSEMBLYSTATUS_CAN { continue }
+
+		//Looping through the array of assemblies
+		for _, res := range assemLineHistory_Holder.AssemblyLines {
+		
+			//Check the filter condition YYYYMMDDHHMMSS
+			/*
+			if len(res.AssemblyDate) != 14 {return nil, tntError(ERR_INVALID_ARGUMENT, "assemblyDate", "AssemblyDate must be 14 digit datetime field.")}
+			if _assemblyDateInt64, err = strconv.ParseInt(res.AssemblyDate, 10, 64); err != nil { errors.New ("Error in converting AssemblyDate to int64")}
+			*/
+			//Skip if not a valid date YYYYMMDDHHMMSS
+			if len(res.AssemblyDate) == 14 {
+				if _assemblyDateInt64, err = strconv.ParseInt(res.AssemblyDate, 10, 64); err == nil { 
+					if	_assemblyDateInt64 >= _fromDate		&&
+						_assemblyDateInt64 <= _toDate		{ 
+						_assemblyFlag = 1
+					} 
+				}
+			}
+						
+			// Append Assembly to Assembly Array if the flag is 1 (indicates valid for filter criteria)
+			if _assemblyFlag == 1 {
+				res2E=append(res2E,res)
+			}
+			
+			//re-setting the flag and AssemblyDate
+				_assemblyFlag = 0
+				_assemblyDateInt64 = 0
+		} // For assemLineHistory_Holder.AssemblyLines ends
+	} // For assemID_Holder.AssemblyIDs ends
+
+    mapB, _ := json.Marshal(res2E)
+    //fmt.Println(string(mapB))
+	return mapB, nil
+}
+
+
+//get all Assemblies History based on Type & BatchNo & From & To Date
+func (t *TnT) getAssembliesHistoryByBatchNumberAndByDate(stub Stub, args []string) ([]byte, error) {
+
+	
+	_includeCancelled, err := includeCancelledArg(args, 5)
+	if err != nil { return nil, err }
+
+	// YYYYMMDDHHMMSS (e.g. 20170612235959) handled as Int64
+	//var _fromDate int64
+	//var _toDate int64
+	
+	_batchType:= args[0]
+	_batchNumber:= args[1]
+	_assemblyFlag:= 0
+
+	_fromDate, err := parseDateArg(args[2], "fromDate")
+	if err != nil { return nil, err }
+	
+	_toDate, err := parseDateArg(args[3], "toDate")
+	if err != nil { return nil, err }
+
+	bytes, err := stub.GetState("Assemblies")
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+	var assemID_Holder AssemblyID_Holder
+	var _assemblyDateInt64 int64
+	
+	err = json.Unmarshal(bytes, &assemID_Holder)
+	if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Assemblies") }
+
+	// Array of filtered Assemblies
+	res2E:= []AssemblyLine{}	
+	// Filtered Assembly
+	//res := new(AssemblyLine)
+	
+	//Looping through the array of assemblyids
+	for _, assemblyId := range assemID_Holder.AssemblyIDs {
+
+		//Get the AssemblyLine History for each AssemblyID
+		bytesAssemblyLinesHistoryByID, err := getHistoryState(stub, assemblyId, &AssemblyLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get bytesAssemblyLinesHistoryByID") }
+
+		var assemLineHistory_Holder AssemblyLine_Holder
+
+		err = json.Unmarshal(bytesAssemblyLinesHistoryByID, &assemLineHistory_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt assemLineHistory_Holder record") }
+
+		//Skip Assemblies cancelled since - the latest version is the current status
+		_latest := len(assemLineHistory_Holder.AssemblyLines)
+		if !_includeCancelled && _latest > 0 && assemLineHistory_Holder.AssemblyLines[_latest-1].AssemblyStatus == ASSEMBLYSTATUS_CAN { continue }
+
+		//re-setting the flag and AssemblyDate
+		_assemblyFlag = 0
+		_assemblyDateInt64 = 0
+
+		//Looping through the array of assemblies to check if the filter condition matches - then consider the Assembly for response (latest status only)
+		for _, res := range assemLineHistory_Holder.AssemblyLines {
+		
+			//Check the filter condition YYYYMMDDHHMMSS
+			/*
+			if len(res.AssemblyDate) != 14 {return nil, tntError(ERR_INVALID_ARGUMENT, "assemblyDate", "AssemblyDate must be 14 digit datetime field.")}
+			if _assemblyDateInt64, err = strconv.ParseInt(res.AssemblyDate, 10, 64); err != nil { errors.New ("Error in converting AssemblyDate to int64")}
+			*/
+			//Skip if not a valid date YYYYMMDDHHMMSS
+			if len(res.AssemblyDate) == 14 {
+				if _assemblyDateInt64, err = strconv.ParseInt(res.AssemblyDate, 10, 64); err == nil { 
+					if	_assemblyDateInt64 >= _fromDate		&&
+						_assemblyDateInt64 <= _toDate		{ 
+							if 		   _batchType == FIL_BATCH					&&
+										res.FilamentBatchId == _batchNumber		{ 
+										_assemblyFlag = 1
+							} else if  _batchType == LED_BATCH					&&
+										res.LedBatchId == _batchNumber			{ 
+										_assemblyFlag = 1
+							} else if  _batchType == CIR_BATCH					&&
+										res.CircuitBoardBatchId == _batchNumber	{ 
+										_assemblyFlag = 1
+							} else if  _batchType == WRE_BATCH					&&
+										res.WireBatchId == _batchNumber			{ 
+										_assemblyFlag = 1
+							} else if  _batchType == CAS_BATCH					&&
+										res.CasingBatchId == _batchNumber		{ 
+										_assemblyFlag = 1
+							} else if  _batchType == ADP_BATCH					&&
+										res.AdaptorBatchId == _batchNumber		{ 
+										_assemblyFlag = 1
+							} else if  _batchType == STK_BATCH					&&
+										res.StickPodBatchId == _batchNumber		{ 
+										_assemblyFlag = 1
+							}
+					} // Date check
+				}// Date parse
+			}// Date lenght check
+						
+			// Append Assembly current status to Assembly Selection Array if the flag is 1 (indicates valid for filter criteria)
+			if _assemblyFlag == 1 {
+				latestIndex := len(assemLineHistory_Holder.AssemblyLines)
+				latestRes := assemLineHistory_Holder.AssemblyLines[latestIndex-1]
+				res2E=append(res2E,latestRes)
+				break // break the for loop as selected Assembly has been added to the list
+			}
+			
+			//re-setting the flag and AssemblyDate
+				_assemblyFlag = 0
+				_assemblyDateInt64 = 0
+		} // For assemLineHistory_Holder.AssemblyLines ends
+	} // For assemID_Holder.AssemblyIDs ends
+
+    mapB, _ := json.Marshal(res2E)
+    //fmt.Println(string(mapB))
+	return mapB, nil
+}
+
+// All AssemblyLine history
+func (t *TnT) getAssemblyLineHistoryByID(stub Stub, args []string) ([]byte, error) {
+
+	_assemblyId := args[0]
+
+
+	bytesAssemLineHolder, err := getHistoryState(stub, _assemblyId, &AssemblyLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+	if bytesAssemLineHolder == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "No history for Assembly " + _assemblyId) }
+
+	var assemLine_Holder AssemblyLine_Holder
+	err = json.Unmarshal(bytesAssemLineHolder, &assemLine_Holder)
+	if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt AssemblyLines record") }
+
+	mapB, _ := json.Marshal(assemLine_Holder)
+	return mapB, nil
+
+}
+
+/* Package section*/
+
+//API to create an Package
+// Assemblies related to the package is updated with status = PACKAGED
+// The shippingToAddress comes from the transient map, it goes to the private data collection and never on the ledger
+func (t *TnT) createPackage(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[9]
+	
+		_caseId := args[0]
+		_holderAssemblyId := args[1]
+		_chargerAssemblyId := args[2]
+		_packageStatus := args[3]
+		//ShippingToAddress, args[5], comes in the transient map - see checkTransientArgs
+		// Status of associated Assemblies	
+		_assemblyStatus:= args[6]
+		_packageInfo1:= args[7]
+		_packageInfo2:= args[8]
+
+		_time:= time.Now().UTC()
+
+		_packageCreationDate := _time.Format(DATE_FORMAT)
+		_packageLastUpdatedOn := _time.Format(DATE_FORMAT)
+		_packageCreatedBy := user_name
+		_packageLastUpdatedBy := user_name
+
+		//PackagingDate in UTC, in the plant of the packed Assemblies locally - checked by checkCreatePackage
+		_packagingDate, err := canonicalDate(args[4], "packagingDate")
+		if err != nil { return nil, err }
+		//ShippingToAddress as stored - checked by checkCreatePackage, only peers without private data take none
+		_shippingToAddress, err := getTransientField(stub, "shippingToAddress")
+		if err != nil { return nil, err }
+		if len(_shippingToAddress) > 0 {
+			_shippingToAddress, err = storedShippingAddress(_shippingToAddress)
+			if err != nil { return nil, err }
+		}
+		_plant, err := packagePlant(stub, _holderAssemblyId, _chargerAssemblyId)
+		if err != nil { return nil, err }
+		zones, err := getPlantTimeZones(stub)
+		if err != nil { return nil, err }
+
+		/* Package Merkle tree -----------------Starts */
+		// Leaves are the assemblies as packed - Holder first then Charger
+		var packMerkle_Holder PackageMerkle_Holder
+		packMerkle_Holder.CaseId = _caseId
+		packMerkle_Holder.SchemaVersion = SCHEMA_VERSION
+		for _, _packedAssemblyId := range []string{_holderAssemblyId, _chargerAssemblyId} {
+			if len(_packedAssemblyId) == 0 { continue }
+
+			packedAssemblyAsBytes, err := stub.GetState(_packedAssemblyId)
+			if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id")	}
+
+			packedAssem := AssemblyLine{}
+			json.Unmarshal(packedAssemblyAsBytes, &packedAssem)
+
+			packMerkle_Holder.Leaves = append(packMerkle_Holder.Leaves, assemblyMerkleLeaf(packedAssem, _caseId))
+		}
+		if len(packMerkle_Holder.Leaves) > 0 {
+			packMerkle_Holder.MerkleRoot, err = merkle.Root(packMerkle_Holder.Leaves)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error computing Package Merkle root") }
+		}
+
+		packMerkle_HolderKey := packageMerkleKey(_caseId)
+		bytesPackMerkle, err := json.Marshal(packMerkle_Holder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating PackageMerkle_Holder record") }
+		err = stub.PutState(packMerkle_HolderKey, bytesPackMerkle)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+		/* Package Merkle tree -----------------Ends */
+
+		//setting the Package to create
+		pack := PackageLine{}
+		pack.CaseId = _caseId
+		pack.HolderAssemblyId = _holderAssemblyId
+		pack.ChargerAssemblyId = _chargerAssemblyId
+		pack.PackageStatus = _packageStatus
+		pack.PackagingDate = _packagingDate
+		pack.PackagingDateLocal = zones.localDate(_packagingDate, _plant)
+		pack.PackageCreationDate = _packageCreationDate
+		pack.PackageLastUpdatedOn = _packageLastUpdatedOn
+		pack.PackageCreatedBy = _packageCreatedBy
+		pack.PackageLastUpdatedBy = _packageLastUpdatedBy
+		pack.PackageInfo1 = _packageInfo1
+		pack.PackageInfo2 = _packageInfo2
+		pack.PackageMerkleRoot = packMerkle_Holder.MerkleRoot
+		pack.SchemaVersion = SCHEMA_VERSION
+
+		// Shipping address goes to the private data collection, only its hash is public
+		pack.CustomerDetailsHash, err = putShippingToAddress(stub, _caseId, _shippingToAddress, _packageLastUpdatedOn, user_name)
+		if err != nil { return nil, err }
+
+		pack.PackageHash = computePackageHash(pack)
+		bytes, err := json.Marshal(pack)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Package record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting Package record") }
+
+		err = stub.PutState(_caseId, bytes)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Package record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing Package record") }
+
+
+		/* PackageLine history -----------------Starts */
+		// Initialises the PackageLine_Holder
+		var packLine_HolderInit PackageLine_Holder
+		packLine_HolderInit.SchemaVersion = SCHEMA_VERSION
+
+		packLine_HolderKey := historyKey(_caseId) // Indicates history key
+		bytesPackLinesInit, err := json.Marshal(packLine_HolderInit)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating packLine_HolderInit record") }
+		err = stub.PutState(packLine_HolderKey, bytesPackLinesInit)
+		/* PackageLine history -----------------Ends */
+
+		/* PackageLine history ------------------------------------------Starts */
+		//packLine_HolderKey := _caseId + "H" // Indicates history key
+
+		bytesPackageLines, err := getHistoryState(stub, _caseId, &PackageLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get bytesPackageLines") }
+
+		var packLine_Holder PackageLine_Holder
+
+		err = json.Unmarshal(bytesPackageLines, &packLine_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt bytesPackageLines record") }
+
+		packLine_Holder.PackageLines = append(packLine_Holder.PackageLines, pack) //appending the newly created pack
+		
+		bytesPackageLines, err = json.Marshal(packLine_Holder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating AssemblyLine_Holder record") }
+
+		err = stub.PutState(packLine_HolderKey, bytesPackageLines)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+		/* PackageLine history ------------------------------------------Ends */
+
+
+		fmt.Println("Created Package successfully")
+
+		//Update Holder Assemblies to Packaged status
+		if 	len(_holderAssemblyId) > 0	{
+			//_assemblyStatus:= "PACKAGED"
+			_time:= time.Now().UTC()
+			_assemblyLastUpdatedOn := _time.Format(DATE_FORMAT)
+			_assemblyLastUpdatedBy := _packageCreatedBy
+			_assemblyPackage:= _caseId // Keeping reference
+			
+			//get the Assembly
+			assemblyHolderAsBytes, err := stub.GetState(_holderAssemblyId)
+			if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id")	}
+			if assemblyHolderAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+			assemHolder := AssemblyLine{}
+			json.Unmarshal(assemblyHolderAsBytes, &assemHolder)
+
+			//update the AssemblyLine status
+			assemHolder.AssemblyStatus = _assemblyStatus
+			assemHolder.AssemblyLastUpdatedOn = _assemblyLastUpdatedOn
+			assemHolder.AssemblyLastUpdatedBy = _assemblyLastUpdatedBy
+			assemHolder.AssemblyPackage = _assemblyPackage
+			assemHolder.AssemblyInfo2 = "" // to reset the hascode to be updated later as part of package hash code update
+			//assemHolder.AssemblyInfo2 = _packageInfo2// specia case to store the transaction hash - This will never be the case on creation (only true for update) hence commented
+			
+			assemHolder.AssemblyHash = computeAssemblyHash(assemHolder)
+			bytesHolder, err := json.Marshal(assemHolder)
+			if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting Assembly record") }
+
+			err = stub.PutState(_holderAssemblyId, bytesHolder)
+			if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing Assembly record") }
+
+
+			/* AssemblyLine history ------------------------------------------Starts */
+			holderAssemLine_HolderKey := historyKey(_holderAssemblyId) // Indicates History Key for Assembly with ID = _assemblyId
+			bytesHolderAssemblyLines, err := getHistoryState(stub, _holderAssemblyId, &AssemblyLine_Holder{})
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+			var holderAssemLine_Holder AssemblyLine_Holder
+
+			err = json.Unmarshal(bytesHolderAssemblyLines, &holderAssemLine_Holder)
+			if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt AssemblyLines record") }
+
+			holderAssemLine_Holder.AssemblyLines = append(holderAssemLine_Holder.AssemblyLines, assemHolder) //appending the updated AssemblyLine
+			
+			bytesHolderAssemblyLines, err = json.Marshal(holderAssemLine_Holder)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating AssemblyLine_Holder record") }
+
+			err = stub.PutState(holderAssemLine_HolderKey, bytesHolderAssemblyLines)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+			/* AssemblyLine history ------------------------------------------Ends */
+
+
+			}
+
+		//Update Charger Assemblies to Packaged status
+		if 	len(_chargerAssemblyId) > 0		{
+			//_assemblyStatus:= "PACKAGED"
+			_time:= time.Now().UTC()
+			_assemblyLastUpdatedOn := _time.Format(DATE_FORMAT)
+			_assemblyLastUpdatedBy := _packageCreatedBy
+			_assemblyPackage:= _caseId // Keeping reference
+
+			//get the Assembly
+			assemblyChargerAsBytes, err := stub.GetState(_chargerAssemblyId)
+			if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id")	}
+			if assemblyChargerAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+			assemCharger := AssemblyLine{}
+			json.Unmarshal(assemblyChargerAsBytes, &assemCharger)
+
+			//update the AssemblyLine status
+			assemCharger.AssemblyStatus = _assemblyStatus
+			assemCharger.AssemblyLastUpdatedOn = _assemblyLastUpdatedOn
+			assemCharger.AssemblyLastUpdatedBy = _assemblyLastUpdatedBy
+			assemCharger.AssemblyPackage = _assemblyPackage
+			assemCharger.AssemblyInfo2 = "" // to reset the hascode to be updated later as part of package hash code update
+
+			
+			assemCharger.AssemblyHash = computeAssemblyHash(assemCharger)
+			bytesCharger, err := json.Marshal(assemCharger)
+			if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting Assembly record") }
+
+			err = stub.PutState(_chargerAssemblyId, bytesCharger)
+			if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing Assembly record") }
+
+
+			/* AssemblyLine history ------------------------------------------Starts */
+			chargerAssemLine_HolderKey := historyKey(_chargerAssemblyId) // Indicates History Key for Assembly with ID = _assemblyId
+			bytesChargerAssemblyLines, err := getHistoryState(stub, _chargerAssemblyId, &AssemblyLine_Holder{})
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+			var chargerAssemLine_Holder AssemblyLine_Holder
+
+			err = json.Unmarshal(bytesChargerAssemblyLines, &chargerAssemLine_Holder)
+			if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt AssemblyLines record") }
+
+			chargerAssemLine_Holder.AssemblyLines = append(chargerAssemLine_Holder.AssemblyLines, assemCharger) //appending the updated AssemblyLine
+			
+			bytesChargerAssemblyLines, err = json.Marshal(chargerAssemLine_Holder)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating AssemblyLine_Holder record") }
+
+			err = stub.PutState(chargerAssemLine_HolderKey, bytesChargerAssemblyLines)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+			/* AssemblyLine history ------------------------------------------Ends */
+
+		}
+
+	/* GetAll changes-------------------------starts--------------------------*/
+		// Holding the PackageCaseIDs in State separately
+		bytesPackageCaseHolder, err := stub.GetState("Packages")
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Packages") }
+
+		var packageCaseID_Holder PackageCaseID_Holder
+
+		err = json.Unmarshal(bytesPackageCaseHolder, &packageCaseID_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Packages record") }
+
+		packageCaseID_Holder.PackageCaseIDs = append(packageCaseID_Holder.PackageCaseIDs, _caseId)
+		
+		bytesPackageCaseHolder, err = json.Marshal(packageCaseID_Holder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating PackageCaseID_Holder record") }
+
+		err = stub.PutState("Packages", bytesPackageCaseHolder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+	/* GetAll changes---------------------------ends------------------------ */
+
+
+		return nil, nil
+
+}
+
+
+//API to update an Package
+// Assemblies related to the package is updated with status sent as parameter
+// A shippingToAddress in the transient map replaces the stored one
+func (t *TnT) updatePackage(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[9]
+		
+		_caseId := args[0]
+		//_holderAssemblyId := args[1]
+		//_chargerAssemblyId := args[2]
+		_packageStatus := args[3]
+		//ShippingToAddress, args[5], comes in the transient map - see checkTransientArgs
+		// Status of associated Assemblies	
+		_assemblyStatus := args[6]
+		_packageInfo1:= args[7]
+		_packageInfo2:= args[8]
+
+		_time:= time.Now().UTC()
+
+		//_packageCreationDate := _time.Format("2006-01-02")
+		_packageLastUpdatedOn := _time.Format(DATE_FORMAT)
+		//_packageCreatedBy := ""
+		_packageLastUpdatedBy := user_name
+
+
+		//PackagingDate in UTC - checked by checkUpdatePackage
+		_packagingDate, err := canonicalDate(args[4], "packagingDate")
+		if err != nil { return nil, err }
+		zones, err := getPlantTimeZones(stub)
+		if err != nil { return nil, err }
+
+		//ShippingToAddress as stored - empty keeps the stored one
+		_shippingToAddress, err := getTransientField(stub, "shippingToAddress")
+		if err != nil { return nil, err }
+		if len(_shippingToAddress) > 0 {
+			_shippingToAddress, err = storedShippingAddress(_shippingToAddress)
+			if err != nil { return nil, err }
+		}
+
+	//Getting the Package - checked by checkUpdatePackage
+		packageAsBytes, err := stub.GetState(_caseId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Package") }
+
+		//setting the Package to update
+		pack := PackageLine{}
+		json.Unmarshal(packageAsBytes, &pack)
+
+		_plant, err := packagePlant(stub, pack.HolderAssemblyId, pack.ChargerAssemblyId)
+		if err != nil { return nil, err }
+
+		//pack.CaseId = _caseId
+		//pack.HolderAssemblyId = _holderAssemblyId
+		//pack.ChargerAssemblyId = _chargerAssemblyId
+		pack.PackageStatus = _packageStatus
+		pack.PackagingDate = _packagingDate
+		pack.PackagingDateLocal = zones.localDate(_packagingDate, _plant)
+		//pack.PackageCreationDate = _packageCreationDate
+		pack.PackageLastUpdatedOn = _packageLastUpdatedOn
+		//pack.PackageCreatedBy = _packageCreatedBy
+		pack.PackageLastUpdatedBy = _packageLastUpdatedBy
+		pack.PackageInfo1 = _packageInfo1
+		pack.PackageInfo2 = _packageInfo2
+
+		// Shipping address goes to the private data collection, only its hash is public
+		pack.ShippingToAddress = ""
+		pack.CustomerDetailsHash, err = putShippingToAddress(stub, _caseId, _shippingToAddress, _packageLastUpdatedOn, user_name)
+		if err != nil { return nil, err }
+
+		// Getting associate Assembly IDs
+		_holderAssemblyId := pack.HolderAssemblyId
+		_chargerAssemblyId := pack.ChargerAssemblyId
+
+
+		pack.PackageHash = computePackageHash(pack)
+		bytes, err := json.Marshal(pack)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Package record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting Package record") }
+
+		err = stub.PutState(_caseId, bytes)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Package record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing Package record") }
+
+
+		/* PackageLine history ------------------------------------------Starts */
+		packLine_HolderKey := historyKey(_caseId) // Indicates history key
+
+		bytesPackageLines, err := getHistoryState(stub, _caseId, &PackageLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get bytesPackageLines") }
+
+		var packLine_Holder PackageLine_Holder
+
+		err = json.Unmarshal(bytesPackageLines, &packLine_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt bytesPackageLines record") }
+
+		packLine_Holder.PackageLines = append(packLine_Holder.PackageLines, pack) //appending the newly created pack
+		
+		bytesPackageLines, err = json.Marshal(packLine_Holder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating AssemblyLine_Holder record") }
+
+		err = stub.PutState(packLine_HolderKey, bytesPackageLines)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+		/* PackageLine history ------------------------------------------Ends */
+
+		fmt.Println("Created Package successfully")
+
+		//Update Holder Assemblies status
+		if 	len(_holderAssemblyId) > 0	{
+			//_assemblyStatus:= "PACKAGED"
+			_time:= time.Now().UTC()
+			_assemblyLastUpdatedOn := _time.Format(DATE_FORMAT)
+			_assemblyLastUpdatedBy := _packageLastUpdatedBy
+			_assemblyPackage:= _caseId // Keeping reference
+
+			//get the Assembly
+			assemblyHolderAsBytes, err := stub.GetState(_holderAssemblyId)
+			if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id")	}
+			if assemblyHolderAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+			assemHolder := AssemblyLine{}
+			json.Unmarshal(assemblyHolderAsBytes, &assemHolder)
+
+			// Don't update assembly if there is no chnage in status
+			// Update only when status moves say from Packaged -> Cancelled	
+			if assemHolder.AssemblyStatus != _assemblyStatus {
+
+				//update the AssemblyLine status
+				assemHolder.AssemblyStatus = _assemblyStatus
+				assemHolder.AssemblyLastUpdatedOn = _assemblyLastUpdatedOn
+				assemHolder.AssemblyLastUpdatedBy = _assemblyLastUpdatedBy
+				assemHolder.AssemblyPackage = _assemblyPackage
+				assemHolder.AssemblyInfo2 = "" // to reset the hascode to be updated later as part of package hash code update
+				
+				assemHolder.AssemblyHash = computeAssemblyHash(assemHolder)
+				bytesHolder, err := json.Marshal(assemHolder)
+				if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting Assembly record") }
+
+				err = stub.PutState(_holderAssemblyId, bytesHolder)
+				if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing Assembly record") }
+
+
+				/* AssemblyLine history ------------------------------------------Starts */
+				holderAssemLine_HolderKey := historyKey(_holderAssemblyId) // Indicates History Key for Assembly with ID = _assemblyId
+				bytesHolderAssemblyLines, err := getHistoryState(stub, _holderAssemblyId, &AssemblyLine_Holder{})
+				if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+				var holderAssemLine_Holder AssemblyLine_Holder
+
+				err = json.Unmarshal(bytesHolderAssemblyLines, &holderAssemLine_Holder)
+				if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt AssemblyLines record") }
+
+				holderAssemLine_Holder.AssemblyLines = append(holderAssemLine_Holder.AssemblyLines, assemHolder) //appending the updated AssemblyLine
+				
+				bytesHolderAssemblyLines, err = json.Marshal(holderAssemLine_Holder)
+				if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating AssemblyLine_Holder record") }
+
+				err = stub.PutState(holderAssemLine_HolderKey, bytesHolderAssemblyLines)
+				if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+				/* AssemblyLine history ------------------------------------------Ends */
+			}// Change of Status ends	
+
+		}
+
+		//Update Charger Assemblies status
+		if 	len(_chargerAssemblyId) > 0		{
+			//_assemblyStatus:= "PACKAGED"
+			_time:= time.Now().UTC()
+			_assemblyLastUpdatedOn := _time.Format(DATE_FORMAT)
+			_assemblyLastUpdatedBy := _packageLastUpdatedBy
+			_assemblyPackage:= _caseId // Keeping reference
+
+			//get the Assembly
+			assemblyChargerAsBytes, err := stub.GetState(_chargerAssemblyId)
+			if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id")	}
+			if assemblyChargerAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+			assemCharger := AssemblyLine{}
+			json.Unmarshal(assemblyChargerAsBytes, &assemCharger)
+
+			// Don't update assembly if there is no chnage in status
+			// Update only when status moves say from Packaged -> Cancelled	
+			if assemCharger.AssemblyStatus != _assemblyStatus {
+				//update the AssemblyLine status
+				assemCharger.AssemblyStatus = _assemblyStatus
+				assemCharger.AssemblyLastUpdatedOn = _assemblyLastUpdatedOn
+				assemCharger.AssemblyLastUpdatedBy = _assemblyLastUpdatedBy
+				assemCharger.AssemblyPackage = _assemblyPackage
+				assemCharger.AssemblyInfo2 = "" // to reset the hascode to be updated later as part of package hash code update
+
+				assemCharger.AssemblyHash = computeAssemblyHash(assemCharger)
+				bytesCharger, err := json.Marshal(assemCharger)
+				if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting Assembly record") }
+
+				err = stub.PutState(_chargerAssemblyId, bytesCharger)
+				if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing Assembly record") }
+
+
+				/* AssemblyLine history ------------------------------------------Starts */
+				chargerAssemLine_HolderKey := historyKey(_chargerAssemblyId) // Indicates History Key for Assembly with ID = _assemblyId
+				bytesChargerAssemblyLines, err := getHistoryState(stub, _chargerAssemblyId, &AssemblyLine_Holder{})
+				if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+				var chargerAssemLine_Holder AssemblyLine_Holder
+
+				err = json.Unmarshal(bytesChargerAssemblyLines, &chargerAssemLine_Holder)
+				if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt AssemblyLines record") }
+
+				chargerAssemLine_Holder.AssemblyLines = append(chargerAssemLine_Holder.AssemblyLines, assemCharger) //appending the updated AssemblyLine
+				
+				bytesChargerAssemblyLines, err = json.Marshal(chargerAssemLine_Holder)
+				if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating AssemblyLine_Holder record") }
+
+				err = stub.PutState(chargerAssemLine_HolderKey, bytesChargerAssemblyLines)
+				if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+				/* AssemblyLine history ------------------------------------------Ends */
+			}// Check if status changes
+
+		}
+
+		return nil, nil
+
+}
+
+
+//API to update an Package HashCode
+// Assemblies related to the package is updated with hashcode 
+// Parameters: CAS0001, HASHCODE, USERNAME
+func (t *TnT) updatePackageInfo2ById(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[2]
+		
+		_caseId := args[0]
+		_packageInfo2:= args[1]
+
+		_time:= time.Now().UTC()
+		//_packageCreationDate := _time.Format("2006-01-02")
+		_packageLastUpdatedOn := _time.Format(DATE_FORMAT)
+		//_packageCreatedBy := ""
+		_packageLastUpdatedBy := user_name
+
+
+	//Checking if the Package exists
+		packageAsBytes, err := stub.GetState(_caseId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Package") }
+		if packageAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "caseId", "Package doesn't exists") }
+
+		//setting the Package to update
+		pack := PackageLine{}
+		json.Unmarshal(packageAsBytes, &pack)
+
+		// Update only when PackageInfo2 is not set to avoid uncenessary duplicate updates
+		if len(pack.PackageInfo2) == 0 {
+			pack.PackageLastUpdatedOn = _packageLastUpdatedOn
+			pack.PackageLastUpdatedBy = _packageLastUpdatedBy
+			pack.PackageInfo2 = _packageInfo2
+			
+			// Getting associate Assembly IDs
+			_holderAssemblyId := pack.HolderAssemblyId
+			_chargerAssemblyId := pack.ChargerAssemblyId
+
+
+			pack.PackageHash = computePackageHash(pack)
+			bytes, err := json.Marshal(pack)
+			if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Package record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting Package record") }
+
+			err = stub.PutState(_caseId, bytes)
+			if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Package record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing Package record") }
+
+
+			/* PackageLine history ------------------------------------------Starts */
+			packLine_HolderKey := historyKey(_caseId) // Indicates history key
+
+			bytesPackageLines, err := getHistoryState(stub, _caseId, &PackageLine_Holder{})
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get bytesPackageLines") }
+
+			var packLine_Holder PackageLine_Holder
+
+			err = json.Unmarshal(bytesPackageLines, &packLine_Holder)
+			if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt bytesPackageLines record") }
+
+			//packLine_Holder.PackageLines = append(packLine_Holder.PackageLines, pack) //appending the newly created pack
+			//Overwrite exisitng last element with the updated element - Don't apend but overwrite
+			latestIndex := len(packLine_Holder.PackageLines)
+			packLine_Holder.PackageLines[latestIndex-1] = pack
+
+
+			bytesPackageLines, err = json.Marshal(packLine_Holder)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating AssemblyLine_Holder record") }
+
+			err = stub.PutState(packLine_HolderKey, bytesPackageLines)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+			/* PackageLine history ------------------------------------------Ends */
+
+			fmt.Println("Updated Package successfully")
+
+			//Update Holder Assemblies status
+			if 	len(_holderAssemblyId) > 0	{
+				//_assemblyStatus:= "PACKAGED"
+				_time:= time.Now().UTC()
+				_assemblyLastUpdatedOn := _time.Format(DATE_FORMAT)
+				_assemblyLastUpdatedBy := _packageLastUpdatedBy
+				//_assemblyPackage:= _caseId // Keeping reference
+				_assemblyInfo2:= _packageInfo2 // same hashcode as used for package update
+
+				//get the Assembly
+				assemblyHolderAsBytes, err := stub.GetState(_holderAssemblyId)
+				if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id")	}
+				if assemblyHolderAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+				assemHolder := AssemblyLine{}
+				json.Unmarshal(assemblyHolderAsBytes, &assemHolder)
+
+				// Don't update assembly if there is no chnage in status
+				// Update only when AssemblyInfo2 is not set to avoid uncenessary duplicate updates
+				if len(assemHolder.AssemblyInfo2) == 0 {
+
+					//update the AssemblyLine status
+					//assemHolder.AssemblyStatus = _assemblyStatus
+					assemHolder.AssemblyLastUpdatedOn = _assemblyLastUpdatedOn
+					assemHolder.AssemblyLastUpdatedBy = _assemblyLastUpdatedBy
+					//assemHolder.AssemblyPackage = _assemblyPackage
+					assemHolder.AssemblyInfo2 = _assemblyInfo2
+
+					
+					assemHolder.AssemblyHash = computeAssemblyHash(assemHolder)
+					bytesHolder, err := json.Marshal(assemHolder)
+					if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting Assembly record") }
+
+					err = stub.PutState(_holderAssemblyId, bytesHolder)
+					if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing Assembly record") }
+
+
+					/* AssemblyLine history ------------------------------------------Starts */
+					holderAssemLine_HolderKey := historyKey(_holderAssemblyId) // Indicates History Key for Assembly with ID = _assemblyId
+					bytesHolderAssemblyLines, err := getHistoryState(stub, _holderAssemblyId, &AssemblyLine_Holder{})
+					if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+					var holderAssemLine_Holder AssemblyLine_Holder
+
+					err = json.Unmarshal(bytesHolderAssemblyLines, &holderAssemLine_Holder)
+					if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt AssemblyLines record") }
+
+					//holderAssemLine_Holder.AssemblyLines = append(holderAssemLine_Holder.AssemblyLines, assemHolder) //appending the updated AssemblyLine
+
+					//Overwrite exisitng last element with the updated element - Don't apend but overwrite
+					latestIndex := len(holderAssemLine_Holder.AssemblyLines)
+					holderAssemLine_Holder.AssemblyLines[latestIndex-1] = assemHolder
+					
+					bytesHolderAssemblyLines, err = json.Marshal(holderAssemLine_Holder)
+					if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating AssemblyLine_Holder record") }
+
+					err = stub.PutState(holderAssemLine_HolderKey, bytesHolderAssemblyLines)
+					if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+					/* AssemblyLine history ------------------------------------------Ends */
+				}// len(assemHolder.AssemblyInfo2) > 0 	
+
+			}
+
+			//Update Charger Assemblies status
+			if 	len(_chargerAssemblyId) > 0		{
+				//_assemblyStatus:= "PACKAGED"
+				_time:= time.Now().UTC()
+				_assemblyLastUpdatedOn := _time.Format(DATE_FORMAT)
+				_assemblyLastUpdatedBy := _packageLastUpdatedBy
+				//_assemblyPackage:= _caseId // Keeping reference
+				_assemblyInfo2:= _packageInfo2 // same hashcode as used for package update
+
+				//get the Assembly
+				assemblyChargerAsBytes, err := stub.GetState(_chargerAssemblyId)
+				if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id")	}
+				if assemblyChargerAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+				assemCharger := AssemblyLine{}
+				json.Unmarshal(assemblyChargerAsBytes, &assemCharger)
+
+				// Don't update assembly if there is no chnage in status
+				// Update only when AssemblyInfo2 is not set to avoid uncenessary duplicate updates
+				if len(assemCharger.AssemblyInfo2) == 0 {
+					//update the AssemblyLine status
+					//assemCharger.AssemblyStatus = _assemblyStatus
+					assemCharger.AssemblyLastUpdatedOn = _assemblyLastUpdatedOn
+					assemCharger.AssemblyLastUpdatedBy = _assemblyLastUpdatedBy
+					//assemCharger.AssemblyPackage = _assemblyPackage
+					assemCharger.AssemblyInfo2 = _assemblyInfo2
+
+					assemCharger.AssemblyHash = computeAssemblyHash(assemCharger)
+					bytesCharger, err := json.Marshal(assemCharger)
+					if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting Assembly record") }
+
+					err = stub.PutState(_chargerAssemblyId, bytesCharger)
+					if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing Assembly record") }
+
+
+					/* AssemblyLine history ------------------------------------------Starts */
+					chargerAssemLine_HolderKey := historyKey(_chargerAssemblyId) // Indicates History Key for Assembly with ID = _assemblyId
+					bytesChargerAssemblyLines, err := getHistoryState(stub, _chargerAssemblyId, &AssemblyLine_Holder{})
+					if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+					var chargerAssemLine_Holder AssemblyLine_Holder
+
+					err = json.Unmarshal(bytesChargerAssemblyLines, &chargerAssemLine_Holder)
+					if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt AssemblyLines record") }
+
+					//chargerAssemLine_Holder.AssemblyLines = append(chargerAssemLine_Holder.AssemblyLines, assemCharger) //appending the updated AssemblyLine
+					
+					//Overwrite exisitng last element with the updated element - Don't apend but overwrite
+					latestIndex := len(chargerAssemLine_Holder.AssemblyLines)
+					chargerAssemLine_Holder.AssemblyLines[latestIndex-1] = assemCharger
+					
+					bytesChargerAssemblyLines, err = json.Marshal(chargerAssemLine_Holder)
+					if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating AssemblyLine_Holder record") }
+
+					err = stub.PutState(chargerAssemLine_HolderKey, bytesChargerAssemblyLines)
+					if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+					/* AssemblyLine history ------------------------------------------Ends */
+				}// len(assemCharger.AssemblyInfo2) > 0
+			} //len(_chargerAssemblyId) > 0	
+		}
+
+		return nil, nil
+
+}
+
+
+//get the Package against ID
+func (t *TnT) getPackageByID(stub Stub, args []string) ([]byte, error) {
+
+	_caseId := args[0]
+	
+	//get the var from chaincode state
+	valAsbytes, err := stub.GetState(_caseId)									
+	if err != nil {
+		return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get state for " + _caseId)
+	}
+	if valAsbytes == nil { return nil, tntError(ERR_NOT_FOUND, "caseId", "Package doesn't exists") }
+
+	//Only a Package record is returned, never whatever else is stored under the key
+	pack := PackageLine{}
+	err = json.Unmarshal(valAsbytes, &pack)
+	if err != nil || pack.CaseId != _caseId { return nil, tntError(ERR_NOT_FOUND, "caseId", "Package doesn't exists") }
+
+	mapB, _ := json.Marshal(pack)
+	return mapB, nil
+
+}
+
+//get all Packages
+func (t *TnT) getAllPackages(stub Stub, args []string) ([]byte, error) {
+
+
+	_includeCancelled, err := includeCancelledArg(args, 1)
+	if err != nil { return nil, err }
+
+	bytesPackageCaseHolder, err := stub.GetState("Packages")
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Packages") }
+
+	var packageCaseID_Holder PackageCaseID_Holder
+
+	err = json.Unmarshal(bytesPackageCaseHolder, &packageCaseID_Holder)
+	if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Assemblies") }
+
+	res2E:= []*PackageLine{}	
+
+	for _, caseId := range packageCaseID_Holder.PackageCaseIDs {
+
+		//Get the existing AssemblyLine
+		packageAsBytes, err := stub.GetState(caseId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Assembly")}
+
+		if packageAsBytes != nil { 
+		res := new(PackageLine)
+		json.Unmarshal(packageAsBytes, &res)
+		if !_includeCancelled && res.PackageStatus == PACKAGESTATUS_CAN { continue }
+
+		// Append Assembly to Assembly Array
+		res2E=append(res2E,res)
+		} // If ends
+		} // For ends
+
+    mapB, _ := json.Marshal(res2E)
+    //fmt.Println(string(mapB))
+	return mapB, nil
+}
+
+
+//Statuses an Assembly only reaches through its own flow, never on createAssembly
+var reservedAssemblyStatuses = map[string]string{
+	ASSEMBLYSTATUS_QAF: "Status 'QA Failed' is set only through QA inspections",
+	ASSEMBLYSTATUS_PKG: "Status 'Packaged' is set only through createPackage",
+	ASSEMBLYSTATUS_CAN: "Use cancelAssembly to cancel an Assembly",
+	ASSEMBLYSTATUS_RET: "Status 'Returned' is set only through breakRMACase",
+	ASSEMBLYSTATUS_SCR: "Scrapping is recorded only through scrapAssembly",
+}
+
+//IDs are the keys of their records, the records kept next to them are under TYPE|ID keys - see Schema section
+func checkRecordId(_id string, _field string) error {
+	if strings.Contains(_id, "|") { return tntError(ERR_INVALID_ARGUMENT, _field, "IDs can't hold '|'") }
+	return nil
+}
+
+//All Validators to be called before Invoke
+//The invokes run the same checks through the dispatcher; the validate* queries are dry runs failing with the first problem
+
+//Checks before createAssembly
+func (t *TnT) checkCreateAssembly(stub Stub, args []string, report *Validation_Report) {
+
+	_assemblyId := args[0]
+	_deviceSerialNo:= args[1]
+	_deviceType:= args[2]
+	_assemblyStatus:= args[11]
+	_assemblyDate:= args[12]
+
+	if err := checkRecordId(_assemblyId, "assemblyId"); err != nil { report.add(err) }
+
+	//Check Date
+	if _, err := canonicalDate(_assemblyDate, "assemblyDate"); err != nil { report.add(err) }
+
+	//Statuses an Assembly only reaches through its own flow, never on create
+	if reason, ok := reservedAssemblyStatuses[_assemblyStatus]; ok { report.fail(ERR_INVALID_TRANSITION, "assemblyStatus", reason) }
+
+	//Checking if the Assembly already exists
+	assemblyAsBytes, err := stub.GetState(_assemblyId)
+	if err != nil { report.fail(ERR_CORRUPT_STATE, "", "Failed to get assembly Id"); return }
+	if assemblyAsBytes != nil { report.fail(ERR_ALREADY_EXISTS, "assemblyId", "Assembly already exists") }
+
+	//Checking if the DeviceSerialNo is free for the DeviceType
+	if len(_deviceSerialNo) == 0 { report.fail(ERR_INVALID_ARGUMENT, "deviceSerialNo", "DeviceSerialNo supplied as empty"); return }
+	_registeredAssemblyId, err := getRegisteredAssemblyId(stub, _deviceSerialNo, _deviceType)
+	if err != nil { report.add(err); return }
+	if len(_registeredAssemblyId) > 0 { report.fail(ERR_ALREADY_EXISTS, "deviceSerialNo", "DeviceSerialNo " + _deviceSerialNo + " already registered to Assembly " + _registeredAssemblyId) }
+}
+
+//Checks before updateAssemblyByID - the status transitions allowed to the AssemblyLine
+func (t *TnT) checkUpdateAssembly(stub Stub, args []string, report *Validation_Report) {
+
+	_assemblyId := args[0]
+	_deviceSerialNo:= args[1]
+	_assemblyStatus:= args[11]
+	_assemblyDate:= args[12]
+
+	//Check Date
+	if _, err := canonicalDate(_assemblyDate, "assemblyDate"); err != nil { report.add(err) }
+
+	//get the Assembly
+	assemblyAsBytes, err := stub.GetState(_assemblyId)
+	if err != nil { report.fail(ERR_CORRUPT_STATE, "", "Failed to get assembly Id"); return }
+	if assemblyAsBytes == nil { report.fail(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists"); return }
+
+	assem := AssemblyLine{}
+	json.Unmarshal(assemblyAsBytes, &assem)
+
+	//Cancelled and scrapped Assemblies are kept read only
+	if assem.AssemblyStatus == ASSEMBLYSTATUS_CAN { report.fail(ERR_INVALID_TRANSITION, "", "Assembly is cancelled"); return }
+	if assem.AssemblyStatus == ASSEMBLYSTATUS_SCR { report.fail(ERR_INVALID_TRANSITION, "", "Assembly is scrapped"); return }
+
+	//Cancelling and scrapping are done through cancelAssembly and scrapAssembly
+	if _assemblyStatus == ASSEMBLYSTATUS_CAN { report.fail(ERR_INVALID_TRANSITION, "assemblyStatus", "Use cancelAssembly to cancel an Assembly") }
+	if _assemblyStatus == ASSEMBLYSTATUS_SCR { report.fail(ERR_INVALID_TRANSITION, "assemblyStatus", "Scrapping is recorded only through scrapAssembly") }
+
+	//DeviceSerialNo is registered - changed only through reassignDeviceSerialNo
+	if _deviceSerialNo != assem.DeviceSerialNo { report.fail(ERR_INVALID_ARGUMENT, "deviceSerialNo", "DeviceSerialNo can't be changed on update, use reassignDeviceSerialNo") }
+
+	//AssemblyLine can't edit an Assembly once it is 'Ready For Packaging'
+	if assem.AssemblyStatus == ASSEMBLYSTATUS_RFP {
+		report.fail(ERR_FORBIDDEN, "user", "Permission denied for AssemblyLine Role to update Assembly if status = 'Ready For Packaging'")
+	}
+
+	//'QA Failed' is set and cleared only through QA inspections
+	if _assemblyStatus != assem.AssemblyStatus &&
+		(_assemblyStatus == ASSEMBLYSTATUS_QAF || assem.AssemblyStatus == ASSEMBLYSTATUS_QAF) {
+		report.fail(ERR_INVALID_TRANSITION, "assemblyStatus", "Status 'QA Failed' is changed only through QA inspections")
+	}
+
+	//A reworked Assembly needs a fresh QA pass before 'Ready For Packaging'
+	if _assemblyStatus == ASSEMBLYSTATUS_RFP && assem.AssemblyStatus != ASSEMBLYSTATUS_RFP {
+		_pendingQA, err := isReworkPendingQA(stub, _assemblyId)
+		if err != nil { report.add(err); return }
+		if _pendingQA { report.fail(ERR_INVALID_TRANSITION, "", "Reworked Assembly must pass QA inspection before 'Ready For Packaging'") }
+	}
+
+	//AssemblyLine can't move an Assembly to "Packaged" status directly; It is internally done in packaging line
+	if _assemblyStatus == ASSEMBLYSTATUS_PKG {
+		report.fail(ERR_INVALID_TRANSITION, "assemblyStatus", "Permission denied for updating AssemblyLine to status 'Packaged'")
+	}
+	if _assemblyStatus == ASSEMBLYSTATUS_RET && assem.AssemblyStatus != ASSEMBLYSTATUS_RET {
+		report.fail(ERR_INVALID_TRANSITION, "assemblyStatus", reservedAssemblyStatuses[ASSEMBLYSTATUS_RET])
+	}
+	if assem.AssemblyStatus == ASSEMBLYSTATUS_PKG && _assemblyStatus != ASSEMBLYSTATUS_PKG {
+		report.fail(ERR_INVALID_TRANSITION, "assemblyStatus", "Packaged Assembly changes status only with its Package")
+	}
+}
+
+//Checks before createPackage
+func (t *TnT) checkCreatePackage(stub Stub, args []string, report *Validation_Report) {
+
+	_caseId := args[0]
+	_holderAssemblyId := args[1]
+	_chargerAssemblyId := args[2]
+	_packageStatus := args[3]
+	_packagingDate := args[4]
+	_assemblyStatus := args[6]
+
+	if len(_caseId) == 0 { report.fail(ERR_INVALID_ARGUMENT, "caseId", "CaseId supplied as empty"); return }
+	if err := checkRecordId(_caseId, "caseId"); err != nil { report.add(err) }
+	if len(_holderAssemblyId) == 0 && len(_chargerAssemblyId) == 0 {
+		report.fail(ERR_INVALID_ARGUMENT, "holderAssemblyId", "A Package needs a Holder or a Charger Assembly")
+	}
+	if len(_holderAssemblyId) > 0 && _holderAssemblyId == _chargerAssemblyId {
+		report.fail(ERR_INVALID_ARGUMENT, "chargerAssemblyId", "Holder and Charger must be different Assemblies")
+	}
+
+	//A Package is created 'Packaged', along with its Assemblies
+	if _packageStatus != PACKAGESTATUS_PKG { report.fail(ERR_INVALID_ARGUMENT, "packageStatus", "PackageStatus must be " + PACKAGESTATUS_PKG + " (Packaged) on create") }
+	if _assemblyStatus != ASSEMBLYSTATUS_PKG { report.fail(ERR_INVALID_ARGUMENT, "assemblyStatus", "AssemblyStatus of the packed Assemblies must be " + ASSEMBLYSTATUS_PKG + " (Packaged) on create") }
+
+	//Check Date
+	checkPackagingDate(stub, _packagingDate, _holderAssemblyId, _chargerAssemblyId, report)
+
+	//Check the Shipping address - taken from the transient map, and kept privately
+	//Peers without private data, the legacy shim, take Packages without one
+	_shippingToAddress, err := getTransientField(stub, "shippingToAddress")
+	if err != nil {
+		report.add(err)
+	} else if len(_shippingToAddress) > 0 {
+		shippingAddress(_shippingToAddress, report)
+		if _, err := customerDetailsSalt(stub, _caseId); err != nil { report.add(err) }
+	} else if hasPrivateData(stub) {
+		report.fail(ERR_INVALID_ARGUMENT, "shippingToAddress", "ShippingToAddress supplied as empty")
+	}
+
+	//Checking if the Package already exists
+	packageAsBytes, err := stub.GetState(_caseId)
+	if err != nil { report.fail(ERR_CORRUPT_STATE, "", "Failed to get Package"); return }
+	if packageAsBytes != nil { report.fail(ERR_ALREADY_EXISTS, "caseId", "Package already exists") }
+
+	//Packed Assemblies must exist, not be in a case already and be 'Ready For Packaging', with no rework waiting for QA
+	for i, _field := range []string{"holderAssemblyId", "chargerAssemblyId"} {
+		_packedAssemblyId := args[1 + i]
+		if len(_packedAssemblyId) == 0 { continue }
+
+		packedAssemblyAsBytes, err := stub.GetState(_packedAssemblyId)
+		if err != nil { report.fail(ERR_CORRUPT_STATE, "", "Failed to get assembly Id"); return }
+		if packedAssemblyAsBytes == nil { report.fail(ERR_NOT_FOUND, _field, "Assembly " + _packedAssemblyId + " doesn't exists"); continue }
+
+		packedAssem := AssemblyLine{}
+		json.Unmarshal(packedAssemblyAsBytes, &packedAssem)
+		if len(packedAssem.AssemblyPackage) > 0 { report.fail(ERR_INVALID_TRANSITION, _field, "Assembly " + _packedAssemblyId + " is already packed in Package " + packedAssem.AssemblyPackage); continue }
+		if packedAssem.AssemblyStatus == ASSEMBLYSTATUS_PKG { report.fail(ERR_INVALID_TRANSITION, _field, "Assembly " + _packedAssemblyId + " is already packaged"); continue }
+		if packedAssem.AssemblyStatus == ASSEMBLYSTATUS_CAN { report.fail(ERR_INVALID_TRANSITION, _field, "Assembly " + _packedAssemblyId + " is cancelled"); continue }
+		if packedAssem.AssemblyStatus == ASSEMBLYSTATUS_SCR { report.fail(ERR_INVALID_TRANSITION, _field, "Assembly " + _packedAssemblyId + " is scrapped"); continue }
+		if packedAssem.AssemblyStatus == ASSEMBLYSTATUS_QAF { report.fail(ERR_INVALID_TRANSITION, _field, "Assembly " + _packedAssemblyId + " failed QA inspection"); continue }
+		if packedAssem.AssemblyStatus != ASSEMBLYSTATUS_RFP {
+			report.fail(ERR_INVALID_TRANSITION, _field, "Assembly " + _packedAssemblyId + " must be " + ASSEMBLYSTATUS_RFP + " (Ready For Packaging) to be packed, it is " + packedAssem.AssemblyStatus)
+			continue
+		}
+
+		_pendingQA, err := isReworkPendingQA(stub, _packedAssemblyId)
+		if err != nil { report.add(err); return }
+		if _pendingQA { report.fail(ERR_INVALID_TRANSITION, _field, "Assembly " + _packedAssemblyId + " was reworked and must pass QA inspection before packing") }
+	}
+}
+
+//Checks before updatePackage
+func (t *TnT) checkUpdatePackage(stub Stub, args []string, report *Validation_Report) {
+
+	_caseId := args[0]
+	_packageStatus := args[3]
+	_packagingDate := args[4]
+	_assemblyStatus := args[6]
+
+	packageAsBytes, err := stub.GetState(_caseId)
+	if err != nil { report.fail(ERR_CORRUPT_STATE, "", "Failed to get Package"); return }
+	if packageAsBytes == nil { report.fail(ERR_NOT_FOUND, "caseId", "Package doesn't exists"); return }
+
+	pack := PackageLine{}
+	json.Unmarshal(packageAsBytes, &pack)
+
+	//Cancelled and returned Packages are kept read only; cancelling is done through cancelPackage, returning through createRMA
+	if pack.PackageStatus == PACKAGESTATUS_CAN { report.fail(ERR_INVALID_TRANSITION, "", "Package is cancelled"); return }
+	if pack.PackageStatus == PACKAGESTATUS_RET || len(pack.PackageRMAId) > 0 { report.fail(ERR_INVALID_TRANSITION, "", "Package is returned under RMA " + pack.PackageRMAId); return }
+
+	//Packages stay 'Packaged' on update
+	if _packageStatus == PACKAGESTATUS_CAN {
+		report.fail(ERR_INVALID_TRANSITION, "packageStatus", "Use cancelPackage to cancel a Package")
+	} else if _packageStatus == PACKAGESTATUS_RET {
+		report.fail(ERR_INVALID_TRANSITION, "packageStatus", "Use createRMA to return a Package")
+	} else if _packageStatus != PACKAGESTATUS_PKG {
+		report.fail(ERR_INVALID_ARGUMENT, "packageStatus", "PackageStatus must be " + PACKAGESTATUS_PKG + " (Packaged)")
+	}
+
+	//Packed Assemblies stay 'Packaged' - they are released as 'Returned' only through breakRMACase
+	if _assemblyStatus == ASSEMBLYSTATUS_CAN {
+		report.fail(ERR_INVALID_TRANSITION, "assemblyStatus", "Use cancelPackage to cancel a Package")
+	} else if _assemblyStatus != ASSEMBLYSTATUS_PKG {
+		report.fail(ERR_INVALID_TRANSITION, "assemblyStatus", "AssemblyStatus of the packed Assemblies must stay " + ASSEMBLYSTATUS_PKG + " (Packaged)")
+	}
+
+	//Check Date - the packed Assemblies are the stored ones
+	checkPackagingDate(stub, _packagingDate, pack.HolderAssemblyId, pack.ChargerAssemblyId, report)
+
+	//Check the Shipping address from the transient map - empty keeps the stored one
+	_shippingToAddress, err := getTransientField(stub, "shippingToAddress")
+	if err != nil { report.add(err); return }
+	if len(_shippingToAddress) > 0 {
+		shippingAddress(_shippingToAddress, report)
+	} else if hasPrivateData(stub) {
+		details, err := getCustomerDetails(stub, _caseId)
+		if err != nil { report.add(err); return }
+		if len(details.ShippingToAddress) == 0 && len(pack.ShippingToAddress) == 0 {
+			report.fail(ERR_INVALID_ARGUMENT, "shippingToAddress", "ShippingToAddress supplied as empty and none stored")
+		}
+	}
+}
+
+//PackagingDate must be a valid date, not before the AssemblyDate of a packed Assembly
+func checkPackagingDate(stub Stub, _packagingDate string, _holderAssemblyId string, _chargerAssemblyId string, report *Validation_Report) {
+	_packagingTime, err := ParseDate(_packagingDate)
+	if err != nil { report.fail(ERR_INVALID_ARGUMENT, "packagingDate", err.Error()); return }
+
+	for _, _packedAssemblyId := range []string{_holderAssemblyId, _chargerAssemblyId} {
+		if len(_packedAssemblyId) == 0 { continue }
+
+		packedAssemblyAsBytes, err := stub.GetState(_packedAssemblyId)
+		if err != nil { report.fail(ERR_CORRUPT_STATE, "", "Failed to get assembly Id"); return }
+		if packedAssemblyAsBytes == nil { continue }
+
+		packedAssem := AssemblyLine{}
+		json.Unmarshal(packedAssemblyAsBytes, &packedAssem)
+
+		//Assemblies from before the dates were checked may have no AssemblyDate to compare with
+		_assemblyTime, err := time.Parse(DATE_FORMAT, packedAssem.AssemblyDate)
+		if err == nil && _packagingTime.Before(_assemblyTime) {
+			report.fail(ERR_INVALID_ARGUMENT, "packagingDate", "PackagingDate " + _packagingTime.Format(DATE_FORMAT) + " is before the AssemblyDate " + packedAssem.AssemblyDate + " of Assembly " + _packedAssemblyId + " (both UTC)")
+		}
+	}
+}
+
+//Shipping address of a Package - ShippingToAddress is given as its JSON, e.g.
+//{"line1":"1 Main St","city":"Kolkata","postalCode":"700001","country":"IN"}. Addresses stored before were free text
+type Shipping_Address struct {
+	Line1 		string `json:"line1"`
+	Line2 		string `json:"line2,omitempty"`
+	City 		string `json:"city"`
+	Region 		string `json:"region,omitempty"` // State, province or county
+	PostalCode 	string `json:"postalCode"`
+	Country 	string `json:"country"` // ISO 3166-1 alpha-2 code, e.g. IN
+}
+
+var postalCodePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 -]{0,8}[A-Za-z0-9]$`)
+var countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+//Checks a ShippingToAddress, reporting every field at fault, and returns it as stored - the compact JSON
+func shippingAddress(_shippingToAddress string, report *Validation_Report) string {
+	var address Shipping_Address
+	decoder := json.NewDecoder(strings.NewReader(_shippingToAddress))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&address); err != nil || decoder.More() {
+		report.fail(ERR_INVALID_ARGUMENT, "shippingToAddress", `ShippingToAddress must be a JSON address with line1, line2, city, region, postalCode and country, e.g. {"line1":"1 Main St","city":"Kolkata","postalCode":"700001","country":"IN"}`)
+		return ""
+	}
+
+	address.Line1 = strings.TrimSpace(address.Line1)
+	address.Line2 = strings.TrimSpace(address.Line2)
+	address.City = strings.TrimSpace(address.City)
+	address.Region = strings.TrimSpace(address.Region)
+	address.PostalCode = strings.TrimSpace(address.PostalCode)
+	address.Country = strings.TrimSpace(address.Country)
+
+	if len(address.Line1) == 0 { report.fail(ERR_INVALID_ARGUMENT, "shippingToAddress.line1", "Address line1 supplied as empty") }
+	if len(address.City) == 0 { report.fail(ERR_INVALID_ARGUMENT, "shippingToAddress.city", "Address city supplied as empty") }
+	if !postalCodePattern.MatchString(address.PostalCode) {
+		report.fail(ERR_INVALID_ARGUMENT, "shippingToAddress.postalCode", "Address postalCode must be 2 to 10 letters, digits, spaces or hyphens")
+	}
+	if !countryPattern.MatchString(address.Country) {
+		report.fail(ERR_INVALID_ARGUMENT, "shippingToAddress.country", "Address country must be an ISO 3166-1 alpha-2 code, e.g. IN")
+	}
+
+	bytes, _ := json.Marshal(address)
+	return string(bytes)
+}
+
+//ShippingToAddress as stored, failing with the first problem
+func storedShippingAddress(_shippingToAddress string) (string, error) {
+	report := Validation_Report{}
+	_address := shippingAddress(_shippingToAddress, &report)
+	return _address, report.err()
+}
+
+// Validator before createAssembly invoke call
+func (t *TnT) validateCreateAssembly(stub Stub, args []string) ([]byte, error) {
+	return nil, t.dryRunFunction(stub, "createAssembly", args).err()
+}
+
+// Validator before updateAssemblyByID invoke call
+func (t *TnT) validateUpdateAssembly(stub Stub, args []string) ([]byte, error) {
+	return nil, t.dryRunFunction(stub, "updateAssemblyByID", args).err()
+}
+
+// Validator before createPackage invoke call
+func (t *TnT) validateCreatePackage(stub Stub, args []string) ([]byte, error) {
+	return nil, t.dryRunFunction(stub, "createPackage", args).err()
+}
+
+// Validator before updatePackage invoke call
+func (t *TnT) validateUpdatePackage(stub Stub, args []string) ([]byte, error) {
+	return nil, t.dryRunFunction(stub, "updatePackage", args).err()
+}
+
+//AllAssemblyIDS
+//get the all Assembly IDs from AssemblyID_Holder - To Test only
+func (t *TnT) getAllAssemblyIDs(stub Stub, args []string) ([]byte, error) {
+
+	bytesAssemHolder, err := stub.GetState("Assemblies")
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+	return bytesAssemHolder, nil	
+
+}
+
+//AllPackageCaseIDs
+//get the all Package CaseIDs from PackageCaseID_Holder - To Test only
+func (t *TnT) getAllPackageCaseIDs(stub Stub, args []string) ([]byte, error) {
+
+	bytesPackageCaseHolder, err := stub.GetState("Packages")
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Packages") }
+
+	return bytesPackageCaseHolder, nil	
+
+}
+
+
+// All PackageLine history
+func (t *TnT) getPackageLineHistoryByID(stub Stub, args []string) ([]byte, error) {
+
+	_caseId := args[0]
+
+
+	bytesPackLineHolder, err := getHistoryState(stub, _caseId, &PackageLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get PackageLine history") }
+	if bytesPackLineHolder == nil { return nil, tntError(ERR_NOT_FOUND, "caseId", "No history for Package " + _caseId) }
+
+	return bytesPackLineHolder, nil	
+
+}
+// Search Package
+//get all Packages based on Assembly Id
+func (t *TnT) getPackagesByAssemblyId(stub Stub, args []string) ([]byte, error) {
+
+	
+	_includeCancelled, err := includeCancelledArg(args, 3)
+	if err != nil { return nil, err }
+
+	_assemblyType:= args[0]
+	_assemblyId := args[1]
+	_packageFlag:= 0
+
+	packages, err := selectPackages(stub, map[string]interface{}{jsonField(_assemblyType): _assemblyId}, jsonField(_assemblyType))
+	if err != nil { return nil, err }
+
+	res2E:= []*PackageLine{}	
+
+	for _, res := range packages {
+		if !_includeCancelled && res.PackageStatus == PACKAGESTATUS_CAN { continue }
+
+		//Check the filter condition
+		if 		   _assemblyType == HLD_ASSMB_TYP	&&
+					res.HolderAssemblyId == _assemblyId		{ 
+					_packageFlag = 1
+		} else if  _assemblyType == CHG_ASSMB_TYP	&&
+					res.ChargerAssemblyId == _assemblyId	{ 
+					_packageFlag = 1
+		}
+		
+
+		// Append Assembly to Assembly Array if the flag is 1 (indicates valid for filter criteria)
+		if _packageFlag == 1 {
+			res2E=append(res2E,res)
+		}
+		//re-setting the flag to 0
+		_packageFlag = 0
+	} // For ends
+
+    mapB, _ := json.Marshal(res2E)
+    //fmt.Println(string(mapB))
+	return mapB, nil
+}
+//get all Packages based on FromDate & ToDate and AssemblyId
+func (t *TnT) getPackagesByDate(stub Stub, args []string) ([]byte, error) {
+
+	
+	_includeCancelled, err := includeCancelledArg(args, 3)
+	if err != nil { return nil, err }
+
+	// YYYYMMDDHHMMSS (e.g. 20170612235959) handled as Int64
+	//var _fromDate int64
+	//var _toDate int64
+	
+	_fromDate, err := parseDateArg(args[0], "fromDate")
+	if err != nil { return nil, err }
+	
+	_toDate, err := parseDateArg(args[1], "toDate")
+	if err != nil { return nil, err }
+	
+	_packageFlag:= 0
+
+	var _packageDateInt64 int64
+
+	packages, err := selectPackages(stub, dateSelector("packagingDate", _fromDate, _toDate), "packagingDate")
+	if err != nil { return nil, err }
+
+	res2E:= []*PackageLine{}	
+
+	for _, res := range packages {
+
+		if !_includeCancelled && res.PackageStatus == PACKAGESTATUS_CAN { continue }
+
+		//fmt.Printf("%T, %v\n", _fromDate, _fromDate)
+		//fmt.Printf("%T, %v\n", _toDate, _toDate)
+		//if _fromDate == _toDate { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Assembly")}
+		
+		//Check the filter condition YYYYMMDDHHMMSS
+		if len(res.PackagingDate) != 14 {return nil, tntError(ERR_INVALID_ARGUMENT, "packagingDate", "PackagingDate must be 14 digit datetime field.")}
+		if _packageDateInt64, err = strconv.ParseInt(res.PackagingDate, 10, 64); err != nil { return nil, tntError(ERR_CORRUPT_STATE, "packagingDate", "Error in converting PackagingDate to int64")}
+		if	_packageDateInt64 >= _fromDate		&&
+			_packageDateInt64 <= _toDate		{ 
+			_packageFlag = 1
+		} 
+					
+		// Append Package Case to Package Array if the flag is 1 (indicates valid for filter criteria)
+		if _packageFlag == 1 {
+			res2E=append(res2E,res)
+		}
+	//re-setting the flag and PackageCreationDate
+		_packageFlag = 0
+		_packageDateInt64 = 0
+	} // For ends
+
+    mapB, _ := json.Marshal(res2E)
+    //fmt.Println(string(mapB))
+	return mapB, nil
+}
+
+//get all Package based on AssemblyID & From & To Date
+func (t *TnT) getPackageByAssemblyIdAndByDate(stub Stub, args []string) ([]byte, error) {
+
+	_includeCancelled, err := includeCancelledArg(args, 5)
+	if err != nil { return nil, err }
+
+	// YYYYMMDDHHMMSS (e.g. 20170612235959) handled as Int64
+	//var _fromDate int64
+	//var _toDate int64
+	_assemblyType:= args[0]
+	_assemblyId := args[1]
+	_fromDate, err := parseDateArg(args[2], "fromDate")
+	if err != nil { return nil, err }
+	
+	_toDate, err := parseDateArg(args[3], "toDate")
+	if err != nil { return nil, err }
+	
+	_packageFlag:= 0
+
+	var _packageDateInt64 int64
+
+	selector := dateSelector("packagingDate", _fromDate, _toDate)
+	selector[jsonField(_assemblyType)] = _assemblyId
+	packages, err := selectPackages(stub, selector, jsonField(_assemblyType))
+	if err != nil { return nil, err }
+
+	res2E:= []*PackageLine{}	
+
+	for _, res := range packages {
+
+		if !_includeCancelled && res.PackageStatus == PACKAGESTATUS_CAN { continue }
+
+		//fmt.Printf("%T, %v\n", _fromDate, _fromDate)
+		//fmt.Printf("%T, %v\n", _toDate, _toDate)
+		//if _fromDate == _toDate { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Assembly")}
+		
+		//Check the filter condition YYYYMMDDHHMMSS
+		if len(res.PackagingDate) != 14 {return nil, tntError(ERR_INVALID_ARGUMENT, "packagingDate", "PackagingDate must be 14 digit datetime field.")}
+		if _packageDateInt64, err = strconv.ParseInt(res.PackagingDate, 10, 64); err != nil { return nil, tntError(ERR_CORRUPT_STATE, "packagingDate", "Error in converting PackagingDate to int64")}
+		if	_packageDateInt64 >= _fromDate		&&
+			_packageDateInt64 <= _toDate{ 
+				if  _assemblyType == HLD_ASSMB_TYP	&&
+				res.HolderAssemblyId == _assemblyId	{ 
+				_packageFlag = 1
+			} else if  _assemblyType == CHG_ASSMB_TYP &&
+						res.ChargerAssemblyId == _assemblyId{ 
+						_packageFlag = 1
+			}
+		} 
+
+		// Append Package Case to Package Array if the flag is 1 (indicates valid for filter criteria)
+		if _packageFlag == 1 {
+			res2E=append(res2E,res)
+		}
+	//re-setting the flag and PackageCreationDate
+		_packageFlag = 0
+		_packageDateInt64 = 0
+	} // For ends
+
+    mapB, _ := json.Marshal(res2E)
+    //fmt.Println(string(mapB))
+	return mapB, nil
+
+}
+//get all Packages History based on FromDate & ToDate
+func (t *TnT) getPackagesHistoryByDate(stub Stub, args []string) ([]byte, error) {
+
+	
+	_includeCancelled, err := includeCancelledArg(args, 3)
+	if err != nil { return nil, err }
+
+	// YYYYMMDDHHMMSS (e.g. 20170612235959) handled as Int64
+	//var _fromDate int64
+	//var _toDate int64
+	
+	_fromDate, err := parseDateArg(args[0], "fromDate")
+	if err != nil { return nil, err }
+	
+	_toDate, err := parseDateArg(args[1], "toDate")
+	if err != nil { return nil, err }
+	
+	_packageFlag:= 0
+
+	bytes, err := stub.GetState("Packages")
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Packages") }
+
+	var packageCaseID_Holder PackageCaseID_Holder
+	var _packageDateInt64 int64
+	
+	err = json.Unmarshal(bytes, &packageCaseID_Holder)
+	if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Packages") }
+
+	// Array of filtered Package Line
+	res2E:= []PackageLine{}	
+
+	
+	//Looping through the array of packageCaseId
+	for _, caseId := range packageCaseID_Holder.PackageCaseIDs {
+
+		//Get the AssemblyLine History for each AssemblyID
+		bytesPackageHistoryLines, err := getHistoryState(stub, caseId, &PackageLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get bytesPackageHistoryLines") }
+
+		var packLine_Holder PackageLine_Holder
+
+		err = json.Unmarshal(bytesPackageHistoryLines, &packLine_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt History Holder record") }
+
+		//Skip Packages cancelled since - the latest version is the current status
+		_latest := len(packLine_Holder.PackageLines)
+		if !_includeCancelled && _latest > 0 && packLine_Holder.PackageLines[_latest-1].PackageStatus == PACKAGESTATUS_CAN { continue }
+
+		//Looping through the array of assemblies
+		for _, res := range packLine_Holder.PackageLines {
+		
+			
+			//Skip if not a valid date YYYYMMDDHHMMSS
+			if len(res.PackagingDate) == 14 {
+				if _packageDateInt64, err = strconv.ParseInt(res.PackagingDate, 10, 64); err == nil { 
+					if	_packageDateInt64 >= _fromDate		&&
+						_packageDateInt64 <= _toDate		{ 
+						_packageFlag = 1
+					} 
+				}
+			}
+						
+			// Append AssembPackagely to Package Array if the flag is 1 (indicates valid for filter criteria)
+			if _packageFlag == 1 {
+				res2E=append(res2E,res)
+			}
+			
+			//re-setting the flag and PackagingDate
+				_packageFlag = 0
+				_packageDateInt64 = 0
+		} // For packLine_Holder.PackageLines ends
+	} // For packageCaseID_Holder.PackageCaseIDs ends
+
+    mapB, _ := json.Marshal(res2E)
+    //fmt.Println(string(mapB))
+	return mapB, nil
+}
+
+
+
+
+/* Device Serial Number section */
+
+// Assembly registered against a DeviceSerialNo for one DeviceType
+type SerialNo_Registration struct {
+	DeviceType 		string `json:"deviceType"`
+	AssemblyId 		string `json:"assemblyId"`
+	RegisteredOn 	string `json:"registeredOn"`
+}
+
+//DeviceSerialNo registry - stored against "SN|" + DeviceSerialNo, at most one registration per DeviceType
+type SerialNo_Holder struct {
+	DeviceSerialNo 	string `json:"deviceSerialNo"`
+	Registrations 	[]SerialNo_Registration `json:"registrations"`
+	SchemaVersion 	Schema_Version `json:"schemaVersion"`
+}
+
+// One reassignment of an Assembly's DeviceSerialNo
+type SerialNo_Change struct {
+	OldDeviceSerialNo 	string `json:"oldDeviceSerialNo"`
+	NewDeviceSerialNo 	string `json:"newDeviceSerialNo"`
+	Reason 				string `json:"reason"`
+	ChangedOn 			string `json:"changedOn"`
+	ChangedBy 			string `json:"changedBy"`
+}
+
+//DeviceSerialNo reassignment history - stored against serialNoHistoryKey
+type SerialNo_History struct {
+	AssemblyId 	string `json:"assemblyId"`
+	Changes 	[]SerialNo_Change `json:"changes"`
+	SchemaVersion 	Schema_Version `json:"schemaVersion"`
+}
+
+func serialNoHistoryKey(_assemblyId string) string {
+	return "SNHIST|" + _assemblyId // Indicates DeviceSerialNo history key
+}
+
+func serialNoKey(_deviceSerialNo string) string {
+	return "SN|" + _deviceSerialNo // Indicates DeviceSerialNo registry key
+}
+
+//get the DeviceSerialNo registry entry, empty if the serial number was never registered
+func getSerialNoHolder(stub Stub, _deviceSerialNo string) (SerialNo_Holder, error) {
+	var serialNo_Holder SerialNo_Holder
+	serialNo_Holder.DeviceSerialNo = _deviceSerialNo
+
+	bytesSerialNo, err := stub.GetState(serialNoKey(_deviceSerialNo))
+	if err != nil { return serialNo_Holder, tntError(ERR_CORRUPT_STATE, "", "Unable to get DeviceSerialNo registry") }
+	if bytesSerialNo == nil { serialNo_Holder.SchemaVersion = SCHEMA_VERSION; return serialNo_Holder, nil }
+
+	err = json.Unmarshal(bytesSerialNo, &serialNo_Holder)
+	if err != nil {	return serialNo_Holder, tntError(ERR_CORRUPT_STATE, "", "Corrupt DeviceSerialNo registry record") }
+
+	return serialNo_Holder, nil
+}
+
+//AssemblyId holding the DeviceSerialNo for the DeviceType, empty if it is free
+func getRegisteredAssemblyId(stub Stub, _deviceSerialNo string, _deviceType string) (string, error) {
+	serialNo_Holder, err := getSerialNoHolder(stub, _deviceSerialNo)
+	if err != nil { return "", err }
+
+	for _, registration := range serialNo_Holder.Registrations {
+		if registration.DeviceType == _deviceType { return registration.AssemblyId, nil }
+	}
+	return "", nil
+}
+
+//Register the DeviceSerialNo against the Assembly - fails if already held by another Assembly of the same DeviceType
+func registerSerialNo(stub Stub, _deviceSerialNo string, _deviceType string, _assemblyId string) error {
+	serialNo_Holder, err := getSerialNoHolder(stub, _deviceSerialNo)
+	if err != nil { return err }
+
+	for _, registration := range serialNo_Holder.Registrations {
+		if registration.DeviceType == _deviceType {
+			if registration.AssemblyId == _assemblyId { return nil }
+			return tntError(ERR_ALREADY_EXISTS, "deviceSerialNo", "DeviceSerialNo " + _deviceSerialNo + " already registered to Assembly " + registration.AssemblyId)
+		}
+	}
+
+	registration := SerialNo_Registration{}
+	registration.DeviceType = _deviceType
+	registration.AssemblyId = _assemblyId
+	registration.RegisteredOn = time.Now().UTC().Format(DATE_FORMAT)
+	serialNo_Holder.Registrations = append(serialNo_Holder.Registrations, registration)
+
+	bytesSerialNo, err := json.Marshal(serialNo_Holder)
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Error creating SerialNo_Holder record") }
+
+	err = stub.PutState(serialNoKey(_deviceSerialNo), bytesSerialNo)
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+
+	return nil
+}
+
+//Release the DeviceSerialNo held by the Assembly so it can be registered again
+func releaseSerialNo(stub Stub, _deviceSerialNo string, _deviceType string, _assemblyId string) error {
+	serialNo_Holder, err := getSerialNoHolder(stub, _deviceSerialNo)
+	if err != nil { return err }
+
+	registrations := []SerialNo_Registration{}
+	for _, registration := range serialNo_Holder.Registrations {
+		if registration.DeviceType == _deviceType && registration.AssemblyId == _assemblyId { continue }
+		registrations = append(registrations, registration)
+	}
+
+	if len(registrations) == 0 {
+		err = stub.DelState(serialNoKey(_deviceSerialNo))
+		if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Unable to delete the state") }
+		return nil
+	}
+
+	serialNo_Holder.Registrations = registrations
+	bytesSerialNo, err := json.Marshal(serialNo_Holder)
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Error creating SerialNo_Holder record") }
+
+	err = stub.PutState(serialNoKey(_deviceSerialNo), bytesSerialNo)
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+
+	return nil
+}
+
+//Reassign the DeviceSerialNo of an Assembly, keeping the change history
+//Parameters = ASM0001, NEW DEVICESERIALNO, REASON, USERNAME
+func (t *TnT) reassignDeviceSerialNo(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[3]
+
+		_assemblyId := args[0]
+		_deviceSerialNo := args[1]
+		_reason := args[2]
+
+		_time:= time.Now().UTC()
+		_assemblyLastUpdatedOn := _time.Format(DATE_FORMAT)
+		_assemblyLastUpdatedBy := user_name
+
+		if len(_deviceSerialNo) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "deviceSerialNo", "DeviceSerialNo supplied as empty") }
+		if len(_reason) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "reason", "Reason for DeviceSerialNo reassignment supplied as empty") }
+
+		//get the Assembly
+		assemblyAsBytes, err := stub.GetState(_assemblyId)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id")	}
+		if assemblyAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+		assem := AssemblyLine{}
+		json.Unmarshal(assemblyAsBytes, &assem)
+
+		// Serial number of a packed or cancelled Assembly is final
+		if assem.AssemblyStatus == ASSEMBLYSTATUS_PKG || assem.AssemblyStatus == ASSEMBLYSTATUS_CAN || assem.AssemblyStatus == ASSEMBLYSTATUS_SCR {
+			return nil, tntError(ERR_INVALID_TRANSITION, "", "DeviceSerialNo can't be reassigned for a Packaged, Cancelled or Scrapped Assembly")
+		}
+		if assem.DeviceSerialNo == _deviceSerialNo { return nil, tntError(ERR_INVALID_ARGUMENT, "deviceSerialNo", "Assembly already has DeviceSerialNo " + _deviceSerialNo) }
+
+		_oldDeviceSerialNo := assem.DeviceSerialNo
+
+		err = registerSerialNo(stub, _deviceSerialNo, assem.DeviceType, _assemblyId)
+		if err != nil { return nil, err }
+		if len(_oldDeviceSerialNo) > 0 {
+			err = releaseSerialNo(stub, _oldDeviceSerialNo, assem.DeviceType, _assemblyId)
+			if err != nil { return nil, err }
+		}
+
+		//update the AssemblyLine serial number
+		assem.DeviceSerialNo = _deviceSerialNo
+		assem.AssemblyLastUpdatedOn = _assemblyLastUpdatedOn
+		assem.AssemblyLastUpdatedBy = _assemblyLastUpdatedBy
+
+		assem.AssemblyHash = computeAssemblyHash(assem)
+		bytes, err := json.Marshal(assem)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting Assembly record") }
+
+		err = stub.PutState(_assemblyId, bytes)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing Assembly record") }
+
+		/* AssemblyLine history ------------------------------------------Starts */
+		assemLine_HolderKey := historyKey(_assemblyId) // Indicates History Key for Assembly with ID = _assemblyId
+		bytesAssemblyLines, err := getHistoryState(stub, _assemblyId, &AssemblyLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+		var assemLine_Holder AssemblyLine_Holder
+
+		err = json.Unmarshal(bytesAssemblyLines, &assemLine_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt AssemblyLines record") }
+
+		assemLine_Holder.AssemblyLines = append(assemLine_Holder.AssemblyLines, assem) //appending the updated AssemblyLine
+
+		bytesAssemblyLines, err = json.Marshal(assemLine_Holder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating AssemblyLine_Holder record") }
+
+		err = stub.PutState(assemLine_HolderKey, bytesAssemblyLines)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+		/* AssemblyLine history ------------------------------------------Ends */
+
+		/* DeviceSerialNo history ------------------------------------------Starts */
+		serialNo_HistoryKey := serialNoHistoryKey(_assemblyId)
+		bytesSerialNoHistory, err := getMovedState(stub, serialNo_HistoryKey, _assemblyId + "S", &SerialNo_History{}, _assemblyId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get DeviceSerialNo history") }
+
+		var serialNo_History SerialNo_History
+		serialNo_History.AssemblyId = _assemblyId
+		if bytesSerialNoHistory != nil {
+			err = json.Unmarshal(bytesSerialNoHistory, &serialNo_History)
+			if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt SerialNo_History record") }
+		} else {
+			serialNo_History.SchemaVersion = SCHEMA_VERSION
+		}
+
+		change := SerialNo_Change{}
+		change.OldDeviceSerialNo = _oldDeviceSerialNo
+		change.NewDeviceSerialNo = _deviceSerialNo
+		change.Reason = _reason
+		change.ChangedOn = _assemblyLastUpdatedOn
+		change.ChangedBy = _assemblyLastUpdatedBy
+		serialNo_History.Changes = append(serialNo_History.Changes, change)
+
+		bytesSerialNoHistory, err = json.Marshal(serialNo_History)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating SerialNo_History record") }
+
+		err = stub.PutState(serialNo_HistoryKey, bytesSerialNoHistory)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+		/* DeviceSerialNo history ------------------------------------------Ends */
+
+		return nil, nil
+}
+
+//Register DeviceSerialNos of Assemblies created before the registry existed
+//Returns the Assemblies whose DeviceSerialNo is already held by another Assembly of the same DeviceType
+//Parameters = USERNAME
+func (t *TnT) rebuildSerialNoRegistry(stub Stub, args []string) ([]byte, error) {
+
+	bytes, err := stub.GetState("Assemblies")
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+	var assemID_Holder AssemblyID_Holder
+
+	err = json.Unmarshal(bytes, &assemID_Holder)
+	if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Assemblies") }
+
+	res2E:= []*AssemblyLine{}
+
+	for _, assemblyId := range assemID_Holder.AssemblyIDs {
+
+		assemblyAsBytes, err := stub.GetState(assemblyId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Assembly")}
+		if assemblyAsBytes == nil { continue }
+
+		res := new(AssemblyLine)
+		json.Unmarshal(assemblyAsBytes, &res)
+		if len(res.DeviceSerialNo) == 0 { continue }
+
+		_registeredAssemblyId, err := getRegisteredAssemblyId(stub, res.DeviceSerialNo, res.DeviceType)
+		if err != nil { return nil, err }
+
+		if len(_registeredAssemblyId) == 0 {
+			err = registerSerialNo(stub, res.DeviceSerialNo, res.DeviceType, res.AssemblyId)
+			if err != nil { return nil, err }
+		} else if _registeredAssemblyId != res.AssemblyId {
+			// Conflicting Assembly - to be resolved through reassignDeviceSerialNo
+			res2E=append(res2E,res)
+		}
+	}
+
+	mapB, _ := json.Marshal(res2E)
+	return mapB, nil
+}
+
+//get the Assemblies registered against a DeviceSerialNo
+//Parameters = DEV0101, DEVICETYPE (empty for all device types), USERNAME
+func (t *TnT) getAssembliesBySerialNo(stub Stub, args []string) ([]byte, error) {
+
+	_deviceSerialNo := args[0]
+	_deviceType := args[1]
+
+	_includeCancelled, err := includeCancelledArg(args, 3)
+	if err != nil { return nil, err }
+
+	serialNo_Holder, err := getSerialNoHolder(stub, _deviceSerialNo)
+	if err != nil { return nil, err }
+
+	res2E:= []*AssemblyLine{}
+
+	for _, registration := range serialNo_Holder.Registrations {
+		if len(_deviceType) > 0 && registration.DeviceType != _deviceType { continue }
+
+		assemblyAsBytes, err := stub.GetState(registration.AssemblyId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Assembly")}
+
+		if assemblyAsBytes != nil {
+			res := new(AssemblyLine)
+			json.Unmarshal(assemblyAsBytes, &res)
+			if !_includeCancelled && res.AssemblyStatus == ASSEMBLYSTATUS_CAN { continue }
+			res2E=append(res2E,res)
+		}
+	}
+
+	mapB, _ := json.Marshal(res2E)
+	return mapB, nil
+}
+
+// DeviceSerialNo reassignment history of an Assembly
+func (t *TnT) getSerialNoHistoryByID(stub Stub, args []string) ([]byte, error) {
+
+	_assemblyId := args[0]
+
+	bytesSerialNoHistory, err := getMovedState(stub, serialNoHistoryKey(_assemblyId), _assemblyId + "S", &SerialNo_History{}, _assemblyId)
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get DeviceSerialNo history") }
+
+	if bytesSerialNoHistory == nil {
+		var serialNo_History SerialNo_History
+		serialNo_History.AssemblyId = _assemblyId
+		bytesSerialNoHistory, _ = json.Marshal(serialNo_History)
+	}
+
+	return bytesSerialNoHistory, nil
+}
+
+/* Recall section */
+
+// Recalled component batch
+type Batch_Recall struct {
+	BatchType 		string `json:"batchType"`
+	BatchNumber 	string `json:"batchNumber"`
+	RecallReason 	string `json:"recallReason"`
+	RecalledOn 		string `json:"recalledOn"`
+	RecalledBy 		string `json:"recalledBy"`
+}
+
+//Recalled batches - stored against "Recalls"
+type Batch_Recall_Holder struct {
+	Recalls 	[]Batch_Recall `json:"recalls"`
+	SchemaVersion 	Schema_Version `json:"schemaVersion"`
+}
+
+//get the recalled batches, empty if nothing was ever recalled
+func getBatchRecalls(stub Stub) (Batch_Recall_Holder, error) {
+	var recall_Holder Batch_Recall_Holder
+
+	bytesRecalls, err := stub.GetState("Recalls")
+	if err != nil { return recall_Holder, tntError(ERR_CORRUPT_STATE, "", "Unable to get Recalls") }
+	if bytesRecalls == nil { recall_Holder.SchemaVersion = SCHEMA_VERSION; return recall_Holder, nil }
+
+	err = json.Unmarshal(bytesRecalls, &recall_Holder)
+	if err != nil {	return recall_Holder, tntError(ERR_CORRUPT_STATE, "", "Corrupt Recalls record") }
+
+	return recall_Holder, nil
+}
+
+//true if any component batch of the Assembly has been recalled
+func isAssemblyRecalled(assem AssemblyLine, recall_Holder Batch_Recall_Holder) bool {
+	for _, recall := range recall_Holder.Recalls {
+		if 		   (recall.BatchType == FIL_BATCH	&& assem.FilamentBatchId == recall.BatchNumber)		||
+					(recall.BatchType == LED_BATCH	&& assem.LedBatchId == recall.BatchNumber)			||
+					(recall.BatchType == CIR_BATCH	&& assem.CircuitBoardBatchId == recall.BatchNumber)	||
+					(recall.BatchType == WRE_BATCH	&& assem.WireBatchId == recall.BatchNumber)			||
+					(recall.BatchType == CAS_BATCH	&& assem.CasingBatchId == recall.BatchNumber)		||
+					(recall.BatchType == ADP_BATCH	&& assem.AdaptorBatchId == recall.BatchNumber)		||
+					(recall.BatchType == STK_BATCH	&& assem.StickPodBatchId == recall.BatchNumber)		{
+			return true
+		}
+	}
+	return false
+}
+
+//Recall a component batch - every Assembly built with it is flagged as recalled
+//Parameters = LedBatchId, LED0002, REASON, USERNAME
+func (t *TnT) recallBatch(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[3]
+
+	_batchType := args[0]
+	_batchNumber := args[1]
+	_recallReason := args[2]
+
+	if _batchType != FIL_BATCH && _batchType != LED_BATCH && _batchType != CIR_BATCH &&
+		_batchType != WRE_BATCH && _batchType != CAS_BATCH && _batchType != ADP_BATCH &&
+		_batchType != STK_BATCH {
+		return nil, tntError(ERR_INVALID_ARGUMENT, "batchType", "Unknown batch type " + _batchType)
+	}
+	if len(_batchNumber) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "batchNo", "Batch number supplied as empty") }
+
+	recall_Holder, err := getBatchRecalls(stub)
+	if err != nil { return nil, err }
+
+	for _, recall := range recall_Holder.Recalls {
+		if recall.BatchType == _batchType && recall.BatchNumber == _batchNumber {
+			return nil, tntError(ERR_INVALID_TRANSITION, "", "Batch already recalled")
+		}
+	}
+
+	recall := Batch_Recall{}
+	recall.BatchType = _batchType
+	recall.BatchNumber = _batchNumber
+	recall.RecallReason = _recallReason
+	recall.RecalledOn = time.Now().UTC().Format(DATE_FORMAT)
+	recall.RecalledBy = user_name
+	recall_Holder.Recalls = append(recall_Holder.Recalls, recall)
+
+	bytesRecalls, err := json.Marshal(recall_Holder)
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating Batch_Recall_Holder record") }
+
+	err = stub.PutState("Recalls", bytesRecalls)
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+
+	return nil, nil
+}
+
+/* Public view section */
+
+//Customer facing projection of an Assembly - no batch IDs or user names
+type Product_Authenticity struct {
+	DeviceSerialNo 		string `json:"deviceSerialNo"`
+	Genuine 			bool `json:"genuine"`
+	DeviceType 			string `json:"deviceType"`
+	ManufacturingPlant 	string `json:"manufacturingPlant"`
+	AssemblyDate 		string `json:"assemblyDate"`
+	PackageStatus 		string `json:"packageStatus"`
+	PackagingDate 		string `json:"packagingDate"`
+	Recalled 			bool `json:"recalled"`
+}
+
+//Authenticity check of a product by DeviceSerialNo - one entry per DeviceType registered with the serial number
+//An unknown serial number returns a single entry with genuine = false
+//Parameters = DEV0101, USERNAME
+func (t *TnT) getProductAuthenticity(stub Stub, args []string) ([]byte, error) {
+
+	_deviceSerialNo := args[0]
+
+	serialNo_Holder, err := getSerialNoHolder(stub, _deviceSerialNo)
+	if err != nil { return nil, err }
+
+	recall_Holder, err := getBatchRecalls(stub)
+	if err != nil { return nil, err }
+
+	res2E:= []Product_Authenticity{}
+
+	for _, registration := range serialNo_Holder.Registrations {
+
+		assemblyAsBytes, err := stub.GetState(registration.AssemblyId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Assembly")}
+		if assemblyAsBytes == nil { continue }
+
+		assem := AssemblyLine{}
+		json.Unmarshal(assemblyAsBytes, &assem)
+
+		res := Product_Authenticity{}
+		res.DeviceSerialNo = _deviceSerialNo
+		res.Genuine = assem.AssemblyStatus != ASSEMBLYSTATUS_CAN &&
+			assem.AssemblyStatus != ASSEMBLYSTATUS_SCR // A destroyed unit's serial number turning up again is not genuine
+		res.DeviceType = assem.DeviceType
+		res.ManufacturingPlant = assem.ManufacturingPlant
+		res.AssemblyDate = assem.AssemblyDate
+		res.Recalled = isAssemblyRecalled(assem, recall_Holder)
+
+		if len(assem.AssemblyPackage) > 0 {
+			packageAsBytes, err := stub.GetState(assem.AssemblyPackage)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Package") }
+			if packageAsBytes != nil {
+				pack := PackageLine{}
+				json.Unmarshal(packageAsBytes, &pack)
+				res.PackageStatus = pack.PackageStatus
+				res.PackagingDate = pack.PackagingDate
+			}
+		}
+
+		res2E=append(res2E,res)
+	}
+
+	// Serial number not known on the ledger
+	if len(res2E) == 0 {
+		res := Product_Authenticity{}
+		res.DeviceSerialNo = _deviceSerialNo
+		res.Genuine = false
+		res2E=append(res2E,res)
+	}
+
+	mapB, _ := json.Marshal(res2E)
+	return mapB, nil
+}
+
+/* QA Inspection section */
+
+// QA inspection of an Assembly
+type QA_Inspection struct {
+	InspectionId 		string `json:"inspectionId"`
+	AssemblyId 			string `json:"assemblyId"`
+	Inspector 			string `json:"inspector"`
+	TestStation 		string `json:"testStation"`
+	MeasuredValues 		map[string]string `json:"measuredValues"`
+	InspectionResult 	string `json:"inspectionResult"` // PASS or FAIL
+	DefectCodes 		[]string `json:"defectCodes"`
+	InspectionDate 		string `json:"inspectionDate"`
+	StatusBefore 		string `json:"statusBefore"` // AssemblyStatus when the inspection was recorded
+	StatusAfter 		string `json:"statusAfter"`
+}
+
+//QA inspections of an Assembly - stored against qaInspectionKey
+type QA_Inspection_Holder struct {
+	Inspections 	[]QA_Inspection `json:"inspections"`
+	SchemaVersion 	Schema_Version `json:"schemaVersion"`
+}
+
+func qaInspectionKey(_assemblyId string) string {
+	return "QA|" + _assemblyId // Indicates QA inspection key
+}
+
+//Defect rate of a batch or plant
+type Defect_Rate struct {
+	Key 					string `json:"key"` // Batch number or ManufacturingPlant
+	AssembliesInspected 	int `json:"assembliesInspected"`
+	AssembliesFailed 		int `json:"assembliesFailed"` // Failed at least one inspection
+	Inspections 			int `json:"inspections"`
+	FailedInspections 		int `json:"failedInspections"`
+	DefectRate 				float64 `json:"defectRate"` // AssembliesFailed / AssembliesInspected
+	DefectCodes 			map[string]int `json:"defectCodes"`
+}
+
+//get the QA inspections of an Assembly, empty if never inspected
+func getQAInspections(stub Stub, _assemblyId string) (QA_Inspection_Holder, error) {
+	var inspection_Holder QA_Inspection_Holder
+
+	bytesInspections, err := getMovedState(stub, qaInspectionKey(_assemblyId), _assemblyId + "Q", &QA_Inspection_Holder{}, _assemblyId)
+	if err != nil { return inspection_Holder, tntError(ERR_CORRUPT_STATE, "", "Unable to get QA inspections") }
+	if bytesInspections == nil { inspection_Holder.SchemaVersion = SCHEMA_VERSION; return inspection_Holder, nil }
+
+	err = json.Unmarshal(bytesInspections, &inspection_Holder)
+	if err != nil {	return inspection_Holder, tntError(ERR_CORRUPT_STATE, "", "Corrupt QA_Inspection_Holder record") }
+
+	return inspection_Holder, nil
+}
+
+//API to record a QA inspection against an Assembly
+//A FAIL moves the Assembly to 'QA Failed', a PASS on a 'QA Failed' Assembly restores the status it had before failing,
+//'Ready For Packaging' when there is none (e.g. records migrated as 'QA Failed')
+//"args": ["ASM0101","STATION01","{\"voltage\":\"4.9\"}","FAIL","[\"D012\"]","20170612235959","qauser1"]
+//_assemblyId,_testStation,_measuredValues,_inspectionResult,_defectCodes,_inspectionDate,user_name
+func (t *TnT) createQAInspection(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[6]
+
+		_assemblyId := args[0]
+		_testStation := args[1]
+		_measuredValues := args[2]
+		_inspectionResult := args[3]
+		_defectCodes := args[4]
+
+		_time:= time.Now().UTC()
+		_assemblyLastUpdatedOn := _time.Format(DATE_FORMAT)
+		_assemblyLastUpdatedBy := user_name
+
+		if _inspectionResult != INSPECTION_PASS && _inspectionResult != INSPECTION_FAIL {
+			return nil, tntError(ERR_INVALID_ARGUMENT, "inspectionResult", "InspectionResult must be PASS or FAIL")
+		}
+		if len(_testStation) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "testStation", "TestStation supplied as empty") }
+		//Check Date
+		_inspectionDate, err := canonicalDate(args[5], "inspectionDate")
+		if err != nil { return nil, err }
+
+		inspection := QA_Inspection{}
+		if len(_measuredValues) > 0 {
+			err := json.Unmarshal([]byte(_measuredValues), &inspection.MeasuredValues)
+			if err != nil { return nil, tntError(ERR_INVALID_ARGUMENT, "measuredValues", "MeasuredValues must be a JSON object of name/value pairs") }
+		}
+		if len(_defectCodes) > 0 {
+			err := json.Unmarshal([]byte(_defectCodes), &inspection.DefectCodes)
+			if err != nil { return nil, tntError(ERR_INVALID_ARGUMENT, "defectCodes", "DefectCodes must be a JSON array of codes") }
+		}
+		if _inspectionResult == INSPECTION_FAIL && len(inspection.DefectCodes) == 0 {
+			return nil, tntError(ERR_INVALID_ARGUMENT, "defectCodes", "DefectCodes required for a failed inspection")
+		}
+
+		//get the Assembly
+		assemblyAsBytes, err := stub.GetState(_assemblyId)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id")	}
+		if assemblyAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+		assem := AssemblyLine{}
+		json.Unmarshal(assemblyAsBytes, &assem)
+
+		if assem.AssemblyStatus == ASSEMBLYSTATUS_PKG || assem.AssemblyStatus == ASSEMBLYSTATUS_CAN || assem.AssemblyStatus == ASSEMBLYSTATUS_SCR {
+			return nil, tntError(ERR_INVALID_TRANSITION, "", "Packaged, Cancelled or Scrapped Assembly can't be inspected")
+		}
+
+		inspection_Holder, err := getQAInspections(stub, _assemblyId)
+		if err != nil { return nil, err }
+
+		inspection.InspectionId = _assemblyId + "-Q" + strconv.Itoa(len(inspection_Holder.Inspections) + 1)
+		inspection.AssemblyId = _assemblyId
+		inspection.Inspector = user_name
+		inspection.TestStation = _testStation
+		inspection.InspectionResult = _inspectionResult
+		inspection.InspectionDate = _inspectionDate
+		inspection.StatusBefore = assem.AssemblyStatus
+		inspection.StatusAfter = assem.AssemblyStatus
+
+		if _inspectionResult == INSPECTION_FAIL {
+			inspection.StatusAfter = ASSEMBLYSTATUS_QAF
+		} else if assem.AssemblyStatus == ASSEMBLYSTATUS_QAF {
+			// Restore the status from before the latest failed inspection
+			inspection.StatusAfter = ASSEMBLYSTATUS_RFP
+			for i := len(inspection_Holder.Inspections) - 1; i >= 0; i-- {
+				failed := inspection_Holder.Inspections[i]
+				if failed.StatusAfter == ASSEMBLYSTATUS_QAF && failed.StatusBefore != ASSEMBLYSTATUS_QAF {
+					inspection.StatusAfter = failed.StatusBefore
+					break
+				}
+			}
+		}
+
+		inspection_Holder.Inspections = append(inspection_Holder.Inspections, inspection)
+
+		// A passed inspection clears the reworks waiting for QA
+		if _inspectionResult == INSPECTION_PASS {
+			rework_Holder, err := getAssemblyReworks(stub, _assemblyId)
+			if err != nil { return nil, err }
+
+			_reworkCleared := false
+			for i := range rework_Holder.Reworks {
+				if rework_Holder.Reworks[i].ReworkStatus == REWORK_PENDING_QA {
+					rework_Holder.Reworks[i].ReworkStatus = REWORK_QA_PASSED
+					_reworkCleared = true
+				}
+			}
+			if _reworkCleared {
+				err = putAssemblyReworks(stub, _assemblyId, rework_Holder)
+				if err != nil { return nil, err }
+			}
+		}
+
+		bytesInspections, err := json.Marshal(inspection_Holder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating QA_Inspection_Holder record") }
+
+		err = stub.PutState(qaInspectionKey(_assemblyId), bytesInspections)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+
+		// Assembly is only touched when the inspection changes its status
+		if inspection.StatusAfter != assem.AssemblyStatus {
+
+			//update the AssemblyLine status
+			assem.AssemblyStatus = inspection.StatusAfter
+			assem.AssemblyLastUpdatedOn = _assemblyLastUpdatedOn
+			assem.AssemblyLastUpdatedBy = _assemblyLastUpdatedBy
+
+			assem.AssemblyHash = computeAssemblyHash(assem)
+			bytes, err := json.Marshal(assem)
+			if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting Assembly record") }
+
+			err = stub.PutState(_assemblyId, bytes)
+			if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing Assembly record") }
+
+			/* AssemblyLine history ------------------------------------------Starts */
+			assemLine_HolderKey := historyKey(_assemblyId) // Indicates History Key for Assembly with ID = _assemblyId
+			bytesAssemblyLines, err := getHistoryState(stub, _assemblyId, &AssemblyLine_Holder{})
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+			var assemLine_Holder AssemblyLine_Holder
+
+			err = json.Unmarshal(bytesAssemblyLines, &assemLine_Holder)
+			if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt AssemblyLines record") }
+
+			assemLine_Holder.AssemblyLines = append(assemLine_Holder.AssemblyLines, assem) //appending the updated AssemblyLine
+
+			bytesAssemblyLines, err = json.Marshal(assemLine_Holder)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating AssemblyLine_Holder record") }
+
+			err = stub.PutState(assemLine_HolderKey, bytesAssemblyLines)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+			/* AssemblyLine history ------------------------------------------Ends */
+		}
+
+		return nil, nil
+}
+
+// All QA inspections of an Assembly
+func (t *TnT) getQAInspectionsByAssemblyID(stub Stub, args []string) ([]byte, error) {
+
+	_assemblyId := args[0]
+
+	inspection_Holder, err := getQAInspections(stub, _assemblyId)
+	if err != nil { return nil, err }
+
+	mapB, _ := json.Marshal(inspection_Holder)
+	return mapB, nil
+}
+
+//Defect rates grouped by the batch numbers of a batch type, or by ManufacturingPlant when batch type is empty
+func (t *TnT) computeDefectRates(stub Stub, _batchType string) ([]*Defect_Rate, error) {
+
+	bytes, err := stub.GetState("Assemblies")
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+	var assemID_Holder AssemblyID_Holder
+
+	err = json.Unmarshal(bytes, &assemID_Holder)
+	if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Assemblies") }
+
+	res2E:= []*Defect_Rate{}
+	rates := map[string]*Defect_Rate{}
+
+	for _, assemblyId := range assemID_Holder.AssemblyIDs {
+
+		inspection_Holder, err := getQAInspections(stub, assemblyId)
+		if err != nil { return nil, err }
+		if len(inspection_Holder.Inspections) == 0 { continue }
+
+		assemblyAsBytes, err := stub.GetState(assemblyId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Assembly")}
+		if assemblyAsBytes == nil { continue }
+
+		res := AssemblyLine{}
+		json.Unmarshal(assemblyAsBytes, &res)
+
+		_key := res.ManufacturingPlant
+		if 		   _batchType == FIL_BATCH	{ _key = res.FilamentBatchId
+		} else if  _batchType == LED_BATCH	{ _key = res.LedBatchId
+		} else if  _batchType == CIR_BATCH	{ _key = res.CircuitBoardBatchId
+		} else if  _batchType == WRE_BATCH	{ _key = res.WireBatchId
+		} else if  _batchType == CAS_BATCH	{ _key = res.CasingBatchId
+		} else if  _batchType == ADP_BATCH	{ _key = res.AdaptorBatchId
+		} else if  _batchType == STK_BATCH	{ _key = res.StickPodBatchId
+		}
+
+		rate, ok := rates[_key]
+		if !ok {
+			rate = &Defect_Rate{Key: _key, DefectCodes: map[string]int{}}
+			rates[_key] = rate
+			res2E=append(res2E,rate)
+		}
+
+		_failed := false
+		for _, inspection := range inspection_Holder.Inspections {
+			rate.Inspections++
+			if inspection.InspectionResult == INSPECTION_FAIL {
+				rate.FailedInspections++
+				_failed = true
+				for _, defectCode := range inspection.DefectCodes {
+					rate.DefectCodes[defectCode]++
+				}
+			}
+		}
+		rate.AssembliesInspected++
+		if _failed { rate.AssembliesFailed++ }
+	}
+
+	for _, rate := range res2E {
+		rate.DefectRate = float64(rate.AssembliesFailed) / float64(rate.AssembliesInspected)
+	}
+
+	return res2E, nil
+}
+
+//Defect rates per batch number of a batch type
+//Parameters = LedBatchId, USERNAME
+func (t *TnT) getDefectRatesByBatch(stub Stub, args []string) ([]byte, error) {
+
+	_batchType := args[0]
+
+	if _batchType != FIL_BATCH && _batchType != LED_BATCH && _batchType != CIR_BATCH &&
+		_batchType != WRE_BATCH && _batchType != CAS_BATCH && _batchType != ADP_BATCH &&
+		_batchType != STK_BATCH {
+		return nil, tntError(ERR_INVALID_ARGUMENT, "batchType", "Unknown batch type " + _batchType)
+	}
+
+	res2E, err := t.computeDefectRates(stub, _batchType)
+	if err != nil { return nil, err }
+
+	mapB, _ := json.Marshal(res2E)
+	return mapB, nil
+}
+
+//Defect rates per ManufacturingPlant
+//Parameters = USERNAME
+func (t *TnT) getDefectRatesByPlant(stub Stub, args []string) ([]byte, error) {
+
+	res2E, err := t.computeDefectRates(stub, "")
+	if err != nil { return nil, err }
+
+	mapB, _ := json.Marshal(res2E)
+	return mapB, nil
+}
+
+/* Rework section */
+
+// Component batch swapped on an Assembly during rework
+type Assembly_Rework struct {
+	ReworkId 			string `json:"reworkId"`
+	AssemblyId 			string `json:"assemblyId"`
+	BatchType 			string `json:"batchType"`
+	ReplacedBatchId 	string `json:"replacedBatchId"`
+	ReplacementBatchId 	string `json:"replacementBatchId"`
+	ReworkReason 		string `json:"reworkReason"`
+	ReworkedOn 			string `json:"reworkedOn"`
+	ReworkedBy 			string `json:"reworkedBy"`
+	ReworkStatus 		string `json:"reworkStatus"` // PENDING_QA until the next passed QA inspection
+}
+
+//Reworks of an Assembly - stored against reworkKey
+type Assembly_Rework_Holder struct {
+	Reworks 	[]Assembly_Rework `json:"reworks"`
+	SchemaVersion 	Schema_Version `json:"schemaVersion"`
+}
+
+func reworkKey(_assemblyId string) string {
+	return "REWORK|" + _assemblyId // Indicates rework key
+}
+
+//get the reworks of an Assembly, empty if never reworked
+func getAssemblyReworks(stub Stub, _assemblyId string) (Assembly_Rework_Holder, error) {
+	var rework_Holder Assembly_Rework_Holder
+
+	bytesReworks, err := getMovedState(stub, reworkKey(_assemblyId), _assemblyId + "R", &Assembly_Rework_Holder{}, _assemblyId)
+	if err != nil { return rework_Holder, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assembly reworks") }
+	if bytesReworks == nil { rework_Holder.SchemaVersion = SCHEMA_VERSION; return rework_Holder, nil }
+
+	err = json.Unmarshal(bytesReworks, &rework_Holder)
+	if err != nil {	return rework_Holder, tntError(ERR_CORRUPT_STATE, "", "Corrupt Assembly_Rework_Holder record") }
+
+	return rework_Holder, nil
+}
+
+func putAssemblyReworks(stub Stub, _assemblyId string, rework_Holder Assembly_Rework_Holder) error {
+	bytesReworks, err := json.Marshal(rework_Holder)
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Error creating Assembly_Rework_Holder record") }
+
+	err = stub.PutState(reworkKey(_assemblyId), bytesReworks)
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+
+	return nil
+}
+
+//true if the Assembly was originally built with the batch and it was replaced in a rework
+func wasBatchReplaced(stub Stub, _assemblyId string, _batchType string, _batchNumber string) (bool, error) {
+	rework_Holder, err := getAssemblyReworks(stub, _assemblyId)
+	if err != nil { return false, err }
+
+	for _, rework := range rework_Holder.Reworks {
+		if rework.BatchType == _batchType && rework.ReplacedBatchId == _batchNumber { return true, nil }
+	}
+	return false, nil
+}
+
+//true if a rework of the Assembly hasn't been followed by a passed QA inspection yet
+func isReworkPendingQA(stub Stub, _assemblyId string) (bool, error) {
+	rework_Holder, err := getAssemblyReworks(stub, _assemblyId)
+	if err != nil { return false, err }
+
+	for _, rework := range rework_Holder.Reworks {
+		if rework.ReworkStatus == REWORK_PENDING_QA { return true, nil }
+	}
+	return false, nil
+}
+
+//Points to the component batch field of the Assembly for the batch type, nil for an unknown type
+func assemblyBatchField(assem *AssemblyLine, _batchType string) *string {
+	if 		   _batchType == FIL_BATCH	{ return &assem.FilamentBatchId
+	} else if  _batchType == LED_BATCH	{ return &assem.LedBatchId
+	} else if  _batchType == CIR_BATCH	{ return &assem.CircuitBoardBatchId
+	} else if  _batchType == WRE_BATCH	{ return &assem.WireBatchId
+	} else if  _batchType == CAS_BATCH	{ return &assem.CasingBatchId
+	} else if  _batchType == ADP_BATCH	{ return &assem.AdaptorBatchId
+	} else if  _batchType == STK_BATCH	{ return &assem.StickPodBatchId
+	}
+	return nil
+}
+
+//API to rework a 'QA Failed' Assembly by swapping component batches
+//The Assembly stays 'QA Failed' until a QA inspection passes it again
+//"args": ["ASM0101","{\"LedBatchId\":\"LED0003\",\"WireBatchId\":\"WIR0004\"}","LED flicker","aluser1"]
+//_assemblyId,_replacementBatches,_reworkReason,user_name
+func (t *TnT) reworkAssembly(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[3]
+
+		_assemblyId := args[0]
+		_replacementBatches := args[1]
+		_reworkReason := args[2]
+
+		_time:= time.Now().UTC()
+		_assemblyLastUpdatedOn := _time.Format(DATE_FORMAT)
+		_assemblyLastUpdatedBy := user_name
+
+		if len(_reworkReason) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "reworkReason", "Rework reason supplied as empty") }
+
+		replacements := map[string]string{}
+		err := json.Unmarshal([]byte(_replacementBatches), &replacements)
+		if err != nil { return nil, tntError(ERR_INVALID_ARGUMENT, "replacementBatches", "Replacement batches must be a JSON object of batch type to batch number") }
+		if len(replacements) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "replacementBatches", "No replacement batches supplied") }
+
+		//get the Assembly
+		assemblyAsBytes, err := stub.GetState(_assemblyId)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id")	}
+		if assemblyAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+		assem := AssemblyLine{}
+		json.Unmarshal(assemblyAsBytes, &assem)
+
+		if assem.AssemblyStatus != ASSEMBLYSTATUS_QAF { return nil, tntError(ERR_INVALID_TRANSITION, "", "Only an Assembly with status 'QA Failed' can be reworked") }
+
+		rework_Holder, err := getAssemblyReworks(stub, _assemblyId)
+		if err != nil { return nil, err }
+
+		// Batch types are processed in a fixed order so every peer writes the same rework records
+		for _, _batchType := range []string{FIL_BATCH, LED_BATCH, CIR_BATCH, WRE_BATCH, CAS_BATCH, ADP_BATCH, STK_BATCH} {
+			_replacementBatchId, ok := replacements[_batchType]
+			if !ok { continue }
+			delete(replacements, _batchType)
+
+			if len(_replacementBatchId) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "replacementBatches", "Replacement batch for " + _batchType + " supplied as empty") }
+
+			batchField := assemblyBatchField(&assem, _batchType)
+			if *batchField == _replacementBatchId { return nil, tntError(ERR_INVALID_ARGUMENT, "replacementBatches", "Replacement batch for " + _batchType + " is the batch already fitted") }
+
+			rework := Assembly_Rework{}
+			rework.ReworkId = _assemblyId + "-R" + strconv.Itoa(len(rework_Holder.Reworks) + 1)
+			rework.AssemblyId = _assemblyId
+			rework.BatchType = _batchType
+			rework.ReplacedBatchId = *batchField
+			rework.ReplacementBatchId = _replacementBatchId
+			rework.ReworkReason = _reworkReason
+			rework.ReworkedOn = _assemblyLastUpdatedOn
+			rework.ReworkedBy = _assemblyLastUpdatedBy
+			rework.ReworkStatus = REWORK_PENDING_QA
+			rework_Holder.Reworks = append(rework_Holder.Reworks, rework)
+
+			*batchField = _replacementBatchId
+		}
+		for _batchType := range replacements {
+			return nil, tntError(ERR_INVALID_ARGUMENT, "batchType", "Unknown batch type " + _batchType)
+		}
+
+		err = putAssemblyReworks(stub, _assemblyId, rework_Holder)
+		if err != nil { return nil, err }
+
+		//update the AssemblyLine - status stays 'QA Failed'
+		assem.AssemblyLastUpdatedOn = _assemblyLastUpdatedOn
+		assem.AssemblyLastUpdatedBy = _assemblyLastUpdatedBy
+
+		assem.AssemblyHash = computeAssemblyHash(assem)
+		bytes, err := json.Marshal(assem)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting Assembly record") }
+
+		err = stub.PutState(_assemblyId, bytes)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing Assembly record") }
+
+		/* AssemblyLine history ------------------------------------------Starts */
+		// The version before the rework stays in the history with the original batches
+		assemLine_HolderKey := historyKey(_assemblyId) // Indicates History Key for Assembly with ID = _assemblyId
+		bytesAssemblyLines, err := getHistoryState(stub, _assemblyId, &AssemblyLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+		var assemLine_Holder AssemblyLine_Holder
+
+		err = json.Unmarshal(bytesAssemblyLines, &assemLine_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt AssemblyLines record") }
+
+		assemLine_Holder.AssemblyLines = append(assemLine_Holder.AssemblyLines, assem) //appending the updated AssemblyLine
+
+		bytesAssemblyLines, err = json.Marshal(assemLine_Holder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating AssemblyLine_Holder record") }
+
+		err = stub.PutState(assemLine_HolderKey, bytesAssemblyLines)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+		/* AssemblyLine history ------------------------------------------Ends */
+
+		return nil, nil
+}
+
+// All reworks of an Assembly
+func (t *TnT) getReworksByAssemblyID(stub Stub, args []string) ([]byte, error) {
+
+	_assemblyId := args[0]
+
+	rework_Holder, err := getAssemblyReworks(stub, _assemblyId)
+	if err != nil { return nil, err }
+
+	mapB, _ := json.Marshal(rework_Holder)
+	return mapB, nil
+}
+
+/* Returns (RMA) section */
+
+// RMA status change
+type RMA_Status_Change struct {
+	RMAStatus 		string `json:"rmaStatus"`
+	Comment 		string `json:"comment"`
+	ChangedOn 		string `json:"changedOn"`
+	ChangedBy 		string `json:"changedBy"`
+}
+
+// Return Merchandise Authorisation against a shipped case or a single device
+type RMA_Line struct {
+	RMAId 				string `json:"rmaId"`
+	CaseId 				string `json:"caseId"`
+	DeviceSerialNo 		string `json:"deviceSerialNo"` // Set when a single device is returned
+	AssemblyIds 		[]string `json:"assemblyIds"` // Returned Assemblies
+	ReturnReason 		string `json:"returnReason"`
+	RMAStatus 			string `json:"rmaStatus"`
+	ReplacementCaseId 	string `json:"replacementCaseId"`
+	CaseBroken 			bool `json:"caseBroken"` // Assemblies released from the case
+	RMACreationDate 	string `json:"rmaCreationDate"`
+	RMALastUpdatedOn 	string `json:"rmaLastUpdatedOn"`
+	RMACreatedBy 		string `json:"rmaCreatedBy"`
+	RMALastUpdatedBy 	string `json:"rmaLastUpdatedBy"`
+	StatusHistory 		[]RMA_Status_Change `json:"statusHistory"`
+	SchemaVersion 		Schema_Version `json:"schemaVersion"`
+}
+
+//RMA IDs - stored against "RMAs"
+type RMA_ID_Holder struct {
+	RMAIds 	[]string `json:"rmaIds"`
+	SchemaVersion 	Schema_Version `json:"schemaVersion"`
+}
+
+//Return rate of a batch or plant
+type Return_Rate struct {
+	Key 				string `json:"key"` // Batch number or ManufacturingPlant
+	AssembliesShipped 	int `json:"assembliesShipped"`
+	AssembliesReturned 	int `json:"assembliesReturned"`
+	ReturnRate 			float64 `json:"returnRate"` // AssembliesReturned / AssembliesShipped
+	ReturnReasons 		map[string]int `json:"returnReasons"`
+}
+
+//get the RMA IDs, empty if no RMA was ever created
+func getRMAIds(stub Stub) (RMA_ID_Holder, error) {
+	var rmaID_Holder RMA_ID_Holder
+
+	bytesRMAHolder, err := stub.GetState("RMAs")
+	if err != nil { return rmaID_Holder, tntError(ERR_CORRUPT_STATE, "", "Unable to get RMAs") }
+	if bytesRMAHolder == nil { rmaID_Holder.SchemaVersion = SCHEMA_VERSION; return rmaID_Holder, nil }
+
+	err = json.Unmarshal(bytesRMAHolder, &rmaID_Holder)
+	if err != nil {	return rmaID_Holder, tntError(ERR_CORRUPT_STATE, "", "Corrupt RMAs record") }
+
+	return rmaID_Holder, nil
+}
+
+//get the RMA against ID
+func getRMA(stub Stub, _rmaId string) (RMA_Line, error) {
+	rma := RMA_Line{}
+
+	rmaAsBytes, err := stub.GetState(_rmaId)
+	if err != nil { return rma, tntError(ERR_CORRUPT_STATE, "", "Failed to get RMA") }
+	if rmaAsBytes == nil { return rma, tntError(ERR_NOT_FOUND, "rmaId", "RMA doesn't exists") }
+
+	err = json.Unmarshal(rmaAsBytes, &rma)
+	if err != nil {	return rma, tntError(ERR_CORRUPT_STATE, "", "Corrupt RMA record") }
+	//RMA IDs share the key space with the other records
+	if rma.RMAId != _rmaId { return rma, tntError(ERR_NOT_FOUND, "rmaId", "RMA doesn't exists") }
+
+	return rma, nil
+}
+
+//RMA status allowed to follow the current one
+func isValidRMATransition(_fromStatus string, _toStatus string) bool {
+	if _fromStatus == RMA_REQUESTED { return _toStatus == RMA_RECEIVED }
+	if _fromStatus == RMA_RECEIVED { return _toStatus == RMA_INSPECTED }
+	if _fromStatus == RMA_INSPECTED {
+		return _toStatus == RMA_REFURBISHED || _toStatus == RMA_SCRAPPED || _toStatus == RMA_REPLACED
+	}
+	return false
+}
+
+//API to create an RMA against a CaseId or a DeviceSerialNo
+//"args": ["RMA0001","CAS0001","","Charger not working","pluser1"]
+//_rmaId,_caseId,_deviceSerialNo,_returnReason,user_name
+func (t *TnT) createRMA(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[4]
+
+		_rmaId := args[0]
+		_caseId := args[1]
+		_deviceSerialNo := args[2]
+		_returnReason := args[3]
+
+		_time:= time.Now().UTC()
+		_rmaCreationDate := _time.Format(DATE_FORMAT)
+
+		if len(_rmaId) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "rmaId", "RMAId supplied as empty") }
+		if err := checkRecordId(_rmaId, "rmaId"); err != nil { return nil, err }
+		if len(_caseId) == 0 && len(_deviceSerialNo) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "caseId", "Either CaseId or DeviceSerialNo must be supplied") }
+		if len(_returnReason) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "returnReason", "Return reason supplied as empty") }
+
+	//Checking if the RMA already exists
+		rmaAsBytes, err := stub.GetState(_rmaId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get RMA") }
+		if rmaAsBytes != nil { return nil, tntError(ERR_ALREADY_EXISTS, "rmaId", "RMA already exists") }
+
+		rma := RMA_Line{}
+		rma.RMAId = _rmaId
+		rma.DeviceSerialNo = _deviceSerialNo
+		rma.ReturnReason = _returnReason
+		rma.RMAStatus = RMA_REQUESTED
+		rma.RMACreationDate = _rmaCreationDate
+		rma.RMALastUpdatedOn = _rmaCreationDate
+		rma.RMACreatedBy = user_name
+		rma.RMALastUpdatedBy = user_name
+		rma.SchemaVersion = SCHEMA_VERSION
+
+		//Returned device - find the packed Assembly carrying the serial number
+		if len(_deviceSerialNo) > 0 {
+			serialNo_Holder, err := getSerialNoHolder(stub, _deviceSerialNo)
+			if err != nil { return nil, err }
+
+			for _, registration := range serialNo_Holder.Registrations {
+				assemblyAsBytes, err := stub.GetState(registration.AssemblyId)
+				if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Assembly")}
+				if assemblyAsBytes == nil { continue }
+
+				assem := AssemblyLine{}
+				json.Unmarshal(assemblyAsBytes, &assem)
+
+				if len(assem.AssemblyPackage) == 0 { continue }
+				if len(_caseId) > 0 && assem.AssemblyPackage != _caseId { continue }
+
+				_caseId = assem.AssemblyPackage
+				rma.AssemblyIds = append(rma.AssemblyIds, assem.AssemblyId)
+			}
+			if len(rma.AssemblyIds) == 0 { return nil, tntError(ERR_NOT_FOUND, "deviceSerialNo", "No packaged Assembly found for DeviceSerialNo " + _deviceSerialNo) }
+			if len(rma.AssemblyIds) > 1 { return nil, tntError(ERR_INVALID_ARGUMENT, "caseId", "DeviceSerialNo " + _deviceSerialNo + " is packed in more than one case, supply the CaseId") }
+		}
+
+		//get the Package
+		packageAsBytes, err := stub.GetState(_caseId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Package") }
+		if packageAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "caseId", "Package doesn't exists") }
+
+		pack := PackageLine{}
+		json.Unmarshal(packageAsBytes, &pack)
+
+		if len(pack.PackageRMAId) > 0 { return nil, tntError(ERR_INVALID_TRANSITION, "", "Package already returned under RMA " + pack.PackageRMAId) }
+		if pack.PackageStatus == PACKAGESTATUS_CAN { return nil, tntError(ERR_INVALID_TRANSITION, "", "Package is cancelled") }
+
+		//Only shipped Packages come back
+		_shipped, err := isPackageShipped(stub, _caseId)
+		if err != nil { return nil, err }
+		if !_shipped { return nil, tntError(ERR_INVALID_TRANSITION, "", "Package " + _caseId + " was never shipped") }
+
+		//Returned case - every Assembly in it is returned
+		if len(_deviceSerialNo) == 0 {
+			if len(pack.HolderAssemblyId) > 0 { rma.AssemblyIds = append(rma.AssemblyIds, pack.HolderAssemblyId) }
+			if len(pack.ChargerAssemblyId) > 0 { rma.AssemblyIds = append(rma.AssemblyIds, pack.ChargerAssemblyId) }
+		}
+		rma.CaseId = _caseId
+
+		statusChange := RMA_Status_Change{}
+		statusChange.RMAStatus = RMA_REQUESTED
+		statusChange.Comment = _returnReason
+		statusChange.ChangedOn = _rmaCreationDate
+		statusChange.ChangedBy = user_name
+		rma.StatusHistory = append(rma.StatusHistory, statusChange)
+
+		bytes, err := json.Marshal(rma)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error converting RMA record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting RMA record") }
+
+		err = stub.PutState(_rmaId, bytes)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error storing RMA record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing RMA record") }
+
+		//Link the Package to the RMA - returned from now on
+		pack.PackageRMAId = _rmaId
+		pack.PackageStatus = PACKAGESTATUS_RET
+		pack.PackageLastUpdatedOn = _rmaCreationDate
+		pack.PackageLastUpdatedBy = user_name
+
+		pack.PackageHash = computePackageHash(pack)
+		bytesPackage, err := json.Marshal(pack)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Package record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting Package record") }
+
+		err = stub.PutState(_caseId, bytesPackage)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Package record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing Package record") }
+
+		/* PackageLine history ------------------------------------------Starts */
+		packLine_HolderKey := historyKey(_caseId) // Indicates history key
+
+		bytesPackageLines, err := getHistoryState(stub, _caseId, &PackageLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get bytesPackageLines") }
+
+		var packLine_Holder PackageLine_Holder
+
+		err = json.Unmarshal(bytesPackageLines, &packLine_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt bytesPackageLines record") }
+
+		packLine_Holder.PackageLines = append(packLine_Holder.PackageLines, pack) //appending the updated pack
+
+		bytesPackageLines, err = json.Marshal(packLine_Holder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating PackageLine_Holder record") }
+
+		err = stub.PutState(packLine_HolderKey, bytesPackageLines)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+		/* PackageLine history ------------------------------------------Ends */
+
+		/* GetAll changes-------------------------starts--------------------------*/
+		rmaID_Holder, err := getRMAIds(stub)
+		if err != nil { return nil, err }
+
+		rmaID_Holder.RMAIds = append(rmaID_Holder.RMAIds, _rmaId)
+
+		bytesRMAHolder, err := json.Marshal(rmaID_Holder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating RMA_ID_Holder record") }
+
+		err = stub.PutState("RMAs", bytesRMAHolder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+		/* GetAll changes---------------------------ends------------------------ */
+
+		fmt.Println("Created RMA successfully")
+
+		return nil, nil
+}
+
+//API to move an RMA to its next status
+//"args": ["RMA0001","REPLACED","CAS0099","Replacement shipped","pluser1"]
+//_rmaId,_rmaStatus,_replacementCaseId (only for REPLACED),_comment,user_name
+func (t *TnT) updateRMAStatus(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[4]
+
+		_rmaId := args[0]
+		_rmaStatus := args[1]
+		_replacementCaseId := args[2]
+		_comment := args[3]
+
+		_time:= time.Now().UTC()
+		_rmaLastUpdatedOn := _time.Format(DATE_FORMAT)
+
+		//get the RMA
+		rma, err := getRMA(stub, _rmaId)
+		if err != nil { return nil, err }
+
+		if !isValidRMATransition(rma.RMAStatus, _rmaStatus) {
+			return nil, tntError(ERR_INVALID_TRANSITION, "", "RMA can't move from status " + rma.RMAStatus + " to " + _rmaStatus)
+		}
+
+		if _rmaStatus == RMA_REPLACED {
+			if len(_replacementCaseId) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "replacementCaseId", "Replacement CaseId required for status REPLACED") }
+
+			replacementAsBytes, err := stub.GetState(_replacementCaseId)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Package") }
+			if replacementAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "replacementCaseId", "Replacement Package doesn't exists") }
+
+			rma.ReplacementCaseId = _replacementCaseId
+		} else if len(_replacementCaseId) > 0 {
+			return nil, tntError(ERR_INVALID_ARGUMENT, "replacementCaseId", "Replacement CaseId only allowed for status REPLACED")
+		}
+
+		rma.RMAStatus = _rmaStatus
+		rma.RMALastUpdatedOn = _rmaLastUpdatedOn
+		rma.RMALastUpdatedBy = user_name
+
+		statusChange := RMA_Status_Change{}
+		statusChange.RMAStatus = _rmaStatus
+		statusChange.Comment = _comment
+		statusChange.ChangedOn = _rmaLastUpdatedOn
+		statusChange.ChangedBy = user_name
+		rma.StatusHistory = append(rma.StatusHistory, statusChange)
+
+		bytes, err := json.Marshal(rma)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error converting RMA record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting RMA record") }
+
+		err = stub.PutState(_rmaId, bytes)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error storing RMA record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing RMA record") }
+
+		return nil, nil
+}
+
+//API to break a returned case back into its Assemblies
+//Returned Assemblies are released from the case with status 'Returned'; the Package keeps its history
+//Parameters = RMA0001, USERNAME
+func (t *TnT) breakRMACase(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[1]
+
+		_rmaId := args[0]
+
+		_time:= time.Now().UTC()
+		_assemblyLastUpdatedOn := _time.Format(DATE_FORMAT)
+		_assemblyLastUpdatedBy := user_name
+
+		//get the RMA
+		rma, err := getRMA(stub, _rmaId)
+		if err != nil { return nil, err }
+
+		if rma.CaseBroken { return nil, tntError(ERR_INVALID_TRANSITION, "", "Case already broken for RMA " + _rmaId) }
+		if rma.RMAStatus != RMA_RECEIVED && rma.RMAStatus != RMA_INSPECTED {
+			return nil, tntError(ERR_INVALID_TRANSITION, "", "Case can only be broken once the return is received")
+		}
+
+		for _, _assemblyId := range rma.AssemblyIds {
+
+			//get the Assembly
+			assemblyAsBytes, err := stub.GetState(_assemblyId)
+			if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id")	}
+			if assemblyAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+			assem := AssemblyLine{}
+			json.Unmarshal(assemblyAsBytes, &assem)
+
+			if assem.AssemblyPackage != rma.CaseId { continue } // Already released from the case
+
+			//update the AssemblyLine - released from the case
+			assem.AssemblyStatus = ASSEMBLYSTATUS_RET
+			assem.AssemblyPackage = ""
+			assem.AssemblyLastUpdatedOn = _assemblyLastUpdatedOn
+			assem.AssemblyLastUpdatedBy = _assemblyLastUpdatedBy
+
+			assem.AssemblyHash = computeAssemblyHash(assem)
+			bytes, err := json.Marshal(assem)
+			if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting Assembly record") }
+
+			err = stub.PutState(_assemblyId, bytes)
+			if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Assembly record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing Assembly record") }
+
+			/* AssemblyLine history ------------------------------------------Starts */
+			assemLine_HolderKey := historyKey(_assemblyId) // Indicates History Key for Assembly with ID = _assemblyId
+			bytesAssemblyLines, err := getHistoryState(stub, _assemblyId, &AssemblyLine_Holder{})
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+			var assemLine_Holder AssemblyLine_Holder
+
+			err = json.Unmarshal(bytesAssemblyLines, &assemLine_Holder)
+			if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt AssemblyLines record") }
+
+			assemLine_Holder.AssemblyLines = append(assemLine_Holder.AssemblyLines, assem) //appending the updated AssemblyLine
+
+			bytesAssemblyLines, err = json.Marshal(assemLine_Holder)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating AssemblyLine_Holder record") }
+
+			err = stub.PutState(assemLine_HolderKey, bytesAssemblyLines)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+			/* AssemblyLine history ------------------------------------------Ends */
+		}
+
+		rma.CaseBroken = true
+		rma.RMALastUpdatedOn = _assemblyLastUpdatedOn
+		rma.RMALastUpdatedBy = user_name
+
+		bytes, err := json.Marshal(rma)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error converting RMA record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error converting RMA record") }
+
+		err = stub.PutState(_rmaId, bytes)
+		if err != nil { fmt.Printf("SAVE_CHANGES: Error storing RMA record: %s", err); return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing RMA record") }
+
+		return nil, nil
+}
+
+//get the RMA against ID
+func (t *TnT) getRMAByID(stub Stub, args []string) ([]byte, error) {
+
+	_rmaId := args[0]
+
+	rma, err := getRMA(stub, _rmaId)
+	if err != nil { return nil, err }
+
+	mapB, _ := json.Marshal(rma)
+	return mapB, nil
+}
+
+//get all RMAs
+func (t *TnT) getAllRMAs(stub Stub, args []string) ([]byte, error) {
+
+	rmaID_Holder, err := getRMAIds(stub)
+	if err != nil { return nil, err }
+
+	res2E:= []*RMA_Line{}
+
+	for _, rmaId := range rmaID_Holder.RMAIds {
+
+		rmaAsBytes, err := stub.GetState(rmaId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get RMA")}
+
+		if rmaAsBytes != nil {
+			res := new(RMA_Line)
+			json.Unmarshal(rmaAsBytes, &res)
+			res2E=append(res2E,res)
+		}
+	}
+
+	mapB, _ := json.Marshal(res2E)
+	return mapB, nil
+}
+
+//Return rates grouped by the batch numbers of a batch type, or by ManufacturingPlant when batch type is empty
+//Shipped Assemblies are the ones in a case that left the plant in a Shipment, plus the returned ones
+func (t *TnT) computeReturnRates(stub Stub, _batchType string) ([]*Return_Rate, error) {
+
+	//Returned Assemblies with their return reason
+	rmaID_Holder, err := getRMAIds(stub)
+	if err != nil { return nil, err }
+
+	returnReasons := map[string]string{}
+	for _, rmaId := range rmaID_Holder.RMAIds {
+		rmaAsBytes, err := stub.GetState(rmaId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get RMA")}
+		if rmaAsBytes == nil { continue }
+
+		rma := RMA_Line{}
+		json.Unmarshal(rmaAsBytes, &rma)
+		for _, _assemblyId := range rma.AssemblyIds {
+			returnReasons[_assemblyId] = rma.ReturnReason
+		}
+	}
+
+	bytes, err := stub.GetState("Assemblies")
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+	var assemID_Holder AssemblyID_Holder
+
+	err = json.Unmarshal(bytes, &assemID_Holder)
+	if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Assemblies") }
+
+	res2E:= []*Return_Rate{}
+	rates := map[string]*Return_Rate{}
+	shippedCases := map[string]bool{}
+
+	for _, assemblyId := range assemID_Holder.AssemblyIDs {
+
+		assemblyAsBytes, err := stub.GetState(assemblyId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Assembly")}
+		if assemblyAsBytes == nil { continue }
+
+		res := AssemblyLine{}
+		json.Unmarshal(assemblyAsBytes, &res)
+
+		_returnReason, _returned := returnReasons[assemblyId]
+		if !_returned {
+			if len(res.AssemblyPackage) == 0 { continue }
+			_shipped, ok := shippedCases[res.AssemblyPackage]
+			if !ok {
+				_shipped, err = isPackageShipped(stub, res.AssemblyPackage)
+				if err != nil { return nil, err }
+				shippedCases[res.AssemblyPackage] = _shipped
+			}
+			if !_shipped { continue }
+		}
+
+		_key := res.ManufacturingPlant
+		if batchField := assemblyBatchField(&res, _batchType); batchField != nil { _key = *batchField }
+
+		rate, ok := rates[_key]
+		if !ok {
+			rate = &Return_Rate{Key: _key, ReturnReasons: map[string]int{}}
+			rates[_key] = rate
+			res2E=append(res2E,rate)
+		}
+
+		rate.AssembliesShipped++
+		if _returned {
+			rate.AssembliesReturned++
+			rate.ReturnReasons[_returnReason]++
+		}
+	}
+
+	for _, rate := range res2E {
+		rate.ReturnRate = float64(rate.AssembliesReturned) / float64(rate.AssembliesShipped)
+	}
+
+	return res2E, nil
+}
+
+//Return rates per batch number of a batch type
+//Parameters = LedBatchId, USERNAME
+func (t *TnT) getReturnRatesByBatch(stub Stub, args []string) ([]byte, error) {
+
+	_batchType := args[0]
+
+	if assemblyBatchField(&AssemblyLine{}, _batchType) == nil { return nil, tntError(ERR_INVALID_ARGUMENT, "batchType", "Unknown batch type " + _batchType) }
+
+	res2E, err := t.computeReturnRates(stub, _batchType)
+	if err != nil { return nil, err }
+
+	mapB, _ := json.Marshal(res2E)
+	return mapB, nil
+}
+
+//Return rates per ManufacturingPlant
+//Parameters = USERNAME
+func (t *TnT) getReturnRatesByPlant(stub Stub, args []string) ([]byte, error) {
+
+	res2E, err := t.computeReturnRates(stub, "")
+	if err != nil { return nil, err }
+
+	mapB, _ := json.Marshal(res2E)
+	return mapB, nil
+}
+
+/* Shipment section */
+
+// Custody handover of a Shipment - made by the custodian and accepted by the receiving user, each submitting with the
+// identity registerUser bound them to (see checkUserIdentity)
+type Custody_Transfer struct {
+	FromUser 		string `json:"fromUser"`
+	FromRole 		string `json:"fromRole"`
+	ToUser 			string `json:"toUser"`
+	ToRole 			string `json:"toRole"`
+	Location 		string `json:"location"`
+	HandedOverOn 	string `json:"handedOverOn"`
+	HandedOverBy 	string `json:"handedOverBy,omitempty"` // Identity that submitted the handover
+	AcceptedOn 		string `json:"acceptedOn"` // Empty while the handover is pending
+	AcceptLocation 	string `json:"acceptLocation"`
+	AcceptedBy 		string `json:"acceptedBy,omitempty"` // Identity that submitted the acceptance
+}
+
+// Shipment of one or more Packages
+type Shipment_Line struct {
+	ShipmentId 			string `json:"shipmentId"`
+	CaseIds 			[]string `json:"caseIds"`
+	Carrier 			string `json:"carrier"`
+	TrackingNumber 		string `json:"trackingNumber"`
+	OriginPlant 		string `json:"originPlant"`
+	Destination 		string `json:"destination"`
+	ShipmentStatus 		string `json:"shipmentStatus"`
+	Custodian 			string `json:"custodian"`
+	CustodianRole 		string `json:"custodianRole"`
+	CustodianIdentity 	string `json:"custodianIdentity,omitempty"` // Identity holding custody, empty on Shipments from before identities
+	CustodyTransfers 	[]Custody_Transfer `json:"custodyTransfers"`
+	ShipmentCreationDate 	string `json:"shipmentCreationDate"`
+	ShipmentCreatedBy 		string `json:"shipmentCreatedBy"`
+	SchemaVersion 			Schema_Version `json:"schemaVersion"`
+}
+
+//Shipment IDs - stored against "Shipments"
+type Shipment_ID_Holder struct {
+	ShipmentIds 	[]string `json:"shipmentIds"`
+	SchemaVersion 	Schema_Version `json:"schemaVersion"`
+}
+
+//Shipments a Package travelled in - stored against caseShipmentsKey
+type Case_Shipment_Holder struct {
+	ShipmentIds 	[]string `json:"shipmentIds"`
+	SchemaVersion 	Schema_Version `json:"schemaVersion"`
+}
+
+func caseShipmentsKey(_caseId string) string {
+	return "CASESHIP|" + _caseId // Indicates case shipment key
+}
+
+//get the Shipments a Package travelled in, empty if never shipped
+func getCaseShipments(stub Stub, _caseId string) (Case_Shipment_Holder, error) {
+	var caseShipment_Holder Case_Shipment_Holder
+
+	bytesCaseShipments, err := getMovedState(stub, caseShipmentsKey(_caseId), _caseId + "T", &Case_Shipment_Holder{}, _caseId)
+	if err != nil { return caseShipment_Holder, tntError(ERR_CORRUPT_STATE, "", "Unable to get Package Shipments") }
+	if bytesCaseShipments == nil { caseShipment_Holder.SchemaVersion = SCHEMA_VERSION; return caseShipment_Holder, nil }
+
+	err = json.Unmarshal(bytesCaseShipments, &caseShipment_Holder)
+	if err != nil {	return caseShipment_Holder, tntError(ERR_CORRUPT_STATE, "", "Corrupt Case_Shipment_Holder record") }
+
+	return caseShipment_Holder, nil
+}
+
+//Stubs that know the identity submitting the transaction - the Fabric contract API stub has it, the legacy shim stub not
+type creatorStub interface {
+	GetCreator() ([]byte, error)
+}
+
+//Identity submitting the transaction, as the SHA-256 of its serialized certificate in hex. Empty when the stub doesn't
+//provide it, the user name argument is all there is then
+func creatorIdentity(stub Stub) (string, error) {
+	creatorStub, ok := stub.(creatorStub)
+	if !ok { return "", nil }
+
+	creator, err := creatorStub.GetCreator()
+	if err != nil { return "", tntError(ERR_CORRUPT_STATE, "", "Unable to get the submitting identity") }
+	if len(creator) == 0 { return "", nil }
+
+	hash := sha256.Sum256(creator)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+//Identity submitting the transaction, which must be the one user_name is registered with - custody of Shipments and
+//customer details only pass between bound identities, so one user can't act for another. The legacy shim has no
+//identities, it can't
+func checkUserIdentity(stub Stub, user_name string) (string, error) {
+	_identity, err := creatorIdentity(stub)
+	if err != nil { return "", err }
+	if len(_identity) == 0 { return "", tntError(ERR_FORBIDDEN, "user", "Acting as user " + user_name + " needs the submitting identity, which only the contract API chaincode (chaincode/v2) has") }
+
+	_bound, err := stub.GetState(userIdentityKey(user_name))
+	if err != nil { return "", tntError(ERR_CORRUPT_STATE, "", "Unable to get the user identity") }
+	if _bound == nil { return "", tntError(ERR_FORBIDDEN, "user", "User " + user_name + " has no identity, it must be registered with registerUser") }
+	if string(_bound) != _identity { return "", tntError(ERR_FORBIDDEN, "user", "Submitting identity is not the one user " + user_name + " is registered with") }
+
+	return _identity, nil
+}
+
+//The calling user - the user argument, or when it is left out the user the submitting identity is registered for
+func callingUser(stub Stub, args []string, _userArg int) (string, error) {
+	if _userArg < len(args) { return args[_userArg], nil }
+
+	_identity, err := creatorIdentity(stub)
+	if err != nil { return "", err }
+	if len(_identity) == 0 { return "", tntError(ERR_INVALID_ARGUMENT, "user", "User name not supplied and the peer doesn't provide the submitting identity") }
+
+	_user, err := stub.GetState(identityUserKey(_identity))
+	if err != nil { return "", tntError(ERR_CORRUPT_STATE, "", "Unable to get the identity user") }
+	if _user == nil { return "", tntError(ERR_FORBIDDEN, "user", "No user is registered with the submitting identity") }
+
+	return string(_user), nil
+}
+
+//A Package is shipped once a Shipment carrying it left the plant - the first handover was accepted
+func isPackageShipped(stub Stub, _caseId string) (bool, error) {
+	caseShipment_Holder, err := getCaseShipments(stub, _caseId)
+	if err != nil { return false, err }
+
+	for _, _shipmentId := range caseShipment_Holder.ShipmentIds {
+		shipment, err := getShipment(stub, _shipmentId)
+		if err != nil { return false, err }
+		if shipment.ShipmentStatus != SHIPMENT_CREATED { return true, nil }
+	}
+	return false, nil
+}
+
+//get the Shipment against ID
+func getShipment(stub Stub, _shipmentId string) (Shipment_Line, error) {
+	shipment := Shipment_Line{}
+
+	shipmentAsBytes, err := stub.GetState(_shipmentId)
+	if err != nil { return shipment, tntError(ERR_CORRUPT_STATE, "", "Failed to get Shipment") }
+	if shipmentAsBytes == nil { return shipment, tntError(ERR_NOT_FOUND, "shipmentId", "Shipment doesn't exists") }
+
+	err = json.Unmarshal(shipmentAsBytes, &shipment)
+	if err != nil {	return shipment, tntError(ERR_CORRUPT_STATE, "", "Corrupt Shipment record") }
+	if shipment.ShipmentId != _shipmentId { return Shipment_Line{}, tntError(ERR_NOT_FOUND, "shipmentId", "Shipment doesn't exists") }
+
+	return shipment, nil
+}
+
+func putShipment(stub Stub, shipment Shipment_Line) error {
+	bytes, err := json.Marshal(shipment)
+	if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Shipment record: %s", err); return tntError(ERR_CORRUPT_STATE, "", "Error converting Shipment record") }
+
+	err = stub.PutState(shipment.ShipmentId, bytes)
+	if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Shipment record: %s", err); return tntError(ERR_CORRUPT_STATE, "", "Error storing Shipment record") }
+
+	return nil
+}
+
+//API to create a Shipment - the creating packaging plant user holds the first custody
+//"args": ["SHP0001","[\"CAS0001\",\"CAS0002\"]","DHL","TRK123456","MAN0002","Retail DC North","pluser1"]
+//_shipmentId,_caseIds,_carrier,_trackingNumber,_originPlant,_destination,user_name
+func (t *TnT) createShipment(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[6]
+
+		_shipmentId := args[0]
+		_caseIds := args[1]
+		_carrier := args[2]
+		_trackingNumber := args[3]
+		_originPlant := args[4]
+		_destination := args[5]
+
+		_time:= time.Now().UTC()
+		_shipmentCreationDate := _time.Format(DATE_FORMAT)
+
+		if len(_shipmentId) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "shipmentId", "ShipmentId supplied as empty") }
+		if err := checkRecordId(_shipmentId, "shipmentId"); err != nil { return nil, err }
+		if len(_carrier) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "carrier", "Carrier supplied as empty") }
+		if len(_originPlant) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "originPlant", "OriginPlant supplied as empty") }
+		if len(_destination) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "destination", "Destination supplied as empty") }
+
+		_identity, err := checkUserIdentity(stub, user_name)
+		if err != nil { return nil, err }
+
+		shipment := Shipment_Line{}
+		shipment.SchemaVersion = SCHEMA_VERSION
+		err = json.Unmarshal([]byte(_caseIds), &shipment.CaseIds)
+		if err != nil { return nil, tntError(ERR_INVALID_ARGUMENT, "caseIds", "CaseIds must be a JSON array of CaseIds") }
+		if len(shipment.CaseIds) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "caseIds", "No CaseIds supplied") }
+
+	//Checking if the Shipment already exists
+		shipmentAsBytes, err := stub.GetState(_shipmentId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Shipment") }
+		if shipmentAsBytes != nil { return nil, tntError(ERR_ALREADY_EXISTS, "shipmentId", "Shipment already exists") }
+
+		//Every Package must exist and not be travelling in another Shipment
+		_seen := map[string]bool{}
+		for _, _caseId := range shipment.CaseIds {
+			if _seen[_caseId] { return nil, tntError(ERR_INVALID_ARGUMENT, "caseIds", "CaseId " + _caseId + " supplied more than once") }
+			_seen[_caseId] = true
+
+			packageAsBytes, err := stub.GetState(_caseId)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Package") }
+			if packageAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "caseId", "Package " + _caseId + " doesn't exists") }
+
+			pack := PackageLine{}
+			json.Unmarshal(packageAsBytes, &pack)
+			if len(pack.PackageRMAId) > 0 { return nil, tntError(ERR_INVALID_TRANSITION, "", "Package " + _caseId + " has been returned") }
+			if pack.PackageStatus != PACKAGESTATUS_PKG { return nil, tntError(ERR_INVALID_TRANSITION, "", "Package " + _caseId + " is not 'Packaged', its status is " + pack.PackageStatus) }
+
+			caseShipment_Holder, err := getCaseShipments(stub, _caseId)
+			if err != nil { return nil, err }
+			if len(caseShipment_Holder.ShipmentIds) > 0 {
+				_lastShipmentId := caseShipment_Holder.ShipmentIds[len(caseShipment_Holder.ShipmentIds)-1]
+				lastShipment, err := getShipment(stub, _lastShipmentId)
+				if err != nil { return nil, err }
+				if lastShipment.ShipmentStatus != SHIPMENT_DELIVERED {
+					return nil, tntError(ERR_INVALID_TRANSITION, "", "Package " + _caseId + " is already in Shipment " + _lastShipmentId)
+				}
+			}
+		}
+
+		shipment.ShipmentId = _shipmentId
+		shipment.Carrier = _carrier
+		shipment.TrackingNumber = _trackingNumber
+		shipment.OriginPlant = _originPlant
+		shipment.Destination = _destination
+		shipment.ShipmentStatus = SHIPMENT_CREATED
+		shipment.Custodian = user_name
+		shipment.CustodianRole = PACKAGELINE_ROLE
+		shipment.CustodianIdentity = _identity
+		shipment.ShipmentCreationDate = _shipmentCreationDate
+		shipment.ShipmentCreatedBy = user_name
+
+		err = putShipment(stub, shipment)
+		if err != nil { return nil, err }
+
+		//Link the Packages to the Shipment
+		for _, _caseId := range shipment.CaseIds {
+			caseShipment_Holder, err := getCaseShipments(stub, _caseId)
+			if err != nil { return nil, err }
+
+			caseShipment_Holder.ShipmentIds = append(caseShipment_Holder.ShipmentIds, _shipmentId)
+
+			bytesCaseShipments, err := json.Marshal(caseShipment_Holder)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating Case_Shipment_Holder record") }
+
+			err = stub.PutState(caseShipmentsKey(_caseId), bytesCaseShipments)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+		}
+
+		/* GetAll changes-------------------------starts--------------------------*/
+		var shipmentID_Holder Shipment_ID_Holder
+
+		bytesShipmentHolder, err := stub.GetState("Shipments")
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Shipments") }
+		if bytesShipmentHolder != nil {
+			err = json.Unmarshal(bytesShipmentHolder, &shipmentID_Holder)
+			if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Shipments record") }
+		} else {
+			shipmentID_Holder.SchemaVersion = SCHEMA_VERSION
+		}
+
+		shipmentID_Holder.ShipmentIds = append(shipmentID_Holder.ShipmentIds, _shipmentId)
+
+		bytesShipmentHolder, err = json.Marshal(shipmentID_Holder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating Shipment_ID_Holder record") }
+
+		err = stub.PutState("Shipments", bytesShipmentHolder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+		/* GetAll changes---------------------------ends------------------------ */
+
+		fmt.Println("Created Shipment successfully")
+
+		return nil, nil
+}
+
+//API for the current custodian to hand a Shipment over - custody passes once the receiver accepts
+//Parameters = SHP0001, RECEIVING USERNAME, LOCATION, USERNAME
+func (t *TnT) handOverShipment(stub Stub, args []string) ([]byte, error) {
+
+	//The role is checked by checkRole, it is recorded on the transfer
+	user_name := args[3]
+	ecert_role, err := t.get_ecert(stub, user_name)
+	if err != nil { return nil, err }
+	user_role := string(ecert_role)
+
+		_shipmentId := args[0]
+		_toUser := args[1]
+		_location := args[2]
+
+		shipment, err := getShipment(stub, _shipmentId)
+		if err != nil { return nil, err }
+
+		if shipment.ShipmentStatus == SHIPMENT_DELIVERED { return nil, tntError(ERR_INVALID_TRANSITION, "", "Shipment already delivered") }
+		if shipment.Custodian != user_name { return nil, tntError(ERR_FORBIDDEN, "user", "Only the current custodian can hand over the Shipment") }
+
+		_identity, err := checkUserIdentity(stub, user_name)
+		if err != nil { return nil, err }
+		if _toUser == user_name { return nil, tntError(ERR_INVALID_ARGUMENT, "toUser", "Shipment can't be handed over to the current custodian") }
+
+		_lastIndex := len(shipment.CustodyTransfers) - 1
+		if _lastIndex >= 0 && len(shipment.CustodyTransfers[_lastIndex].AcceptedOn) == 0 {
+			return nil, tntError(ERR_INVALID_TRANSITION, "", "Previous handover to " + shipment.CustodyTransfers[_lastIndex].ToUser + " not yet accepted")
+		}
+
+		to_role, err := t.get_ecert(stub, _toUser)
+		if err != nil {return nil, tntError(ERR_CORRUPT_STATE, "", "userrole couldn't be retrieved")}
+		if to_role == nil {return nil, tntError(ERR_NOT_FOUND, "toUser", "receiving username not defined")}
+		if string(to_role) != CARRIER_ROLE &&
+			string(to_role) != DISTRIBUTOR_ROLE &&
+			string(to_role) != RETAILER_ROLE {
+			return nil, tntError(ERR_INVALID_ARGUMENT, "toUser", "Shipment can only be handed over to a Carrier, Distributor or Retailer")
+		}
+
+		//The receiver accepts with its bound identity, see checkUserIdentity
+		_toIdentity, err := stub.GetState(userIdentityKey(_toUser))
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get the user identity") }
+		if _toIdentity == nil { return nil, tntError(ERR_INVALID_ARGUMENT, "toUser", "Receiving user has no identity, it must be registered with registerUser") }
+
+		transfer := Custody_Transfer{}
+		transfer.FromUser = user_name
+		transfer.FromRole = user_role
+		transfer.ToUser = _toUser
+		transfer.ToRole = string(to_role)
+		transfer.Location = _location
+		transfer.HandedOverOn = time.Now().UTC().Format(DATE_FORMAT)
+		transfer.HandedOverBy = _identity
+		shipment.CustodyTransfers = append(shipment.CustodyTransfers, transfer)
+
+		err = putShipment(stub, shipment)
+		if err != nil { return nil, err }
+
+		return nil, nil
+}
+
+//API for the receiver to accept a pending handover - the Shipment is delivered when a Retailer accepts
+//Parameters = SHP0001, LOCATION, USERNAME
+func (t *TnT) acceptShipment(stub Stub, args []string) ([]byte, error) {
+
+	//The role is checked by checkRole, it becomes the custodian's
+	user_name := args[2]
+	ecert_role, err := t.get_ecert(stub, user_name)
+	if err != nil { return nil, err }
+	user_role := string(ecert_role)
+
+		_shipmentId := args[0]
+		_location := args[1]
+
+		shipment, err := getShipment(stub, _shipmentId)
+		if err != nil { return nil, err }
+
+		_lastIndex := len(shipment.CustodyTransfers) - 1
+		if _lastIndex < 0 || len(shipment.CustodyTransfers[_lastIndex].AcceptedOn) > 0 {
+			return nil, tntError(ERR_INVALID_TRANSITION, "", "No pending handover for Shipment " + _shipmentId)
+		}
+		if shipment.CustodyTransfers[_lastIndex].ToUser != user_name {
+			return nil, tntError(ERR_INVALID_TRANSITION, "", "Pending handover is addressed to " + shipment.CustodyTransfers[_lastIndex].ToUser)
+		}
+
+		//The receiver accepts with its own identity, not the one that handed over
+		_identity, err := checkUserIdentity(stub, user_name)
+		if err != nil { return nil, err }
+		if _identity == shipment.CustodyTransfers[_lastIndex].HandedOverBy {
+			return nil, tntError(ERR_FORBIDDEN, "user", "Handover must be accepted by the receiving identity, not the one that handed over")
+		}
+
+		shipment.CustodyTransfers[_lastIndex].AcceptedOn = time.Now().UTC().Format(DATE_FORMAT)
+		shipment.CustodyTransfers[_lastIndex].AcceptLocation = _location
+		shipment.CustodyTransfers[_lastIndex].AcceptedBy = _identity
+		shipment.Custodian = user_name
+		shipment.CustodianRole = user_role
+		shipment.CustodianIdentity = _identity
+		shipment.ShipmentStatus = SHIPMENT_IN_TRANSIT
+		if user_role == RETAILER_ROLE { shipment.ShipmentStatus = SHIPMENT_DELIVERED }
+
+		err = putShipment(stub, shipment)
+		if err != nil { return nil, err }
+
+		return nil, nil
+}
+
+//get the Shipment against ID
+func (t *TnT) getShipmentByID(stub Stub, args []string) ([]byte, error) {
+
+	_shipmentId := args[0]
+
+	shipment, err := getShipment(stub, _shipmentId)
+	if err != nil { return nil, err }
+
+	mapB, _ := json.Marshal(shipment)
+	return mapB, nil
+}
+
+//Chain of custody of a Package - every Shipment it travelled in, oldest first
+func (t *TnT) getCustodyChainByCaseId(stub Stub, args []string) ([]byte, error) {
+
+	_caseId := args[0]
+
+	caseShipment_Holder, err := getCaseShipments(stub, _caseId)
+	if err != nil { return nil, err }
+
+	res2E:= []Shipment_Line{}
+	for _, _shipmentId := range caseShipment_Holder.ShipmentIds {
+		shipment, err := getShipment(stub, _shipmentId)
+		if err != nil { return nil, err }
+		res2E=append(res2E,shipment)
+	}
+
+	mapB, _ := json.Marshal(res2E)
+	return mapB, nil
+}
+
+/* Private customer data section */
+
+//Customer PII of a Package - kept in the private data collection, only its hash is on the public PackageLine
+type Customer_Details struct {
+	CaseId 				string `json:"caseId"`
+	ShippingToAddress 	string `json:"shippingToAddress"`
+	CustomerName 		string `json:"customerName"`
+	CustomerPhone 		string `json:"customerPhone"`
+	CustomerEmail 		string `json:"customerEmail"`
+	LastUpdatedOn 		string `json:"lastUpdatedOn"`
+	LastUpdatedBy 		string `json:"lastUpdatedBy"`
+	Salt 				string `json:"salt,omitempty"` // Secret salt of the hash, see customerDetailsSalt
+	SchemaVersion 		Schema_Version `json:"schemaVersion"`
+}
+
+// Private data API of peers supporting private data collections (see collections_config.json)
+type privateDataStub interface {
+	GetPrivateData(collection string, key string) ([]byte, error)
+	PutPrivateData(collection string, key string, value []byte) error
+}
+
+//Private data goes to the collection only - peers without private data support (the legacy shim) can't keep it at all,
+//it is never written to the world state instead
+func hasPrivateData(stub Stub) bool {
+	_, ok := stub.(privateDataStub)
+	return ok
+}
+
+func errNoPrivateData() error {
+	return tntError(ERR_FORBIDDEN, "", "Private data collections are not supported by this peer, customer details can't be kept - run the contract API chaincode (chaincode/v2)")
+}
+
+//get private data from the collection
+func getPrivateData(stub Stub, collection string, key string) ([]byte, error) {
+	privateStub, ok := stub.(privateDataStub)
+	if !ok { return nil, errNoPrivateData() }
+
+	bytes, err := privateStub.GetPrivateData(collection, key)
+	if _, ok := err.(*TnT_Error); ok { return nil, err } // From a dry run stub
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get the private data") }
+	return bytes, nil
+}
+
+//put private data into the collection
+func putPrivateData(stub Stub, collection string, key string, value []byte) error {
+	privateStub, ok := stub.(privateDataStub)
+	if !ok { return errNoPrivateData() }
+
+	err := privateStub.PutPrivateData(collection, key, value)
+	if _, ok := err.(*TnT_Error); ok { return err } // From a dry run stub
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Unable to put the private data") }
+	return nil
+}
+
+//World state key the customer details were kept under on peers without private data support, before schema version 3
+func publicPrivateDataKey(collection string, key string) string {
+	return "PRIVATE|" + collection + "|" + key
+}
+
+//Stubs carrying the transient map of the transaction proposal - data the chaincode reads but the ledger never records
+type transientStub interface {
+	GetTransient() (map[string][]byte, error)
+}
+
+//Field of the transient map, empty when the stub has no transient map or the field isn't in it
+//Customer PII is taken from here so it stays out of the transaction arguments, which every block records
+func getTransientField(stub Stub, _field string) (string, error) {
+	transStub, ok := stub.(transientStub)
+	if !ok { return "", nil }
+
+	transient, err := transStub.GetTransient()
+	if err != nil { return "", tntError(ERR_INVALID_ARGUMENT, _field, "Unable to get the transient data") }
+	return string(transient[_field]), nil
+}
+
+//Canonical hash of the Customer_Details stored on the public PackageLine
+func computeCustomerDetailsHash(details Customer_Details) string {
+	var buf []byte
+	buf = writeHashField(buf, details.CaseId)
+	buf = writeHashField(buf, details.ShippingToAddress)
+	buf = writeHashField(buf, details.CustomerName)
+	buf = writeHashField(buf, details.CustomerPhone)
+	buf = writeHashField(buf, details.CustomerEmail)
+	buf = writeHashField(buf, details.LastUpdatedOn)
+	buf = writeHashField(buf, details.LastUpdatedBy)
+	// Details stored before the hash was salted keep their unsalted hash until they are next written
+	if len(details.Salt) > 0 {
+		buf = writeHashField(buf, "salt")
+		buf = writeHashField(buf, details.Salt)
+	}
+
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+//Salt of the Customer details hash of a Package, derived from the random customerDetailsSalt of the transient map
+//The public hash of details as guessable as a phone number could otherwise be reversed by hashing every candidate;
+//the salt is kept with the details in the private data collection only
+func customerDetailsSalt(stub Stub, _caseId string) (string, error) {
+	_seed, err := getTransientField(stub, "customerDetailsSalt")
+	if err != nil { return "", err }
+	if len(_seed) < CUSTOMER_DETAILS_SALT_MIN {
+		return "", tntError(ERR_INVALID_ARGUMENT, "customerDetailsSalt", fmt.Sprintf("Customer details need a random customerDetailsSalt of at least %d bytes in the transient map", CUSTOMER_DETAILS_SALT_MIN))
+	}
+
+	mac := hmac.New(sha256.New, []byte(_seed))
+	mac.Write([]byte(_caseId))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+//get the Customer_Details of a Package, empty if none were stored
+func getCustomerDetails(stub Stub, _caseId string) (Customer_Details, error) {
+	details := Customer_Details{}
+	details.CaseId = _caseId
+
+	bytesDetails, err := getPrivateData(stub, CUSTOMER_DETAILS_COLLECTION, _caseId)
+	if err != nil { return details, err }
+	if bytesDetails == nil { details.SchemaVersion = SCHEMA_VERSION; return details, nil }
+
+	err = json.Unmarshal(bytesDetails, &details)
+	if err != nil {	return details, tntError(ERR_CORRUPT_STATE, "", "Corrupt Customer details record") }
+
+	return details, nil
+}
+
+//Store the Customer_Details privately and return the hash for the public PackageLine - salted, see customerDetailsSalt
+func putCustomerDetails(stub Stub, details Customer_Details) (string, error) {
+	var err error
+	if len(details.Salt) == 0 {
+		details.Salt, err = customerDetailsSalt(stub, details.CaseId)
+		if err != nil { return "", err }
+	}
+
+	err = storeCustomerDetails(stub, details)
+	if err != nil { return "", err }
+
+	return computeCustomerDetailsHash(details), nil
+}
+
+//Store the Customer_Details privately as they are
+func storeCustomerDetails(stub Stub, details Customer_Details) error {
+	bytesDetails, err := json.Marshal(details)
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Error creating Customer details record") }
+
+	return putPrivateData(stub, CUSTOMER_DETAILS_COLLECTION, details.CaseId, bytesDetails)
+}
+
+//Store the shipping address of a Package privately, keeping the other Customer details
+//An empty address keeps the stored one - clients never see it on the public record to send it back. Peers without
+//private data keep no address at all
+func putShippingToAddress(stub Stub, _caseId string, _shippingToAddress string, _updatedOn string, _updatedBy string) (string, error) {
+	if len(_shippingToAddress) == 0 && !hasPrivateData(stub) { return "", nil }
+
+	details, err := getCustomerDetails(stub, _caseId)
+	if err != nil { return "", err }
+
+	if len(_shippingToAddress) == 0 {
+		if len(details.LastUpdatedOn) == 0 { return "", nil } // Nothing stored yet
+		return computeCustomerDetailsHash(details), nil
+	}
+
+	details.ShippingToAddress = _shippingToAddress
+	details.LastUpdatedOn = _updatedOn
+	details.LastUpdatedBy = _updatedBy
+
+	return putCustomerDetails(stub, details)
+}
+
+//Write a new PackageLine version, hashed, and append it to the history
+func savePackageLine(stub Stub, pack PackageLine) error {
+	pack.PackageHash = computePackageHash(pack)
+	bytes, err := json.Marshal(pack)
+	if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Package record: %s", err); return tntError(ERR_CORRUPT_STATE, "", "Error converting Package record") }
+
+	err = stub.PutState(pack.CaseId, bytes)
+	if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Package record: %s", err); return tntError(ERR_CORRUPT_STATE, "", "Error storing Package record") }
+
+	/* PackageLine history ------------------------------------------Starts */
+	packLine_HolderKey := historyKey(pack.CaseId) // Indicates history key
+
+	bytesPackageLines, err := getHistoryState(stub, pack.CaseId, &PackageLine_Holder{})
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Unable to get bytesPackageLines") }
+
+	var packLine_Holder PackageLine_Holder
+
+	err = json.Unmarshal(bytesPackageLines, &packLine_Holder)
+	if err != nil {	return tntError(ERR_CORRUPT_STATE, "", "Corrupt bytesPackageLines record") }
+
+	packLine_Holder.PackageLines = append(packLine_Holder.PackageLines, pack) //appending the updated pack
+
+	bytesPackageLines, err = json.Marshal(packLine_Holder)
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Error creating PackageLine_Holder record") }
+
+	err = stub.PutState(packLine_HolderKey, bytesPackageLines)
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+	/* PackageLine history ------------------------------------------Ends */
+
+	return nil
+}
+
+//API to store the Customer details of a Package in the private data collection
+//"args": ["CAS0001","","","","","pluser1"], the details in the transient map - they never appear in the transaction
+//_caseId,customerName,customerPhone,customerEmail,shippingToAddress,user_name - the details left empty; transient
+//customerName,customerPhone,customerEmail,shippingToAddress (empty keeps the stored one),customerDetailsSalt
+func (t *TnT) updatePackageCustomerDetails(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[5]
+
+		_caseId := args[0]
+		_fields := map[string]string{}
+		for _, _field := range customerDetailsTransient {
+			_value, err := getTransientField(stub, _field)
+			if err != nil { return nil, err }
+			_fields[_field] = _value
+		}
+		_shippingToAddress := _fields["shippingToAddress"]
+
+		_time:= time.Now().UTC()
+		_packageLastUpdatedOn := _time.Format(DATE_FORMAT)
+
+		//Check the Shipping address - empty keeps the stored one
+		var err error
+		if len(_shippingToAddress) > 0 {
+			_shippingToAddress, err = storedShippingAddress(_shippingToAddress)
+			if err != nil { return nil, err }
+		}
+
+		//get the Package
+		packageAsBytes, err := stub.GetState(_caseId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Package") }
+		if packageAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "caseId", "Package doesn't exists") }
+
+		pack := PackageLine{}
+		json.Unmarshal(packageAsBytes, &pack)
+
+		details, err := getCustomerDetails(stub, _caseId)
+		if err != nil { return nil, err }
+
+		details.CustomerName = _fields["customerName"]
+		details.CustomerPhone = _fields["customerPhone"]
+		details.CustomerEmail = _fields["customerEmail"]
+		if len(_shippingToAddress) > 0 { details.ShippingToAddress = _shippingToAddress }
+		details.LastUpdatedOn = _packageLastUpdatedOn
+		details.LastUpdatedBy = user_name
+
+		pack.CustomerDetailsHash, err = putCustomerDetails(stub, details)
+		if err != nil { return nil, err }
+
+		pack.PackageLastUpdatedOn = _packageLastUpdatedOn
+		pack.PackageLastUpdatedBy = user_name
+
+		err = savePackageLine(stub, pack)
+		if err != nil { return nil, err }
+
+		return nil, nil
+}
+
+//get the Customer details of a Package - shipper and recipients only, submitting with the identity they are registered
+//with. The user may be left out, it is then the one of the submitting identity
+//_caseId[,user_name]
+func (t *TnT) getPackageCustomerDetails(stub Stub, args []string) ([]byte, error) {
+
+	_caseId := args[0]
+	user_name, err := callingUser(stub, args, 1)
+	if err != nil { return nil, err }
+
+	_, err = checkUserIdentity(stub, user_name)
+	if err != nil { return nil, err }
+
+	_allowed, err := isShipperOrRecipient(stub, _caseId, user_name)
+	if err != nil { return nil, err }
+	if !_allowed { return nil, tntError(ERR_FORBIDDEN, "user", "Only the shipper and the recipients of the Package see its Customer details") }
+
+	details, err := getCustomerDetails(stub, _caseId)
+	if err != nil { return nil, err }
+
+	mapB, _ := json.Marshal(details)
+	return mapB, nil
+}
+
+//The shipper of a Package is the user who packed it or created a Shipment carrying it, its recipients the users who
+//accepted a handover of such a Shipment
+func isShipperOrRecipient(stub Stub, _caseId string, user_name string) (bool, error) {
+	packageAsBytes, err := stub.GetState(_caseId)
+	if err != nil { return false, tntError(ERR_CORRUPT_STATE, "", "Failed to get Package") }
+	if packageAsBytes == nil { return false, tntError(ERR_NOT_FOUND, "caseId", "Package doesn't exists") }
+
+	pack := PackageLine{}
+	err = json.Unmarshal(packageAsBytes, &pack)
+	if err != nil || pack.CaseId != _caseId { return false, tntError(ERR_NOT_FOUND, "caseId", "Package doesn't exists") }
+	if pack.PackageCreatedBy == user_name { return true, nil }
+
+	caseShipment_Holder, err := getCaseShipments(stub, _caseId)
+	if err != nil { return false, err }
+
+	for _, _shipmentId := range caseShipment_Holder.ShipmentIds {
+		shipment, err := getShipment(stub, _shipmentId)
+		if err != nil { return false, err }
+		if shipment.ShipmentCreatedBy == user_name { return true, nil }
+		for _, transfer := range shipment.CustodyTransfers {
+			if transfer.ToUser == user_name && len(transfer.AcceptedOn) > 0 { return true, nil }
+		}
+	}
+	return false, nil
+}
+
+//Move the clear text shipping address of a Package into the private data collection, true if anything was rewritten
+//Earlier history versions lose the address but keep their PackageHash, so records exported before still verify - see
+//verifyPackage. The current record moves its address in a new version, which keeps the hash it replaces
+func privatisePackageAddress(stub Stub, caseId string, _updatedOn string, _updatedBy string) (bool, error) {
+	packageAsBytes, err := stub.GetState(caseId)
+	if err != nil { return false, tntError(ERR_CORRUPT_STATE, "", "Failed to get Package")}
+	if packageAsBytes == nil { return false, nil }
+
+	pack := PackageLine{}
+	json.Unmarshal(packageAsBytes, &pack)
+
+	//Redact the addresses kept in the history
+	_changed := false
+	packLine_HolderKey := historyKey(caseId) // Indicates history key
+	bytesPackageLines, err := getHistoryState(stub, caseId, &PackageLine_Holder{})
+	if err != nil { return false, tntError(ERR_CORRUPT_STATE, "", "Unable to get bytesPackageLines") }
+	if bytesPackageLines != nil {
+		var packLine_Holder PackageLine_Holder
+
+		err = json.Unmarshal(bytesPackageLines, &packLine_Holder)
+		if err != nil {	return false, tntError(ERR_CORRUPT_STATE, "", "Corrupt bytesPackageLines record") }
+
+		for i := range packLine_Holder.PackageLines {
+			if len(packLine_Holder.PackageLines[i].ShippingToAddress) == 0 { continue }
+			packLine_Holder.PackageLines[i].ShippingToAddress = ""
+			packLine_Holder.PackageLines[i].ShippingToAddressRedacted = true
+			_changed = true
+		}
+
+		if _changed {
+			bytesPackageLines, err = json.Marshal(packLine_Holder)
+			if err != nil { return false, tntError(ERR_CORRUPT_STATE, "", "Error creating PackageLine_Holder record") }
+
+			err = stub.PutState(packLine_HolderKey, bytesPackageLines)
+			if err != nil { return false, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+		}
+	}
+
+	//Current address goes to the private data collection, in a new version
+	if len(pack.ShippingToAddress) > 0 {
+		pack.PrivatisedFromHash = pack.PackageHash
+		if len(pack.PrivatisedFromHash) == 0 { pack.PrivatisedFromHash = computePackageHash(pack) }
+
+		pack.CustomerDetailsHash, err = putShippingToAddress(stub, caseId, pack.ShippingToAddress, _updatedOn, _updatedBy)
+		if err != nil { return false, err }
+		pack.ShippingToAddress = ""
+		pack.PackageLastUpdatedOn = _updatedOn
+		pack.PackageLastUpdatedBy = _updatedBy
+
+		err = savePackageLine(stub, pack)
+		if err != nil { return false, err }
+		_changed = true
+	}
+
+	return _changed, nil
+}
+
+//Move clear text shipping addresses of existing Packages into the private data collection, see privatisePackageAddress
+//Parameters = USERNAME; transient customerDetailsSalt
+func (t *TnT) privatisePackageAddresses(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[0]
+
+	bytesPackageCaseHolder, err := stub.GetState("Packages")
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Packages") }
+
+	var packageCaseID_Holder PackageCaseID_Holder
+
+	err = json.Unmarshal(bytesPackageCaseHolder, &packageCaseID_Holder)
+	if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Packages") }
+
+	_time:= time.Now().UTC()
+	_packageLastUpdatedOn := _time.Format(DATE_FORMAT)
+
+	for _, caseId := range packageCaseID_Holder.PackageCaseIDs {
+		_, err = privatisePackageAddress(stub, caseId, _packageLastUpdatedOn, user_name)
+		if err != nil { return nil, err }
+	}
+
+	return nil, nil
+}
+
+/* Cancellation section */
+
+//Reason for cancelling an Assembly or a Package - stored against cancellationKey
+type Cancellation struct {
+	Id 				string `json:"id"`
+	ReasonCode 		string `json:"reasonCode"`
+	Comment 		string `json:"comment"`
+	StatusBefore 	string `json:"statusBefore"`
+	CancelledOn 	string `json:"cancelledOn"`
+	CancelledBy 	string `json:"cancelledBy"`
+	SchemaVersion 	Schema_Version `json:"schemaVersion"`
+}
+
+func isValidCancelReason(_reasonCode string) bool {
+	switch _reasonCode {
+	case CANCEL_DUPLICATE, CANCEL_DATA_ERROR, CANCEL_DAMAGED, CANCEL_ORDER_WITHDRAWN, CANCEL_OTHER:
+		return true
+	}
+	return false
+}
+
+//Optional trailing INCLUDECANCELLED argument of list and search queries - Cancelled records are left out unless "true"
+func includeCancelledArg(args []string, _argCount int) (bool, error) {
+	if len(args) == _argCount { return false, nil }
+
+	_includeCancelled, err := strconv.ParseBool(args[_argCount])
+	if err != nil { return false, tntError(ERR_INVALID_ARGUMENT, "includeCancelled", "IncludeCancelled must be true or false") }
+
+	return _includeCancelled, nil
+}
+
+func cancellationKey(_id string) string {
+	return "CANCEL|" + _id // Indicates cancellation key, AssemblyId or CaseId
+}
+
+func putCancellation(stub Stub, cancellation Cancellation) error {
+	bytesCancellation, err := json.Marshal(cancellation)
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Error creating Cancellation record") }
+
+	err = stub.PutState(cancellationKey(cancellation.Id), bytesCancellation)
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+
+	return nil
+}
+
+//Write a new AssemblyLine version
+func saveAssemblyLine(stub Stub, assem AssemblyLine) error {
+	assem.AssemblyHash = computeAssemblyHash(assem)
+	bytes, err := json.Marshal(assem)
+	if err != nil { fmt.Printf("SAVE_CHANGES: Error converting Assembly record: %s", err); return tntError(ERR_CORRUPT_STATE, "", "Error converting Assembly record") }
+
+	err = stub.PutState(assem.AssemblyId, bytes)
+	if err != nil { fmt.Printf("SAVE_CHANGES: Error storing Assembly record: %s", err); return tntError(ERR_CORRUPT_STATE, "", "Error storing Assembly record") }
+
+	/* AssemblyLine history ------------------------------------------Starts */
+	assemLine_HolderKey := historyKey(assem.AssemblyId) // Indicates History Key for Assembly with ID = _assemblyId
+	bytesAssemblyLines, err := getHistoryState(stub, assem.AssemblyId, &AssemblyLine_Holder{})
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+	var assemLine_Holder AssemblyLine_Holder
+
+	err = json.Unmarshal(bytesAssemblyLines, &assemLine_Holder)
+	if err != nil {	return tntError(ERR_CORRUPT_STATE, "", "Corrupt AssemblyLines record") }
+
+	assemLine_Holder.AssemblyLines = append(assemLine_Holder.AssemblyLines, assem) //appending the updated AssemblyLine
+
+	bytesAssemblyLines, err = json.Marshal(assemLine_Holder)
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Error creating AssemblyLine_Holder record") }
+
+	err = stub.PutState(assemLine_HolderKey, bytesAssemblyLines)
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+	/* AssemblyLine history ------------------------------------------Ends */
+
+	return nil
+}
+
+//API to cancel an Assembly - the record is kept with status 'Cancelled'
+//Packaged Assemblies are freed by cancelling their Package first
+//"args": ["ASM0101","DUPLICATE","Scanned twice","aluser1"]
+func (t *TnT) cancelAssembly(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[3]
+
+		_assemblyId := args[0]
+		_reasonCode := args[1]
+		_comment := args[2]
+
+		if !isValidCancelReason(_reasonCode) { return nil, tntError(ERR_INVALID_ARGUMENT, "reasonCode", "Invalid cancellation reason code " + _reasonCode) }
+
+		_time:= time.Now().UTC()
+		_assemblyLastUpdatedOn := _time.Format(DATE_FORMAT)
+
+		//get the Assembly
+		assemblyAsBytes, err := stub.GetState(_assemblyId)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id")	}
+		if assemblyAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+		assem := AssemblyLine{}
+		json.Unmarshal(assemblyAsBytes, &assem)
+
+		if assem.AssemblyStatus == ASSEMBLYSTATUS_CAN { return nil, tntError(ERR_INVALID_TRANSITION, "", "Assembly already cancelled") }
+		if assem.AssemblyStatus == ASSEMBLYSTATUS_SCR { return nil, tntError(ERR_INVALID_TRANSITION, "", "Assembly is scrapped") }
+		if assem.AssemblyStatus == ASSEMBLYSTATUS_PKG || len(assem.AssemblyPackage) > 0 {
+			return nil, tntError(ERR_INVALID_TRANSITION, "", "Assembly is in Package " + assem.AssemblyPackage + ", cancel the Package first")
+		}
+
+		cancellation := Cancellation{}
+		cancellation.SchemaVersion = SCHEMA_VERSION
+		cancellation.Id = _assemblyId
+		cancellation.ReasonCode = _reasonCode
+		cancellation.Comment = _comment
+		cancellation.StatusBefore = assem.AssemblyStatus
+		cancellation.CancelledOn = _assemblyLastUpdatedOn
+		cancellation.CancelledBy = user_name
+
+		err = putCancellation(stub, cancellation)
+		if err != nil { return nil, err }
+
+		assem.AssemblyStatus = ASSEMBLYSTATUS_CAN
+		assem.AssemblyLastUpdatedOn = _assemblyLastUpdatedOn
+		assem.AssemblyLastUpdatedBy = user_name
+
+		err = saveAssemblyLine(stub, assem)
+		if err != nil { return nil, err }
+
+		return nil, nil
+}
+
+//API to cancel a Package - the record is kept with status 'Cancelled' and its Assemblies go back to 'Ready For Packaging'
+//"args": ["CAS0001","ORDER_WITHDRAWN","Customer order withdrawn","pluser1"]
+func (t *TnT) cancelPackage(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[3]
+
+		_caseId := args[0]
+		_reasonCode := args[1]
+		_comment := args[2]
+
+		if !isValidCancelReason(_reasonCode) { return nil, tntError(ERR_INVALID_ARGUMENT, "reasonCode", "Invalid cancellation reason code " + _reasonCode) }
+
+		_time:= time.Now().UTC()
+		_packageLastUpdatedOn := _time.Format(DATE_FORMAT)
+
+		//get the Package
+		packageAsBytes, err := stub.GetState(_caseId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Package") }
+		if packageAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "caseId", "Package doesn't exists") }
+
+		pack := PackageLine{}
+		json.Unmarshal(packageAsBytes, &pack)
+
+		if pack.PackageStatus == PACKAGESTATUS_CAN { return nil, tntError(ERR_INVALID_TRANSITION, "", "Package already cancelled") }
+		if len(pack.PackageRMAId) > 0 { return nil, tntError(ERR_INVALID_TRANSITION, "", "Package is returned under RMA " + pack.PackageRMAId) }
+
+		caseShipment_Holder, err := getCaseShipments(stub, _caseId)
+		if err != nil { return nil, err }
+		if len(caseShipment_Holder.ShipmentIds) > 0 { return nil, tntError(ERR_INVALID_TRANSITION, "", "Package already shipped") }
+
+		cancellation := Cancellation{}
+		cancellation.SchemaVersion = SCHEMA_VERSION
+		cancellation.Id = _caseId
+		cancellation.ReasonCode = _reasonCode
+		cancellation.Comment = _comment
+		cancellation.StatusBefore = pack.PackageStatus
+		cancellation.CancelledOn = _packageLastUpdatedOn
+		cancellation.CancelledBy = user_name
+
+		err = putCancellation(stub, cancellation)
+		if err != nil { return nil, err }
+
+		//Free the packed Assemblies
+		for _, _assemblyId := range []string{pack.HolderAssemblyId, pack.ChargerAssemblyId} {
+			if len(_assemblyId) == 0 { continue }
+
+			assemblyAsBytes, err := stub.GetState(_assemblyId)
+			if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id")	}
+			if assemblyAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+			assem := AssemblyLine{}
+			json.Unmarshal(assemblyAsBytes, &assem)
+
+			if assem.AssemblyPackage != _caseId { continue } // Already released from the case
+
+			assem.AssemblyStatus = ASSEMBLYSTATUS_RFP
+			assem.AssemblyPackage = ""
+			assem.AssemblyLastUpdatedOn = _packageLastUpdatedOn
+			assem.AssemblyLastUpdatedBy = user_name
+
+			err = saveAssemblyLine(stub, assem)
+			if err != nil { return nil, err }
+		}
+
+		pack.PackageStatus = PACKAGESTATUS_CAN
+		pack.PackageLastUpdatedOn = _packageLastUpdatedOn
+		pack.PackageLastUpdatedBy = user_name
+
+		err = savePackageLine(stub, pack)
+		if err != nil { return nil, err }
+
+		return nil, nil
+}
+
+//get the Cancellation of an Assembly or a Package
+//Parameters = ASM0101 or CAS0001, USERNAME
+func (t *TnT) getCancellationByID(stub Stub, args []string) ([]byte, error) {
+
+	_id := args[0]
+
+	bytesCancellation, err := getMovedState(stub, cancellationKey(_id), _id + "C", &Cancellation{}, _id)
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Cancellation") }
+	if bytesCancellation == nil { return nil, tntError(ERR_NOT_FOUND, "id", "No Cancellation for " + _id) }
+
+	return bytesCancellation, nil
+}
+
+/* Scrap section */
+
+// Destruction of an Assembly or of loose components of a batch - an Assembly's is stored against scrapKey,
+// a batch's in its Component_Batch
+type Scrap_Record struct {
+	ItemType 		string `json:"itemType"` // ASSEMBLY or BATCH
+	Id 				string `json:"id"` // AssemblyId or BatchNo
+	BatchType 		string `json:"batchType"`
+	Quantity 		int `json:"quantity"`
+	Method 			string `json:"method"`
+	Witness 		string `json:"witness"`
+	ScrapDate 		string `json:"scrapDate"`
+	Comment 		string `json:"comment"`
+	StatusBefore 	string `json:"statusBefore"`
+	RecordedOn 		string `json:"recordedOn"`
+	RecordedBy 		string `json:"recordedBy"`
+	SchemaVersion 		Schema_Version `json:"schemaVersion"`
+}
+
+func scrapKey(_assemblyId string) string {
+	return "SCRAP|" + _assemblyId // Indicates destruction key
+}
+
+// Goods receipt of a component batch
+type Batch_Receipt struct {
+	Quantity 		int `json:"quantity"`
+	ReceivedDate 	string `json:"receivedDate"`
+	RecordedOn 		string `json:"recordedOn"`
+	RecordedBy 		string `json:"recordedBy"`
+}
+
+//Component batch receipts and scraps - stored against "BATCH|" + BatchType + "|" + BatchNo
+type Component_Batch struct {
+	BatchType 	string `json:"batchType"`
+	BatchNo 	string `json:"batchNo"`
+	Receipts 	[]Batch_Receipt `json:"receipts"`
+	Scraps 		[]Scrap_Record `json:"scraps"`
+	SchemaVersion 	Schema_Version `json:"schemaVersion"`
+}
+
+// Quantity reconciliation of a component batch
+type Batch_Reconciliation struct {
+	BatchType 				string `json:"batchType"`
+	BatchNo 				string `json:"batchNo"`
+	Received 				int `json:"received"`
+	Consumed 				int `json:"consumed"` // Assemblies built with the batch, reworked ones included
+	Scrapped 				int `json:"scrapped"` // Loose components scrapped
+	Remaining 				int `json:"remaining"`
+	ScrappedInAssemblies 	int `json:"scrappedInAssemblies"` // Part of Consumed
+	Balanced 				bool `json:"balanced"` // false when more was used than received
+	Scraps 					[]Scrap_Record `json:"scraps"`
+}
+
+func componentBatchKey(_batchType string, _batchNo string) string {
+	return "BATCH|" + _batchType + "|" + _batchNo
+}
+
+//get the Component_Batch record, empty if nothing was recorded yet
+func getComponentBatch(stub Stub, _batchType string, _batchNo string) (Component_Batch, error) {
+	var batch Component_Batch
+	batch.BatchType = _batchType
+	batch.BatchNo = _batchNo
+
+	bytesBatch, err := stub.GetState(componentBatchKey(_batchType, _batchNo))
+	if err != nil { return batch, tntError(ERR_CORRUPT_STATE, "", "Unable to get Component batch") }
+	if bytesBatch == nil { batch.SchemaVersion = SCHEMA_VERSION; return batch, nil }
+
+	err = json.Unmarshal(bytesBatch, &batch)
+	if err != nil {	return batch, tntError(ERR_CORRUPT_STATE, "", "Corrupt Component batch record") }
+
+	return batch, nil
+}
+
+func putComponentBatch(stub Stub, batch Component_Batch) error {
+	bytesBatch, err := json.Marshal(batch)
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Error creating Component batch record") }
+
+	err = stub.PutState(componentBatchKey(batch.BatchType, batch.BatchNo), bytesBatch)
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+
+	return nil
+}
+
+//Quantity reconciliation of a component batch
+//Cancelled Assemblies are not counted as consumed
+func (t *TnT) computeBatchReconciliation(stub Stub, _batchType string, _batchNo string) (Batch_Reconciliation, error) {
+	var res Batch_Reconciliation
+	res.BatchType = _batchType
+	res.BatchNo = _batchNo
+
+	batch, err := getComponentBatch(stub, _batchType, _batchNo)
+	if err != nil { return res, err }
+
+	for _, receipt := range batch.Receipts { res.Received += receipt.Quantity }
+	for _, scrap := range batch.Scraps { res.Scrapped += scrap.Quantity }
+	res.Scraps = batch.Scraps
+
+	bytes, err := stub.GetState("Assemblies")
+	if err != nil { return res, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+	var assemID_Holder AssemblyID_Holder
+
+	err = json.Unmarshal(bytes, &assemID_Holder)
+	if err != nil {	return res, tntError(ERR_CORRUPT_STATE, "", "Corrupt Assemblies") }
+
+	for _, assemblyId := range assemID_Holder.AssemblyIDs {
+
+		assemblyAsBytes, err := stub.GetState(assemblyId)
+		if err != nil { return res, tntError(ERR_CORRUPT_STATE, "", "Failed to get Assembly")}
+		if assemblyAsBytes == nil { continue }
+
+		assem := AssemblyLine{}
+		json.Unmarshal(assemblyAsBytes, &assem)
+		if assem.AssemblyStatus == ASSEMBLYSTATUS_CAN { continue }
+
+		if *assemblyBatchField(&assem, _batchType) == _batchNo {
+			res.Consumed++
+			if assem.AssemblyStatus == ASSEMBLYSTATUS_SCR { res.ScrappedInAssemblies++ }
+			continue
+		}
+
+		// Components swapped out on rework were consumed as well
+		_replaced, err := wasBatchReplaced(stub, assemblyId, _batchType, _batchNo)
+		if err != nil { return res, err }
+		if _replaced { res.Consumed++ }
+	}
+
+	res.Remaining = res.Received - res.Consumed - res.Scrapped
+	res.Balanced = res.Remaining >= 0
+
+	return res, nil
+}
+
+//API to record a goods receipt of a component batch
+//"args": ["FilamentBatchId","FIL0002","500","20170608101500","aluser1"]
+func (t *TnT) receiveComponentBatch(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[4]
+
+		_batchType := args[0]
+		_batchNo := args[1]
+
+		if assemblyBatchField(&AssemblyLine{}, _batchType) == nil { return nil, tntError(ERR_INVALID_ARGUMENT, "batchType", "Invalid batch type " + _batchType) }
+		if len(_batchNo) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "batchNo", "BatchNo supplied as empty") }
+
+		_quantity, err := strconv.Atoi(args[2])
+		if err != nil || _quantity <= 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "quantity", "Quantity must be a positive number") }
+
+		//Check Date
+		_receivedDate, err := canonicalDate(args[3], "receivedDate")
+		if err != nil { return nil, err }
+
+		_time:= time.Now().UTC()
+
+		batch, err := getComponentBatch(stub, _batchType, _batchNo)
+		if err != nil { return nil, err }
+
+		receipt := Batch_Receipt{}
+		receipt.Quantity = _quantity
+		receipt.ReceivedDate = _receivedDate
+		receipt.RecordedOn = _time.Format(DATE_FORMAT)
+		receipt.RecordedBy = user_name
+
+		batch.Receipts = append(batch.Receipts, receipt)
+
+		err = putComponentBatch(stub, batch)
+		if err != nil { return nil, err }
+
+		return nil, nil
+}
+
+//API to record the destruction of an Assembly
+//"args": ["ASM0101","SHREDDED","qauser2","20170612101500","Failed rework","aluser1"]
+func (t *TnT) scrapAssembly(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[5]
+
+		_assemblyId := args[0]
+		_method := args[1]
+		_witness := args[2]
+		_comment := args[4]
+
+		if len(_method) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "method", "Scrap method supplied as empty") }
+		if len(_witness) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "witness", "Witness supplied as empty") }
+		if _witness == user_name { return nil, tntError(ERR_INVALID_ARGUMENT, "witness", "Witness must be another user") }
+		//Check Date
+		_scrapDate, err := canonicalDate(args[3], "scrapDate")
+		if err != nil { return nil, err }
+
+		_time:= time.Now().UTC()
+		_assemblyLastUpdatedOn := _time.Format(DATE_FORMAT)
+
+		//get the Assembly
+		assemblyAsBytes, err := stub.GetState(_assemblyId)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id")	}
+		if assemblyAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+		assem := AssemblyLine{}
+		json.Unmarshal(assemblyAsBytes, &assem)
+
+		if assem.AssemblyStatus == ASSEMBLYSTATUS_SCR { return nil, tntError(ERR_INVALID_TRANSITION, "", "Assembly already scrapped") }
+		if assem.AssemblyStatus == ASSEMBLYSTATUS_CAN { return nil, tntError(ERR_INVALID_TRANSITION, "", "Assembly is cancelled") }
+		if assem.AssemblyStatus == ASSEMBLYSTATUS_PKG || len(assem.AssemblyPackage) > 0 {
+			return nil, tntError(ERR_INVALID_TRANSITION, "", "Assembly is in Package " + assem.AssemblyPackage + ", release it from the Package first")
+		}
+
+		scrap := Scrap_Record{}
+		scrap.SchemaVersion = SCHEMA_VERSION
+		scrap.ItemType = SCRAP_ASSEMBLY
+		scrap.Id = _assemblyId
+		scrap.Quantity = 1
+		scrap.Method = _method
+		scrap.Witness = _witness
+		scrap.ScrapDate = _scrapDate
+		scrap.Comment = _comment
+		scrap.StatusBefore = assem.AssemblyStatus
+		scrap.RecordedOn = _assemblyLastUpdatedOn
+		scrap.RecordedBy = user_name
+
+		bytesScrap, err := json.Marshal(scrap)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating Scrap record") }
+
+		err = stub.PutState(scrapKey(_assemblyId), bytesScrap)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+
+		assem.AssemblyStatus = ASSEMBLYSTATUS_SCR
+		assem.AssemblyLastUpdatedOn = _assemblyLastUpdatedOn
+		assem.AssemblyLastUpdatedBy = user_name
+
+		err = saveAssemblyLine(stub, assem)
+		if err != nil { return nil, err }
+
+		return nil, nil
+}
+
+//API to record the destruction of loose components of a batch
+//"args": ["LedBatchId","LED0002","25","INCINERATED","qauser2","20170612101500","Moisture damage","aluser1"]
+func (t *TnT) scrapComponentBatch(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[7]
+
+		_batchType := args[0]
+		_batchNo := args[1]
+		_method := args[3]
+		_witness := args[4]
+		_comment := args[6]
+
+		if assemblyBatchField(&AssemblyLine{}, _batchType) == nil { return nil, tntError(ERR_INVALID_ARGUMENT, "batchType", "Invalid batch type " + _batchType) }
+
+		_quantity, err := strconv.Atoi(args[2])
+		if err != nil || _quantity <= 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "quantity", "Quantity must be a positive number") }
+
+		if len(_method) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "method", "Scrap method supplied as empty") }
+		if len(_witness) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "witness", "Witness supplied as empty") }
+		if _witness == user_name { return nil, tntError(ERR_INVALID_ARGUMENT, "witness", "Witness must be another user") }
+		//Check Date
+		_scrapDate, err := canonicalDate(args[5], "scrapDate")
+		if err != nil { return nil, err }
+
+		//Can't scrap more than what is left of the batch
+		reconciliation, err := t.computeBatchReconciliation(stub, _batchType, _batchNo)
+		if err != nil { return nil, err }
+		if _quantity > reconciliation.Remaining {
+			return nil, tntError(ERR_INVALID_ARGUMENT, "quantity", fmt.Sprintf("Only %d components of batch %s remaining", reconciliation.Remaining, _batchNo))
+		}
+
+		_time:= time.Now().UTC()
+
+		scrap := Scrap_Record{}
+		scrap.SchemaVersion = SCHEMA_VERSION
+		scrap.ItemType = SCRAP_BATCH
+		scrap.Id = _batchNo
+		scrap.BatchType = _batchType
+		scrap.Quantity = _quantity
+		scrap.Method = _method
+		scrap.Witness = _witness
+		scrap.ScrapDate = _scrapDate
+		scrap.Comment = _comment
+		scrap.RecordedOn = _time.Format(DATE_FORMAT)
+		scrap.RecordedBy = user_name
+
+		batch, err := getComponentBatch(stub, _batchType, _batchNo)
+		if err != nil { return nil, err }
+
+		batch.Scraps = append(batch.Scraps, scrap)
+
+		err = putComponentBatch(stub, batch)
+		if err != nil { return nil, err }
+
+		return nil, nil
+}
+
+//get the Scrap record of an Assembly
+func (t *TnT) getScrapByAssemblyID(stub Stub, args []string) ([]byte, error) {
+
+	_assemblyId := args[0]
+
+	bytesScrap, err := getMovedState(stub, scrapKey(_assemblyId), _assemblyId + "D", &Scrap_Record{}, _assemblyId)
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Scrap record") }
+	if bytesScrap == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly " + _assemblyId + " not scrapped") }
+
+	return bytesScrap, nil
+}
+
+//Reconciliation of a component batch: received vs consumed in assemblies vs scrapped vs remaining
+//Parameters = BATCHTYPE, BATCHNO, USERNAME
+func (t *TnT) getBatchReconciliation(stub Stub, args []string) ([]byte, error) {
+
+	_batchType := args[0]
+	_batchNo := args[1]
+
+	if assemblyBatchField(&AssemblyLine{}, _batchType) == nil { return nil, tntError(ERR_INVALID_ARGUMENT, "batchType", "Invalid batch type " + _batchType) }
+
+	res, err := t.computeBatchReconciliation(stub, _batchType, _batchNo)
+	if err != nil { return nil, err }
+
+	mapB, _ := json.Marshal(res)
+	return mapB, nil
+}
+
+/* Production statistics section */
+
+// Assembly count for one group - only the grouped fields are filled
+type Assembly_Count struct {
+	ManufacturingPlant 	string `json:"manufacturingPlant,omitempty"`
+	DeviceType 			string `json:"deviceType,omitempty"`
+	AssemblyStatus 		string `json:"assemblyStatus,omitempty"`
+	Period 				string `json:"period,omitempty"` // YYYYMMDD or YYYY-Www
+	Count 				int `json:"count"`
+}
+
+// Package count for one group - only the grouped fields are filled
+type Package_Count struct {
+	PackageStatus 	string `json:"packageStatus,omitempty"`
+	Period 			string `json:"period,omitempty"` // YYYYMMDD or YYYY-Www
+	Count 			int `json:"count"`
+}
+
+//Split a comma separated GROUPBY argument, checking every dimension is allowed
+func parseGroupBy(_groupBy string, allowed []string) ([]string, error) {
+	var dims []string
+	if len(_groupBy) == 0 { return dims, nil }
+
+	for _, dim := range strings.Split(_groupBy, ",") {
+		dim = strings.TrimSpace(dim)
+		_allowed := false
+		for _, a := range allowed {
+			if dim == a { _allowed = true }
+		}
+		if !_allowed { return nil, tntError(ERR_INVALID_ARGUMENT, "groupBy", "Invalid group by " + dim + ", expecting one of " + strings.Join(allowed, ",")) }
+		dims = append(dims, dim)
+	}
+	return dims, nil
+}
+
+//FROMDATE / TODATE of statistics queries - empty leaves the range open
+func parseDateBound(_date string, _field string, _open int64) (int64, error) {
+	if len(_date) == 0 { return _open, nil }
+	return parseDateArg(_date, _field)
+}
+
+//Check a YYYYMMDDHHMMSS date is within the range; invalid dates are only in an open range
+func dateInRange(_date string, _fromDate int64, _toDate int64) bool {
+	_dateInt64, err := strconv.ParseInt(_date, 10, 64)
+	if len(_date) != 14 || err != nil { return _fromDate == 0 && _toDate == math.MaxInt64 }
+	return _dateInt64 >= _fromDate && _dateInt64 <= _toDate
+}
+
+//Day (YYYYMMDD) or ISO week (YYYY-Www) of a YYYYMMDDHHMMSS date
+func datePeriod(_date string, _period string) string {
+	if len(_date) != 14 { return "" }
+	if _period == STATS_DAY { return _date[:8] }
+
+	_time, err := time.Parse(DATE_FORMAT, _date)
+	if err != nil { return "" }
+	_year, _week := _time.ISOWeek()
+	return fmt.Sprintf("%04d-W%02d", _year, _week)
+}
+
+//Assembly counts grouped by any of plant, deviceType, status and day or week (of AssemblyDate)
+//Computed in one pass over the current records - no shared counter keys, so invokes don't conflict on them
+//Parameters = GROUPBY (e.g. "plant,deviceType,day"), FROMDATE, TODATE (YYYYMMDDHHMMSS, empty for open), USERNAME, [INCLUDECANCELLED]
+func (t *TnT) getAssemblyCounts(stub Stub, args []string) ([]byte, error) {
+
+	_includeCancelled, err := includeCancelledArg(args, 4)
+	if err != nil { return nil, err }
+
+	dims, err := parseGroupBy(args[0], []string{STATS_PLANT, STATS_DEVICE_TYPE, STATS_STATUS, STATS_DAY, STATS_WEEK})
+	if err != nil { return nil, err }
+
+	_fromDate, err := parseDateBound(args[1], "fromDate", 0)
+	if err != nil { return nil, err }
+
+	_toDate, err := parseDateBound(args[2], "toDate", math.MaxInt64)
+	if err != nil { return nil, err }
+
+	assemblies, err := selectAssemblies(stub, dateSelector("assemblyDate", _fromDate, _toDate), "assemblyDate", nil)
+	if err != nil { return nil, err }
+
+	counts := make(map[string]*Assembly_Count)
+	var keys []string
+
+	for _, assem := range assemblies {
+
+		if !_includeCancelled && assem.AssemblyStatus == ASSEMBLYSTATUS_CAN { continue }
+		if !dateInRange(assem.AssemblyDate, _fromDate, _toDate) { continue }
+
+		group := Assembly_Count{}
+		for _, dim := range dims {
+			switch dim {
+			case STATS_PLANT: group.ManufacturingPlant = assem.ManufacturingPlant
+			case STATS_DEVICE_TYPE: group.DeviceType = assem.DeviceType
+			case STATS_STATUS: group.AssemblyStatus = assem.AssemblyStatus
+			case STATS_DAY, STATS_WEEK: group.Period = datePeriod(assem.AssemblyDate, dim)
+			}
+		}
+
+		_key := group.ManufacturingPlant + "|" + group.DeviceType + "|" + group.AssemblyStatus + "|" + group.Period
+		if _, ok := counts[_key]; !ok {
+			counts[_key] = &group
+			keys = append(keys, _key)
+		}
+		counts[_key].Count++
+	}
+
+	// Sorted for a deterministic response across endorsers
+	sort.Strings(keys)
+	res2E:= []*Assembly_Count{}
+	for _, _key := range keys { res2E = append(res2E, counts[_key]) }
+
+	mapB, _ := json.Marshal(res2E)
+	return mapB, nil
+}
+
+//Package counts grouped by any of status and day or week (of PackagingDate)
+//Parameters = GROUPBY (e.g. "status,week"), FROMDATE, TODATE (YYYYMMDDHHMMSS, empty for open), USERNAME, [INCLUDECANCELLED]
+func (t *TnT) getPackageCounts(stub Stub, args []string) ([]byte, error) {
+
+	_includeCancelled, err := includeCancelledArg(args, 4)
+	if err != nil { return nil, err }
+
+	dims, err := parseGroupBy(args[0], []string{STATS_STATUS, STATS_DAY, STATS_WEEK})
+	if err != nil { return nil, err }
+
+	_fromDate, err := parseDateBound(args[1], "fromDate", 0)
+	if err != nil { return nil, err }
+
+	_toDate, err := parseDateBound(args[2], "toDate", math.MaxInt64)
+	if err != nil { return nil, err }
+
+	packages, err := selectPackages(stub, dateSelector("packagingDate", _fromDate, _toDate), "packagingDate")
+	if err != nil { return nil, err }
+
+	counts := make(map[string]*Package_Count)
+	var keys []string
+
+	for _, pack := range packages {
+
+		if !_includeCancelled && pack.PackageStatus == PACKAGESTATUS_CAN { continue }
+		if !dateInRange(pack.PackagingDate, _fromDate, _toDate) { continue }
+
+		group := Package_Count{}
+		for _, dim := range dims {
+			switch dim {
+			case STATS_STATUS: group.PackageStatus = pack.PackageStatus
+			case STATS_DAY, STATS_WEEK: group.Period = datePeriod(pack.PackagingDate, dim)
+			}
+		}
+
+		_key := group.PackageStatus + "|" + group.Period
+		if _, ok := counts[_key]; !ok {
+			counts[_key] = &group
+			keys = append(keys, _key)
+		}
+		counts[_key].Count++
+	}
+
+	// Sorted for a deterministic response across endorsers
+	sort.Strings(keys)
+	res2E:= []*Package_Count{}
+	for _, _key := range keys { res2E = append(res2E, counts[_key]) }
+
+	mapB, _ := json.Marshal(res2E)
+	return mapB, nil
+}
+
+/* Cycle time section */
+
+// Cycle time statistics of one segment for one group - durations in seconds
+type Cycle_Time_Stats struct {
+	ManufacturingPlant 	string `json:"manufacturingPlant,omitempty"`
+	DeviceType 			string `json:"deviceType,omitempty"`
+	Segment 			string `json:"segment"`
+	Count 				int `json:"count"`
+	MinSeconds 			int64 `json:"minSeconds"`
+	MeanSeconds 		int64 `json:"meanSeconds"`
+	P50Seconds 			int64 `json:"p50Seconds"`
+	P90Seconds 			int64 `json:"p90Seconds"`
+	P95Seconds 			int64 `json:"p95Seconds"`
+	MaxSeconds 			int64 `json:"maxSeconds"`
+	durations 			[]int64
+}
+
+// Assembly sitting in its current status longer than the threshold
+type Stuck_Assembly struct {
+	AssemblyId 			string `json:"assemblyId"`
+	DeviceSerialNo 		string `json:"deviceSerialNo"`
+	DeviceType 			string `json:"deviceType"`
+	ManufacturingPlant 	string `json:"manufacturingPlant"`
+	AssemblyStatus 		string `json:"assemblyStatus"`
+	AssemblyPackage 	string `json:"assemblyPackage"`
+	InStatusSince 		string `json:"inStatusSince"`
+	HoursInStatus 		int64 `json:"hoursInStatus"`
+}
+
+type int64Slice []int64
+
+func (s int64Slice) Len() int           { return len(s) }
+func (s int64Slice) Less(i, j int) bool { return s[i] < s[j] }
+func (s int64Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+//YYYYMMDDHHMMSS timestamps written by the chaincode are UTC
+func parseLedgerTime(_date string) (time.Time, bool) {
+	if len(_date) != 14 { return time.Time{}, false }
+	_time, err := time.Parse(DATE_FORMAT, _date)
+	if err != nil { return time.Time{}, false }
+	return _time, true
+}
+
+//Nearest rank percentile of sorted durations
+func percentile(sorted []int64, _p int) int64 {
+	_rank := (_p * len(sorted) + 99) / 100
+	if _rank < 1 { _rank = 1 }
+	return sorted[_rank-1]
+}
+
+//Time the Package of an Assembly was first handed over for shipping
+func firstHandOver(stub Stub, _caseId string) (time.Time, bool, error) {
+	var _first time.Time
+	_found := false
+
+	caseShipment_Holder, err := getCaseShipments(stub, _caseId)
+	if err != nil { return _first, false, err }
+
+	for _, _shipmentId := range caseShipment_Holder.ShipmentIds {
+		shipment, err := getShipment(stub, _shipmentId)
+		if err != nil { return _first, false, err }
+
+		for _, transfer := range shipment.CustodyTransfers {
+			_handedOverOn, ok := parseLedgerTime(transfer.HandedOverOn)
+			if ok && (!_found || _handedOverOn.Before(_first)) {
+				_first = _handedOverOn
+				_found = true
+			}
+		}
+	}
+	return _first, _found, nil
+}
+
+//Cycle times between statuses from the AssemblyLine history, with percentiles per group
+//Segments: ASSEMBLY_TO_RFP (AssemblyDate to first 'Ready For Packaging'), RFP_TO_PACKAGED, PACKAGED_TO_SHIPPED (first carrier handover)
+//Parameters = GROUPBY (any of "plant,deviceType", empty for overall), FROMDATE, TODATE (AssemblyDate YYYYMMDDHHMMSS, empty for open), USERNAME, [INCLUDECANCELLED]
+func (t *TnT) getCycleTimes(stub Stub, args []string) ([]byte, error) {
+
+	_includeCancelled, err := includeCancelledArg(args, 4)
+	if err != nil { return nil, err }
+
+	dims, err := parseGroupBy(args[0], []string{STATS_PLANT, STATS_DEVICE_TYPE})
+	if err != nil { return nil, err }
+
+	_fromDate, err := parseDateBound(args[1], "fromDate", 0)
+	if err != nil { return nil, err }
+
+	_toDate, err := parseDateBound(args[2], "toDate", math.MaxInt64)
+	if err != nil { return nil, err }
+
+	bytes, err := stub.GetState("Assemblies")
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+	var assemID_Holder AssemblyID_Holder
+
+	err = json.Unmarshal(bytes, &assemID_Holder)
+	if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Assemblies") }
+
+	stats := make(map[string]*Cycle_Time_Stats)
+	var keys []string
+
+	addDuration := func(group Cycle_Time_Stats, _segment string, _from time.Time, _to time.Time) {
+		if _to.Before(_from) { return }
+		_key := group.ManufacturingPlant + "|" + group.DeviceType + "|" + _segment
+		if _, ok := stats[_key]; !ok {
+			group.Segment = _segment
+			stats[_key] = &group
+			keys = append(keys, _key)
+		}
+		stats[_key].durations = append(stats[_key].durations, int64(_to.Sub(_from).Seconds()))
+	}
+
+	for _, assemblyId := range assemID_Holder.AssemblyIDs {
+
+		bytesAssemblyLines, err := getHistoryState(stub, assemblyId, &AssemblyLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get bytesAssemblyLinesHistoryByID") }
+		if bytesAssemblyLines == nil { continue }
+
+		var assemLine_Holder AssemblyLine_Holder
+
+		err = json.Unmarshal(bytesAssemblyLines, &assemLine_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt assemLineHistory_Holder record") }
+		if len(assemLine_Holder.AssemblyLines) == 0 { continue }
+
+		first := assemLine_Holder.AssemblyLines[0]
+		latest := assemLine_Holder.AssemblyLines[len(assemLine_Holder.AssemblyLines)-1]
+
+		if !_includeCancelled && latest.AssemblyStatus == ASSEMBLYSTATUS_CAN { continue }
+		if !dateInRange(latest.AssemblyDate, _fromDate, _toDate) { continue }
+
+		group := Cycle_Time_Stats{}
+		for _, dim := range dims {
+			switch dim {
+			case STATS_PLANT: group.ManufacturingPlant = latest.ManufacturingPlant
+			case STATS_DEVICE_TYPE: group.DeviceType = latest.DeviceType
+			}
+		}
+
+		_assembledOn, _assembled := parseLedgerTime(first.AssemblyDate)
+		if !_assembled { _assembledOn, _assembled = parseLedgerTime(first.AssemblyCreationDate) }
+
+		//First time the Assembly reached each status
+		var _rfpOn, _packagedOn time.Time
+		_rfp, _packaged := false, false
+		_caseId := ""
+		for _, version := range assemLine_Holder.AssemblyLines {
+			_updatedOn, ok := parseLedgerTime(version.AssemblyLastUpdatedOn)
+			if !ok { continue }
+			if !_rfp && version.AssemblyStatus == ASSEMBLYSTATUS_RFP {
+				_rfpOn, _rfp = _updatedOn, true
+			}
+			if _rfp && !_packaged && version.AssemblyStatus == ASSEMBLYSTATUS_PKG {
+				_packagedOn, _packaged = _updatedOn, true
+				_caseId = version.AssemblyPackage
+			}
+		}
+
+		if _assembled && _rfp { addDuration(group, CYCLE_ASSEMBLY_TO_RFP, _assembledOn, _rfpOn) }
+		if _rfp && _packaged { addDuration(group, CYCLE_RFP_TO_PACKAGED, _rfpOn, _packagedOn) }
+		if _packaged && len(_caseId) > 0 {
+			_shippedOn, _shipped, err := firstHandOver(stub, _caseId)
+			if err != nil { return nil, err }
+			if _shipped { addDuration(group, CYCLE_PACKAGED_TO_SHIPPED, _packagedOn, _shippedOn) }
+		}
+	}
+
+	// Sorted for a deterministic response across endorsers
+	sort.Strings(keys)
+	res2E:= []*Cycle_Time_Stats{}
+	for _, _key := range keys {
+		res := stats[_key]
+		sort.Sort(int64Slice(res.durations))
+
+		var _total int64
+		for _, _duration := range res.durations { _total += _duration }
+
+		res.Count = len(res.durations)
+		res.MinSeconds = res.durations[0]
+		res.MaxSeconds = res.durations[res.Count-1]
+		res.MeanSeconds = _total / int64(res.Count)
+		res.P50Seconds = percentile(res.durations, 50)
+		res.P90Seconds = percentile(res.durations, 90)
+		res.P95Seconds = percentile(res.durations, 95)
+
+		res2E = append(res2E, res)
+	}
+
+	mapB, _ := json.Marshal(res2E)
+	return mapB, nil
+}
+
+//Assemblies in their current status for longer than THRESHOLDHOURS
+//Cancelled and Scrapped Assemblies are never stuck, Packaged ones only until their Package is handed over for shipping
+//Parameters = STATUS (empty for all), THRESHOLDHOURS, USERNAME
+func (t *TnT) getStuckAssemblies(stub Stub, args []string) ([]byte, error) {
+
+	_status := args[0]
+
+	_thresholdHours, err := strconv.ParseFloat(args[1], 64)
+	if err != nil || _thresholdHours < 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "thresholdHours", "ThresholdHours must be a positive number") }
+
+	_now := time.Now().UTC()
+
+	bytes, err := stub.GetState("Assemblies")
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+	var assemID_Holder AssemblyID_Holder
+
+	err = json.Unmarshal(bytes, &assemID_Holder)
+	if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Assemblies") }
+
+	res2E:= []*Stuck_Assembly{}
+
+	for _, assemblyId := range assemID_Holder.AssemblyIDs {
+
+		bytesAssemblyLines, err := getHistoryState(stub, assemblyId, &AssemblyLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get bytesAssemblyLinesHistoryByID") }
+		if bytesAssemblyLines == nil { continue }
+
+		var assemLine_Holder AssemblyLine_Holder
+
+		err = json.Unmarshal(bytesAssemblyLines, &assemLine_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt assemLineHistory_Holder record") }
+		if len(assemLine_Holder.AssemblyLines) == 0 { continue }
+
+		latest := assemLine_Holder.AssemblyLines[len(assemLine_Holder.AssemblyLines)-1]
+
+		if latest.AssemblyStatus == ASSEMBLYSTATUS_CAN || latest.AssemblyStatus == ASSEMBLYSTATUS_SCR { continue }
+		if len(_status) > 0 && latest.AssemblyStatus != _status { continue }
+
+		//Walk back to the version the current status was entered
+		_since := latest.AssemblyLastUpdatedOn
+		for i := len(assemLine_Holder.AssemblyLines) - 1; i >= 0; i-- {
+			if assemLine_Holder.AssemblyLines[i].AssemblyStatus != latest.AssemblyStatus { break }
+			_since = assemLine_Holder.AssemblyLines[i].AssemblyLastUpdatedOn
+		}
+
+		_sinceTime, ok := parseLedgerTime(_since)
+		if !ok { continue }
+
+		_inStatus := _now.Sub(_sinceTime)
+		if _inStatus.Hours() < _thresholdHours { continue }
+
+		if latest.AssemblyStatus == ASSEMBLYSTATUS_PKG && len(latest.AssemblyPackage) > 0 {
+			_, _shipped, err := firstHandOver(stub, latest.AssemblyPackage)
+			if err != nil { return nil, err }
+			if _shipped { continue }
+		}
+
+		res := new(Stuck_Assembly)
+		res.AssemblyId = latest.AssemblyId
+		res.DeviceSerialNo = latest.DeviceSerialNo
+		res.DeviceType = latest.DeviceType
+		res.ManufacturingPlant = latest.ManufacturingPlant
+		res.AssemblyStatus = latest.AssemblyStatus
+		res.AssemblyPackage = latest.AssemblyPackage
+		res.InStatusSince = _since
+		res.HoursInStatus = int64(_inStatus.Hours())
+
+		res2E=append(res2E,res)
+	}
+
+	mapB, _ := json.Marshal(res2E)
+	return mapB, nil
+}
+
+/* CSV export section */
+
+//One page of a CSV export over the IDs of a holder - see csvexport.Stream for reading all pages
+//BOOKMARK is the position of the first ID of the page (empty for the first page, which has the header row)
+func exportCSVPage(ids []string, _fields string, _bookmark string, _pageSize string, sample interface{}, rowsFor func(id string) ([]interface{}, error)) ([]byte, error) {
+
+	_start := 0
+	if len(_bookmark) > 0 {
+		var err error
+		_start, err = strconv.Atoi(_bookmark)
+		if err != nil || _start < 0 || _start > len(ids) { return nil, tntError(ERR_INVALID_ARGUMENT, "bookmark", "Invalid bookmark " + _bookmark) }
+	}
+
+	_size, err := strconv.Atoi(_pageSize)
+	if err != nil || _size <= 0 || _size > CSV_MAX_PAGE_SIZE {
+		return nil, tntError(ERR_INVALID_ARGUMENT, "pageSize", fmt.Sprintf("PageSize must be between 1 and %d", CSV_MAX_PAGE_SIZE))
+	}
+
+	fields, err := csvexport.ParseFields(_fields, sample)
+	if err != nil { return nil, tntError(ERR_INVALID_ARGUMENT, "fields", err.Error()) }
+
+	var buf strings.Builder
+	w, err := csvexport.NewWriter(&buf, sample, fields)
+	if err != nil { return nil, tntError(ERR_INVALID_ARGUMENT, "fields", err.Error()) }
+
+	if _start == 0 {
+		err = w.WriteHeader()
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error writing CSV header") }
+	}
+
+	page := csvexport.Page{}
+	_end := _start + _size
+	if _end > len(ids) { _end = len(ids) }
+
+	for _, id := range ids[_start:_end] {
+		rows, err := rowsFor(id)
+		if err != nil { return nil, err }
+
+		for _, row := range rows {
+			err = w.Write(row)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error writing CSV row") }
+			page.Rows++
+		}
+	}
+
+	err = w.Flush()
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error writing CSV") }
+
+	page.CSV = buf.String()
+	if _end < len(ids) { page.NextBookmark = strconv.Itoa(_end) }
+
+	mapB, _ := json.Marshal(page)
+	return mapB, nil
+}
+
+//Assembly IDs for exports
+func getAssemblyIDs(stub Stub) ([]string, error) {
+	bytes, err := stub.GetState("Assemblies")
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+
+	var assemID_Holder AssemblyID_Holder
+
+	err = json.Unmarshal(bytes, &assemID_Holder)
+	if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Assemblies") }
+
+	return assemID_Holder.AssemblyIDs, nil
+}
+
+//Package CaseIDs for exports
+func getPackageCaseIDs(stub Stub) ([]string, error) {
+	bytes, err := stub.GetState("Packages")
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Packages") }
+
+	var packageCaseID_Holder PackageCaseID_Holder
+
+	err = json.Unmarshal(bytes, &packageCaseID_Holder)
+	if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Packages") }
+
+	return packageCaseID_Holder.PackageCaseIDs, nil
+}
+
+//Export current Assemblies as CSV, as getAllAssemblies
+//Parameters = FIELDS (comma separated json names, empty for all), BOOKMARK, PAGESIZE, USERNAME, [INCLUDECANCELLED]
+func (t *TnT) exportAssembliesCSV(stub Stub, args []string) ([]byte, error) {
+
+	_includeCancelled, err := includeCancelledArg(args, 4)
+	if err != nil { return nil, err }
+
+	ids, err := getAssemblyIDs(stub)
+	if err != nil { return nil, err }
+
+	return exportCSVPage(ids, args[0], args[1], args[2], AssemblyLine{}, func(assemblyId string) ([]interface{}, error) {
+		assemblyAsBytes, err := stub.GetState(assemblyId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Assembly")}
+		if assemblyAsBytes == nil { return nil, nil }
+
+		res := new(AssemblyLine)
+		json.Unmarshal(assemblyAsBytes, &res)
+		if !_includeCancelled && res.AssemblyStatus == ASSEMBLYSTATUS_CAN { return nil, nil }
+
+		return []interface{}{res}, nil
+	})
+}
+
+//Export current Packages as CSV, as getAllPackages
+//Parameters = FIELDS (comma separated json names, empty for all), BOOKMARK, PAGESIZE, USERNAME, [INCLUDECANCELLED]
+func (t *TnT) exportPackagesCSV(stub Stub, args []string) ([]byte, error) {
+
+	_includeCancelled, err := includeCancelledArg(args, 4)
+	if err != nil { return nil, err }
+
+	ids, err := getPackageCaseIDs(stub)
+	if err != nil { return nil, err }
+
+	return exportCSVPage(ids, args[0], args[1], args[2], PackageLine{}, func(caseId string) ([]interface{}, error) {
+		packageAsBytes, err := stub.GetState(caseId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Package")}
+		if packageAsBytes == nil { return nil, nil }
+
+		res := new(PackageLine)
+		json.Unmarshal(packageAsBytes, &res)
+		if !_includeCancelled && res.PackageStatus == PACKAGESTATUS_CAN { return nil, nil }
+
+		return []interface{}{res}, nil
+	})
+}
+
+//Export AssemblyLine history versions as CSV, as getAssembliesHistoryByDate - a page covers PAGESIZE Assemblies
+//Parameters = FIELDS, FROMDATE, TODATE (YYYYMMDDHHMMSS, empty for open), BOOKMARK, PAGESIZE, USERNAME, [INCLUDECANCELLED]
+func (t *TnT) exportAssembliesHistoryCSV(stub Stub, args []string) ([]byte, error) {
+
+	_includeCancelled, err := includeCancelledArg(args, 6)
+	if err != nil { return nil, err }
+
+	_fromDate, err := parseDateBound(args[1], "fromDate", 0)
+	if err != nil { return nil, err }
+
+	_toDate, err := parseDateBound(args[2], "toDate", math.MaxInt64)
+	if err != nil { return nil, err }
+
+	ids, err := getAssemblyIDs(stub)
+	if err != nil { return nil, err }
+
+	return exportCSVPage(ids, args[0], args[3], args[4], AssemblyLine{}, func(assemblyId string) ([]interface{}, error) {
+		bytesAssemblyLines, err := getHistoryState(stub, assemblyId, &AssemblyLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get bytesAssemblyLinesHistoryByID") }
+		if bytesAssemblyLines == nil { return nil, nil }
+
+		var assemLine_Holder AssemblyLine_Holder
+
+		err = json.Unmarshal(bytesAssemblyLines, &assemLine_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt assemLineHistory_Holder record") }
+
+		_latest := len(assemLine_Holder.AssemblyLines)
+		if !_includeCancelled && _latest > 0 && assemLine_Holder.AssemblyLines[_latest-1].AssemblyStatus == ASSEMBLYSTATUS_CAN { return nil, nil }
+
+		var rows []interface{}
+		for i := range assemLine_Holder.AssemblyLines {
+			if dateInRange(assemLine_Holder.AssemblyLines[i].AssemblyDate, _fromDate, _toDate) {
+				rows = append(rows, &assemLine_Holder.AssemblyLines[i])
+			}
+		}
+		return rows, nil
+	})
+}
+
+//Export PackageLine history versions as CSV, as getPackagesHistoryByDate - a page covers PAGESIZE Packages
+//Parameters = FIELDS, FROMDATE, TODATE (YYYYMMDDHHMMSS, empty for open), BOOKMARK, PAGESIZE, USERNAME, [INCLUDECANCELLED]
+func (t *TnT) exportPackagesHistoryCSV(stub Stub, args []string) ([]byte, error) {
+
+	_includeCancelled, err := includeCancelledArg(args, 6)
+	if err != nil { return nil, err }
+
+	_fromDate, err := parseDateBound(args[1], "fromDate", 0)
+	if err != nil { return nil, err }
+
+	_toDate, err := parseDateBound(args[2], "toDate", math.MaxInt64)
+	if err != nil { return nil, err }
+
+	ids, err := getPackageCaseIDs(stub)
+	if err != nil { return nil, err }
+
+	return exportCSVPage(ids, args[0], args[3], args[4], PackageLine{}, func(caseId string) ([]interface{}, error) {
+		bytesPackageLines, err := getHistoryState(stub, caseId, &PackageLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get bytesPackageHistoryLines") }
+		if bytesPackageLines == nil { return nil, nil }
+
+		var packLine_Holder PackageLine_Holder
+
+		err = json.Unmarshal(bytesPackageLines, &packLine_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt History Holder record") }
+
+		_latest := len(packLine_Holder.PackageLines)
+		if !_includeCancelled && _latest > 0 && packLine_Holder.PackageLines[_latest-1].PackageStatus == PACKAGESTATUS_CAN { return nil, nil }
+
+		var rows []interface{}
+		for i := range packLine_Holder.PackageLines {
+			if dateInRange(packLine_Holder.PackageLines[i].PackagingDate, _fromDate, _toDate) {
+				rows = append(rows, &packLine_Holder.PackageLines[i])
+			}
+		}
+		return rows, nil
+	})
+}
+
+/* Merkle proof section */
+
+//Canonical Merkle leaf of an Assembly packed into the case _caseId
+func assemblyMerkleLeaf(assem AssemblyLine, _caseId string) merkle.AssemblyLeaf {
+	leaf := merkle.AssemblyLeaf{}
+	leaf.AssemblyId = assem.AssemblyId
+	leaf.DeviceSerialNo = assem.DeviceSerialNo
+	leaf.DeviceType = assem.DeviceType
+	leaf.FilamentBatchId = assem.FilamentBatchId
+	leaf.LedBatchId = assem.LedBatchId
+	leaf.CircuitBoardBatchId = assem.CircuitBoardBatchId
+	leaf.WireBatchId = assem.WireBatchId
+	leaf.CasingBatchId = assem.CasingBatchId
+	leaf.AdaptorBatchId = assem.AdaptorBatchId
+	leaf.StickPodBatchId = assem.StickPodBatchId
+	leaf.ManufacturingPlant = assem.ManufacturingPlant
+	leaf.AssemblyDate = assem.AssemblyDate
+	leaf.CaseId = _caseId
+	return leaf
+}
+
+//Inclusion proof of a device in a Package, verifiable offline with merkle.Verify
+//Parameters = CAS0001, DEV0101 (DeviceSerialNo), USERNAME
+func (t *TnT) getPackageInclusionProof(stub Stub, args []string) ([]byte, error) {
+
+	_caseId := args[0]
+	_deviceSerialNo := args[1]
+
+	bytesPackMerkle, err := getMovedState(stub, packageMerkleKey(_caseId), _caseId + "M", &PackageMerkle_Holder{}, _caseId)
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Package Merkle tree") }
+	if bytesPackMerkle == nil { return nil, tntError(ERR_NOT_FOUND, "caseId", "No Merkle tree stored for Package " + _caseId) }
+
+	var packMerkle_Holder PackageMerkle_Holder
+	err = json.Unmarshal(bytesPackMerkle, &packMerkle_Holder)
+	if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt PackageMerkle_Holder record") }
+
+	for i, leaf := range packMerkle_Holder.Leaves {
+		if leaf.DeviceSerialNo != _deviceSerialNo { continue }
+
+		proof, err := merkle.Proof(packMerkle_Holder.Leaves, i)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error computing inclusion proof") }
+
+		inclusion := Inclusion_Proof{}
+		inclusion.CaseId = _caseId
+		inclusion.MerkleRoot = packMerkle_Holder.MerkleRoot
+		inclusion.LeafIndex = i
+		inclusion.Leaf = leaf
+		inclusion.Proof = proof
+
+		mapB, _ := json.Marshal(inclusion)
+		return mapB, nil
+	}
+
+	return nil, tntError(ERR_NOT_FOUND, "deviceSerialNo", "Device " + _deviceSerialNo + " is not part of Package " + _caseId)
+}
+
+/* Hash Verification section */
+
+// Result of an Assembly / Package hash verification
+type Hash_Verification struct {
+	RecordId 		string `json:"recordId"`
+	ComputedHash 	string `json:"computedHash"`
+	StoredHash 		string `json:"storedHash"`
+	MatchedVersion 	int `json:"matchedVersion"` // Index in the history holder, -1 if no version matches
+	Valid 			bool `json:"valid"`
+	Message 		string `json:"message"`
+}
+
+// Writes a field as length:value; so that adjacent fields can't be shifted into each other
+func writeHashField(buf []byte, field string) []byte {
+	buf = append(buf, strconv.Itoa(len(field))...)
+	buf = append(buf, ':')
+	buf = append(buf, field...)
+	return append(buf, ';')
+}
+
+//Canonical content hash of an AssemblyLine version (all fields except AssemblyHash itself)
+//Field order is fixed here and must never change, otherwise previously stored hashes can't be verified
+func computeAssemblyHash(assem AssemblyLine) string {
+	var buf []byte
+	buf = writeHashField(buf, assem.AssemblyId)
+	buf = writeHashField(buf, assem.DeviceSerialNo)
+	buf = writeHashField(buf, assem.DeviceType)
+	buf = writeHashField(buf, assem.FilamentBatchId)
+	buf = writeHashField(buf, assem.LedBatchId)
+	buf = writeHashField(buf, assem.CircuitBoardBatchId)
+	buf = writeHashField(buf, assem.WireBatchId)
+	buf = writeHashField(buf, assem.CasingBatchId)
+	buf = writeHashField(buf, assem.AdaptorBatchId)
+	buf = writeHashField(buf, assem.StickPodBatchId)
+	buf = writeHashField(buf, assem.ManufacturingPlant)
+	buf = writeHashField(buf, assem.AssemblyStatus)
+	buf = writeHashField(buf, assem.AssemblyDate)
+	buf = writeHashField(buf, assem.AssemblyCreationDate)
+	buf = writeHashField(buf, assem.AssemblyLastUpdatedOn)
+	buf = writeHashField(buf, assem.AssemblyCreatedBy)
+	buf = writeHashField(buf, assem.AssemblyLastUpdatedBy)
+	buf = writeHashField(buf, assem.AssemblyPackage)
+	buf = writeHashField(buf, assem.AssemblyInfo1)
+	buf = writeHashField(buf, assem.AssemblyInfo2)
+
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+//Canonical content hash of a PackageLine version (all fields except PackageHash itself)
+//Field order is fixed here and must never change, otherwise previously stored hashes can't be verified
+func computePackageHash(pack PackageLine) string {
+	var buf []byte
+	buf = writeHashField(buf, pack.CaseId)
+	buf = writeHashField(buf, pack.HolderAssemblyId)
+	buf = writeHashField(buf, pack.ChargerAssemblyId)
+	buf = writeHashField(buf, pack.PackageStatus)
+	buf = writeHashField(buf, pack.PackagingDate)
+	buf = writeHashField(buf, pack.ShippingToAddress)
+	buf = writeHashField(buf, pack.PackageCreationDate)
+	buf = writeHashField(buf, pack.PackageLastUpdatedOn)
+	buf = writeHashField(buf, pack.PackageCreatedBy)
+	buf = writeHashField(buf, pack.PackageLastUpdatedBy)
+	buf = writeHashField(buf, pack.PackageInfo1)
+	buf = writeHashField(buf, pack.PackageInfo2)
+	// Added after hashes were first stored - only part of the hash when set so older versions still verify
+	// Field name is hashed too since any of these may be missing
+	if len(pack.PackageMerkleRoot) > 0 {
+		buf = writeHashField(buf, "packageMerkleRoot")
+		buf = writeHashField(buf, pack.PackageMerkleRoot)
+	}
+	if len(pack.PackageRMAId) > 0 {
+		buf = writeHashField(buf, "packageRMAId")
+		buf = writeHashField(buf, pack.PackageRMAId)
+	}
+	if len(pack.CustomerDetailsHash) > 0 {
+		buf = writeHashField(buf, "customerDetailsHash")
+		buf = writeHashField(buf, pack.CustomerDetailsHash)
+	}
+	if len(pack.PrivatisedFromHash) > 0 {
+		buf = writeHashField(buf, "privatisedFromHash")
+		buf = writeHashField(buf, pack.PrivatisedFromHash)
+	}
+
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+//Verify an Assembly record against the hashes stored on the ledger
+//Parameters = ASM0001, exported AssemblyLine JSON (empty to verify the current ledger record), USERNAME
+func (t *TnT) verifyAssembly(stub Stub, args []string) ([]byte, error) {
+
+	_assemblyId := args[0]
+	_assemblyRecord := args[1]
+
+	verification := Hash_Verification{}
+	verification.RecordId = _assemblyId
+	verification.MatchedVersion = -1
+
+	//Verify the current ledger record when no exported record is supplied
+	if len(_assemblyRecord) == 0 {
+		assemblyAsBytes, err := stub.GetState(_assemblyId)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id")	}
+		if assemblyAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+		assem := AssemblyLine{}
+		err = json.Unmarshal(assemblyAsBytes, &assem)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Assembly record") }
+
+		verification.ComputedHash = computeAssemblyHash(assem)
+		verification.StoredHash = assem.AssemblyHash
+	} else {
+		assem := AssemblyLine{}
+		err := json.Unmarshal([]byte(_assemblyRecord), &assem)
+		if err != nil {	return nil, tntError(ERR_INVALID_ARGUMENT, "assemblyRecord", "Supplied Assembly record is not valid JSON") }
+		if assem.AssemblyId != _assemblyId { return nil, tntError(ERR_INVALID_ARGUMENT, "assemblyRecord", "Supplied Assembly record doesn't belong to " + _assemblyId) }
+
+		verification.ComputedHash = computeAssemblyHash(assem)
+
+		bytesAssemblyLines, err := getHistoryState(stub, _assemblyId, &AssemblyLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+		if bytesAssemblyLines == nil { return nil, tntError(ERR_NOT_FOUND, "assemblyId", "Assembly doesn't exists") }
+
+		var assemLine_Holder AssemblyLine_Holder
+
+		err = json.Unmarshal(bytesAssemblyLines, &assemLine_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt AssemblyLines record") }
+
+		//Looking for the ledger version the exported record was taken from
+		for i, res := range assemLine_Holder.AssemblyLines {
+			if res.AssemblyHash == verification.ComputedHash &&
+				computeAssemblyHash(res) == res.AssemblyHash {
+				verification.MatchedVersion = i
+				verification.StoredHash = res.AssemblyHash
+			}
+		}
+	}
+
+	if len(verification.StoredHash) == 0 {
+		verification.Message = "No matching hash stored on the ledger"
+	} else if verification.StoredHash != verification.ComputedHash {
+		verification.Message = "Hash mismatch - record has been altered"
+	} else {
+		verification.Valid = true
+		verification.Message = "Hash verified"
+	}
+
+	mapB, _ := json.Marshal(verification)
+	return mapB, nil
+}
+
+//Verify a Package record against the hashes stored on the ledger
+//Parameters = CAS0001, exported PackageLine JSON (empty to verify the current ledger record), USERNAME
+func (t *TnT) verifyPackage(stub Stub, args []string) ([]byte, error) {
+
+	_caseId := args[0]
+	_packageRecord := args[1]
+
+	verification := Hash_Verification{}
+	verification.RecordId = _caseId
+	verification.MatchedVersion = -1
+
+	//Verify the current ledger record when no exported record is supplied
+	if len(_packageRecord) == 0 {
+		packageAsBytes, err := stub.GetState(_caseId)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Package") }
+		if packageAsBytes == nil { return nil, tntError(ERR_NOT_FOUND, "caseId", "Package doesn't exists") }
+
+		pack := PackageLine{}
+		err = json.Unmarshal(packageAsBytes, &pack)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Package record") }
+
+		verification.ComputedHash = computePackageHash(pack)
+		verification.StoredHash = pack.PackageHash
+	} else {
+		pack := PackageLine{}
+		err := json.Unmarshal([]byte(_packageRecord), &pack)
+		if err != nil {	return nil, tntError(ERR_INVALID_ARGUMENT, "packageRecord", "Supplied Package record is not valid JSON") }
+		if pack.CaseId != _caseId { return nil, tntError(ERR_INVALID_ARGUMENT, "packageRecord", "Supplied Package record doesn't belong to " + _caseId) }
+
+		verification.ComputedHash = computePackageHash(pack)
+
+		bytesPackageLines, err := getHistoryState(stub, _caseId, &PackageLine_Holder{})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get bytesPackageLines") }
+		if bytesPackageLines == nil { return nil, tntError(ERR_NOT_FOUND, "caseId", "Package doesn't exists") }
+
+		var packLine_Holder PackageLine_Holder
+
+		err = json.Unmarshal(bytesPackageLines, &packLine_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt bytesPackageLines record") }
+
+		//Looking for the ledger version the exported record was taken from - a version whose address was redacted by
+		//privatisePackageAddress is checked with the address of the exported record
+		for i, res := range packLine_Holder.PackageLines {
+			if res.ShippingToAddressRedacted { res.ShippingToAddress = pack.ShippingToAddress }
+			if res.PackageHash == verification.ComputedHash &&
+				computePackageHash(res) == res.PackageHash {
+				verification.MatchedVersion = i
+				verification.StoredHash = res.PackageHash
+			}
+		}
+	}
+
+	if len(verification.StoredHash) == 0 {
+		verification.Message = "No matching hash stored on the ledger"
+	} else if verification.StoredHash != verification.ComputedHash {
+		verification.Message = "Hash mismatch - record has been altered"
+	} else {
+		verification.Valid = true
+		verification.Message = "Hash verified"
+	}
+
+	mapB, _ := json.Marshal(verification)
+	return mapB, nil
+}
+
+
+//Security & Access
+
+//==============================================================================================================================
+//	 General Functions
+//==============================================================================================================================
+//	 get_ecert - Takes the name passed and calls out to the REST API for HyperLedger to retrieve the ecert
+//				 for that user. Returns the ecert as retrived including html encoding.
+//==============================================================================================================================
+func (t *TnT) get_ecert(stub Stub, name string) ([]byte, error) {
+
+	ecert, err := stub.GetState(name)
+
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Couldn't retrieve ecert for user " + name) }
+
+	return ecert, nil
+}
+
+
+//==============================================================================================================================
+//	 add_ecert - Adds a new ecert and user pair to the table of ecerts
+//==============================================================================================================================
+
+func (t *TnT) add_ecert(stub Stub, name string, ecert string) ([]byte, error) {
+
+	err := stub.PutState(name, []byte(ecert))
+
+	if err != nil {
+		return nil, tntError(ERR_CORRUPT_STATE, "", "Error storing eCert for user " + name + " identity: " + ecert)
+	}
+
+	return nil, nil
+
+}
+
+//Roles a user can be registered with
+var userRoles = []string{ASSEMBLYLINE_ROLE, PACKAGELINE_ROLE, QA_VIEWER_ROLE, CONSUMER_ROLE, QA_INSPECTOR_ROLE, CARRIER_ROLE, DISTRIBUTOR_ROLE, RETAILER_ROLE}
+
+func isUserRole(_role string) bool {
+	for _, role := range userRoles {
+		if role == _role { return true }
+	}
+	return false
+}
+
+//Identity a user submits with, set by registerUser
+func userIdentityKey(_user string) string {
+	return "IDENTITY|" + _user // Indicates user identity key
+}
+
+//User an identity is registered for, set by registerUser
+func identityUserKey(_identity string) string {
+	return "IDENTITYUSER|" + _identity // Indicates identity user key
+}
+
+//Stubs that tell whether the identity submitting the transaction administers the ledger - the contract API stub reads
+//it from the certificate, the legacy shim has no administrators
+type adminStub interface {
+	IsAdmin() (bool, error)
+}
+
+//Fails unless the identity submitting the transaction administers the ledger
+func checkAdmin(stub Stub) error {
+	adminStub, ok := stub.(adminStub)
+	if !ok { return tntError(ERR_FORBIDDEN, "", "Ledger administration needs the contract API chaincode (chaincode/v2)") }
+
+	admin, err := adminStub.IsAdmin()
+	if err != nil { return tntError(ERR_CORRUPT_STATE, "", "Unable to get the submitting identity") }
+	if !admin { return tntError(ERR_FORBIDDEN, "", "Permission denied, only ledger administrators can do this") }
+	return nil
+}
+
+//Identity submitting a transaction, as registerUser binds users to it
+type Caller_Identity struct {
+	Identity 	string `json:"identity"`
+}
+
+//API for a ledger administrator to register a user, or change its role, bound to the identity the user submits with
+//Parameters = USERNAME, ROLE, IDENTITY (the user's getCallerIdentity)
+func (t *TnT) registerUser(stub Stub, args []string) ([]byte, error) {
+
+	_user := args[0]
+	_role := args[1]
+	_identity := strings.ToLower(args[2])
+
+	if len(_user) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "name", "User name supplied as empty") }
+	if err := checkRecordId(_user, "name"); err != nil { return nil, err }
+	if !isUserRole(_role) { return nil, tntError(ERR_INVALID_ARGUMENT, "role", "Unknown role " + _role) }
+	_hash, err := hex.DecodeString(_identity)
+	if err != nil || len(_hash) != sha256.Size { return nil, tntError(ERR_INVALID_ARGUMENT, "identity", "Identity must be the user's getCallerIdentity") }
+
+	//Users are stored under their name, next to the records
+	ecert_role, err := t.get_ecert(stub, _user)
+	if err != nil { return nil, err }
+	if ecert_role != nil && !isUserRole(string(ecert_role)) { return nil, tntError(ERR_ALREADY_EXISTS, "name", "Name " + _user + " is taken by a record") }
+
+	//An identity belongs to one user, so it tells who calls where the user is left out - see callingUser
+	_owner, err := stub.GetState(identityUserKey(_identity))
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get the identity user") }
+	if _owner != nil && string(_owner) != _user { return nil, tntError(ERR_ALREADY_EXISTS, "identity", "Identity is registered with user " + string(_owner)) }
+
+	_, err = t.add_ecert(stub, _user, _role)
+	if err != nil { return nil, err }
+
+	//A renewed identity replaces the old one
+	_old, err := stub.GetState(userIdentityKey(_user))
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get the user identity") }
+	if _old != nil && string(_old) != _identity {
+		err = stub.DelState(identityUserKey(string(_old)))
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to delete the state") }
+	}
+
+	err = stub.PutState(userIdentityKey(_user), []byte(_identity))
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+	err = stub.PutState(identityUserKey(_identity), []byte(_user))
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+
+	return nil, nil
+}
+
+//Identity submitting the query, for a ledger administrator to register the caller with - see registerUser
+func (t *TnT) getCallerIdentity(stub Stub, args []string) ([]byte, error) {
+
+	_identity, err := creatorIdentity(stub)
+	if err != nil { return nil, err }
+	if len(_identity) == 0 { return nil, tntError(ERR_NOT_FOUND, "", "The peer doesn't provide the submitting identity") }
+
+	mapB, _ := json.Marshal(Caller_Identity{Identity: _identity})
+	return mapB, nil
+}
+
+/* Dates section */
+
+//Dates are given as YYYYMMDDHHMMSS, read as UTC, or as ISO-8601 / RFC 3339 with an offset, e.g. 2017-06-08T15:45:00+05:30,
+//2017-06-08T10:15:00Z or 20170608T154500+0530. They are stored, indexed and compared as YYYYMMDDHHMMSS in UTC (DATE_FORMAT)
+var dateLayouts = []string{DATE_FORMAT, time.RFC3339, "2006-01-02T15:04:05Z0700", "20060102T150405Z0700"}
+
+//Parses a date given to the chaincode, in UTC - only real calendar dates, so no month 13 or February 30
+func ParseDate(_date string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		_time, err := time.Parse(layout, _date)
+		if err == nil { return _time.UTC(), nil }
+	}
+	return time.Time{}, fmt.Errorf("%q is not a valid date, expecting YYYYMMDDHHMMSS (UTC) or ISO-8601 with an offset, e.g. 2017-06-08T15:45:00+05:30", _date)
+}
+
+//A date argument in its stored form
+func canonicalDate(_date string, _field string) (string, error) {
+	_time, err := ParseDate(_date)
+	if err != nil { return "", tntError(ERR_INVALID_ARGUMENT, _field, err.Error()) }
+	return _time.Format(DATE_FORMAT), nil
+}
+
+//FROMDATE / TODATE of the date range queries - the stored form as int64
+func parseDateArg(_date string, _field string) (int64, error) {
+	_canonical, err := canonicalDate(_date, _field)
+	if err != nil { return 0, err }
+	return strconv.ParseInt(_canonical, 10, 64)
+}
+
+//Time zones of the manufacturing plants - stored against "PlantTimeZones". Plants without one show their dates in UTC
+type Plant_TimeZones struct {
+	TimeZones 		map[string]string `json:"timeZones"` // ManufacturingPlant -> IANA time zone (e.g. Asia/Kolkata) or UTC offset (e.g. +05:30)
+	LastUpdatedOn 	string `json:"lastUpdatedOn"`
+	LastUpdatedBy 	string `json:"lastUpdatedBy"`
+	SchemaVersion 	Schema_Version `json:"schemaVersion"`
+}
+
+//Location of an IANA time zone or a UTC offset. The time zone database is built into the chaincode (time/tzdata),
+//so every peer converts alike
+func loadTimeZone(_zone string) (*time.Location, error) {
+	if len(_zone) == 6 && (_zone[0] == '+' || _zone[0] == '-') && _zone[3] == ':' {
+		_hours, errHours := strconv.Atoi(_zone[1:3])
+		_minutes, errMinutes := strconv.Atoi(_zone[4:])
+		if errHours != nil || errMinutes != nil || strings.Trim(_zone[1:3] + _zone[4:], "0123456789") != "" || _hours > 14 || _minutes > 59 {
+			return nil, fmt.Errorf("invalid UTC offset %s", _zone)
+		}
+		_offset := (_hours * 60 + _minutes) * 60
+		if _zone[0] == '-' { _offset = -_offset }
+		return time.FixedZone(_zone, _offset), nil
+	}
+	//Local is the peer's own time zone - not the same on every peer
+	if len(_zone) == 0 || _zone == "Local" { return nil, fmt.Errorf("invalid time zone %q", _zone) }
+	return time.LoadLocation(_zone)
+}
+
+func getPlantTimeZones(stub Stub) (Plant_TimeZones, error) {
+	zones := Plant_TimeZones{}
+
+	bytesZones, err := stub.GetState("PlantTimeZones")
+	if err != nil { return zones, tntError(ERR_CORRUPT_STATE, "", "Unable to get Plant time zones") }
+	if bytesZones != nil {
+		err = json.Unmarshal(bytesZones, &zones)
+		if err != nil {	return zones, tntError(ERR_CORRUPT_STATE, "", "Corrupt Plant time zones record") }
+	} else {
+		zones.SchemaVersion = SCHEMA_VERSION
+	}
+	if zones.TimeZones == nil { zones.TimeZones = map[string]string{} }
+
+	return zones, nil
+}
+
+//A stored date as RFC 3339 in the time zone of the plant, e.g. 2017-06-08T15:45:00+05:30. Empty for dates not in
+//the stored form (records from before the dates were checked)
+func (zones Plant_TimeZones) localDate(_date string, _plant string) string {
+	_time, err := time.Parse(DATE_FORMAT, _date)
+	if err != nil { return "" }
+
+	_location, err := loadTimeZone(zones.TimeZones[_plant])
+	if err != nil { _location = time.UTC }
+	return _time.In(_location).Format(time.RFC3339)
+}
+
+//Plant of a Package - that of its Holder Assembly, else of its Charger Assembly
+func packagePlant(stub Stub, _holderAssemblyId string, _chargerAssemblyId string) (string, error) {
+	for _, _assemblyId := range []string{_holderAssemblyId, _chargerAssemblyId} {
+		if len(_assemblyId) == 0 { continue }
+
+		assemblyAsBytes, err := stub.GetState(_assemblyId)
+		if err != nil { return "", tntError(ERR_CORRUPT_STATE, "", "Failed to get assembly Id") }
+		if assemblyAsBytes == nil { continue }
+
+		assem := AssemblyLine{}
+		json.Unmarshal(assemblyAsBytes, &assem)
+		if len(assem.ManufacturingPlant) > 0 { return assem.ManufacturingPlant, nil }
+	}
+	return "", nil
+}
+
+//Sets the time zone of a manufacturing plant, for the local dates of the records written afterwards - records
+//written before keep theirs. Empty TIMEZONE removes it, the plant's dates are then shown in UTC
+//Parameters = PLANT, TIMEZONE (IANA time zone e.g. Asia/Kolkata, or UTC offset e.g. +05:30), USERNAME
+func (t *TnT) setPlantTimeZone(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[2]
+
+	_plant := args[0]
+	_zone := args[1]
+
+	if len(_plant) == 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "plant", "Plant supplied as empty") }
+	if len(_zone) > 0 {
+		if _, err := loadTimeZone(_zone); err != nil {
+			return nil, tntError(ERR_INVALID_ARGUMENT, "timeZone", "Unknown time zone " + _zone + ", expecting an IANA time zone (e.g. Asia/Kolkata) or a UTC offset (e.g. +05:30)")
+		}
+	}
+
+	zones, err := getPlantTimeZones(stub)
+	if err != nil { return nil, err }
+
+	if len(_zone) == 0 {
+		delete(zones.TimeZones, _plant)
+	} else {
+		zones.TimeZones[_plant] = _zone
+	}
+	zones.LastUpdatedOn = time.Now().UTC().Format(DATE_FORMAT)
+	zones.LastUpdatedBy = user_name
+
+	bytesZones, err := json.Marshal(zones)
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating Plant time zones record") }
+
+	err = stub.PutState("PlantTimeZones", bytesZones)
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+
+	return bytesZones, nil
+}
+
+//Time zones of the manufacturing plants
+//Parameters = USERNAME
+func (t *TnT) getPlantTimeZones(stub Stub, args []string) ([]byte, error) {
+	zones, err := getPlantTimeZones(stub)
+	if err != nil { return nil, err }
+
+	mapB, _ := json.Marshal(zones)
+	return mapB, nil
+}
+
+/* Schema section */
+
+//Schema versions of the stored records (SCHEMA_VERSION is the current one)
+//0 - records written before versioning, they have no schemaVersion. AssemblyLines from before AssemblyDate have no
+//    assemblyDate, records from before the content hashes have no hash, Packages may hold their ShippingToAddress publicly
+//1 - schemaVersion on every record
+//2 - assemblyDateLocal and packagingDateLocal, the dates in the time zone of the plant. Dates are stored in UTC; those
+//    stored before were YYYYMMDDHHMMSS without a time zone and are taken as UTC
+//3 - records kept next to an Assembly or Package under TYPE|ID keys (historyKey, serialNoHistoryKey, qaInspectionKey,
+//    reworkKey, cancellationKey, scrapKey, packageMerkleKey, caseShipmentsKey). They were stored against the ID with a
+//    suffix ("H", "S", "Q", "R", "C", "D", "M", "T"), which an Assembly or Package could hold as its own ID. Reads
+//    fall back to the old key until migrateSchema has moved them, see getMovedState. Customer details that peers
+//    without private data support kept in the world state (publicPrivateDataKey) go to the private data collection
+//A record type getting an upgrade step reads older versions through an UnmarshalJSON, as AssemblyLine, and
+//migrateSchema applies the steps needing other records or writes
+//New records are created at SCHEMA_VERSION. A record updated in place keeps the version it was stored with until
+//migrateSchema has applied the steps it is missing
+
+//Schema version of a stored record
+type Schema_Version int
+
+//AssemblyLine without its UnmarshalJSON
+type assemblyLine_Stored AssemblyLine
+
+//Reads an AssemblyLine of any schema version, upconverted to the current one
+func (assem *AssemblyLine) UnmarshalJSON(data []byte) error {
+	err := json.Unmarshal(data, (*assemblyLine_Stored)(assem))
+	if err != nil { return err }
+
+	if assem.SchemaVersion < 1 {
+		//Records from before AssemblyDate - the creation date is the closest known
+		var fields map[string]json.RawMessage
+		json.Unmarshal(data, &fields)
+		if _, ok := fields["assemblyDate"]; !ok { assem.AssemblyDate = assem.AssemblyCreationDate }
+	}
+	return nil
+}
+
+//Reads the record under key, or under the key it had before schema version 3 while migrateSchema hasn't moved it.
+//The old key is an ID with a suffix, shared with the Assemblies and Packages, so the record there is only taken
+//when it decodes strictly as record and belongs to _id (see ownsRecord)
+func getMovedState(stub Stub, key string, oldKey string, record interface{}, _id string) ([]byte, error) {
+	bytes, err := stub.GetState(key)
+	if err != nil || bytes != nil { return bytes, err }
+
+	migration, err := getSchemaMigration(stub)
+	if err != nil { return nil, err }
+	if migration.TargetVersion == SCHEMA_VERSION && migration.Done { return nil, nil }
+
+	bytes, err = stub.GetState(oldKey)
+	if err != nil || bytes == nil { return nil, err }
+	if !decodeStrict(bytes, record) || !ownsRecord(record, _id) { return nil, nil }
+
+	return bytes, nil
+}
+
+//Moves the record under oldKey to key, checked as by getMovedState. A record already under key was written since
+//from the old one and is newer, the old one is only removed
+func moveRecord(stub Stub, oldKey string, key string, record interface{}, _id string) (bool, error) {
+	bytes, err := stub.GetState(oldKey)
+	if err != nil { return false, tntError(ERR_CORRUPT_STATE, "", "Unable to get " + oldKey) }
+	if bytes == nil || !decodeStrict(bytes, record) || !ownsRecord(record, _id) { return false, nil }
+
+	current, err := stub.GetState(key)
+	if err != nil { return false, tntError(ERR_CORRUPT_STATE, "", "Unable to get " + key) }
+	if current == nil {
+		err = stub.PutState(key, bytes)
+		if err != nil { return false, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+	}
+
+	err = stub.DelState(oldKey)
+	if err != nil { return false, tntError(ERR_CORRUPT_STATE, "", "Unable to delete the state") }
+
+	return true, nil
+}
+
+//Decodes bytes into record, false if they hold fields record doesn't have
+func decodeStrict(bytes []byte, record interface{}) bool {
+	decoder := json.NewDecoder(strings.NewReader(string(bytes)))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(record) == nil
+}
+
+//true if the record read from an old key is the one kept for the Assembly or Package _id
+func ownsRecord(record interface{}, _id string) bool {
+	switch r := record.(type) {
+	case *SerialNo_History:
+		return r.AssemblyId == _id
+	case *QA_Inspection_Holder:
+		for _, inspection := range r.Inspections {
+			if inspection.AssemblyId != _id { return false }
+		}
+		return true
+	case *Assembly_Rework_Holder:
+		for _, rework := range r.Reworks {
+			if rework.AssemblyId != _id { return false }
+		}
+		return true
+	case *Cancellation:
+		return r.Id == _id
+	case *Scrap_Record:
+		return r.ItemType == SCRAP_ASSEMBLY && r.Id == _id
+	case *PackageMerkle_Holder:
+		return r.CaseId == _id
+	case *AssemblyLine_Holder:
+		for _, assem := range r.AssemblyLines {
+			if assem.AssemblyId != _id { return false }
+		}
+		return true
+	case *PackageLine_Holder:
+		for _, pack := range r.PackageLines {
+			if pack.CaseId != _id { return false }
+		}
+		return true
+	case *Case_Shipment_Holder:
+		return true // Nothing else decodes strictly as a list of ShipmentIds
+	}
+	return false
+}
+
+//Schema version a record was stored with
+func storedSchemaVersion(bytes []byte) (int, error) {
+	var stored struct {
+		SchemaVersion 	int `json:"schemaVersion"`
+	}
+	err := json.Unmarshal(bytes, &stored)
+	return stored.SchemaVersion, err
+}
+
+//Progress of migrateSchema - stored against "SchemaMigration"
+type Schema_Migration struct {
+	TargetVersion 	int `json:"targetVersion"` // SCHEMA_VERSION the records are migrated to
+	Bookmark 		int `json:"bookmark"` // Next unit to migrate
+	Units 			int `json:"units"` // Units found by the last run
+	Migrated 		int `json:"migrated"` // Records rewritten so far
+	Done 			bool `json:"done"`
+	LastRunOn 		string `json:"lastRunOn"`
+	LastRunBy 		string `json:"lastRunBy"`
+	SchemaVersion 	Schema_Version `json:"schemaVersion"`
+}
+
+//Unit of migration work - the ledger wide records, or the records of one Assembly, Package, RMA or Shipment
+type migration_Unit struct {
+	Kind 	string
+	Id 		string
+}
+
+func getSchemaMigration(stub Stub) (Schema_Migration, error) {
+	var migration Schema_Migration
+
+	bytesMigration, err := stub.GetState("SchemaMigration")
+	if err != nil { return migration, tntError(ERR_CORRUPT_STATE, "", "Unable to get Schema migration") }
+	if bytesMigration == nil { migration.SchemaVersion = SCHEMA_VERSION; return migration, nil }
+
+	err = json.Unmarshal(bytesMigration, &migration)
+	if err != nil {	return migration, tntError(ERR_CORRUPT_STATE, "", "Corrupt Schema migration record") }
+
+	return migration, nil
+}
+
+//Units in migration order. The ID lists only grow, so a unit added between two runs moves the later units
+//back and the next run repeats a few units, which are skipped as already migrated
+func getMigrationUnits(stub Stub) ([]migration_Unit, error) {
+	units := []migration_Unit{{Kind: "ledger"}}
+
+	assemblyIds, err := getAssemblyIDs(stub)
+	if err != nil { return nil, err }
+	for _, id := range assemblyIds {
+		units = append(units, migration_Unit{Kind: "assembly", Id: id})
+	}
+
+	caseIds, err := getPackageCaseIDs(stub)
+	if err != nil { return nil, err }
+	for _, id := range caseIds {
+		units = append(units, migration_Unit{Kind: "package", Id: id})
+	}
+
+	rmaID_Holder, err := getRMAIds(stub)
+	if err != nil { return nil, err }
+	for _, id := range rmaID_Holder.RMAIds {
+		units = append(units, migration_Unit{Kind: "rma", Id: id})
+	}
+
+	var shipmentID_Holder Shipment_ID_Holder
+	bytesShipmentHolder, err := stub.GetState("Shipments")
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Shipments") }
+	if bytesShipmentHolder != nil {
+		err = json.Unmarshal(bytesShipmentHolder, &shipmentID_Holder)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Shipments record") }
+	}
+	for _, id := range shipmentID_Holder.ShipmentIds {
+		units = append(units, migration_Unit{Kind: "shipment", Id: id})
+	}
+
+	return units, nil
+}
+
+//Rewrites the record under key at the current schema version, reading it into record (upconverting it)
+//and applying fix, if any. Records missing or already current are left alone
+//record is a pointer to a struct with a SchemaVersion field
+func migrateRecord(stub Stub, key string, record interface{}, fix func()) (bool, error) {
+	bytes, err := stub.GetState(key)
+	if err != nil { return false, tntError(ERR_CORRUPT_STATE, "", "Unable to get " + key) }
+	if bytes == nil { return false, nil }
+
+	_version, err := storedSchemaVersion(bytes)
+	if err != nil {	return false, tntError(ERR_CORRUPT_STATE, "", "Corrupt record " + key) }
+	if _version >= SCHEMA_VERSION { return false, nil }
+
+	err = json.Unmarshal(bytes, record)
+	if err != nil {	return false, tntError(ERR_CORRUPT_STATE, "", "Corrupt record " + key) }
+	if fix != nil { fix() }
+	reflect.ValueOf(record).Elem().FieldByName("SchemaVersion").SetInt(SCHEMA_VERSION)
+
+	bytes, err = json.Marshal(record)
+	if err != nil { return false, tntError(ERR_CORRUPT_STATE, "", "Error converting record " + key) }
+
+	err = stub.PutState(key, bytes)
+	if err != nil { return false, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+
+	return true, nil
+}
+
+//Migrates the records of one unit, returns the number of records rewritten
+func (t *TnT) migrateUnit(stub Stub, unit migration_Unit, _updatedOn string, _updatedBy string) (int, error) {
+	_migrated := 0
+	migrate := func(key string, record interface{}, fix func()) error {
+		_changed, err := migrateRecord(stub, key, record, fix)
+		if _changed { _migrated++ }
+		return err
+	}
+	move := func(oldKey string, key string, record interface{}) error {
+		_changed, err := moveRecord(stub, oldKey, key, record, unit.Id)
+		if _changed { _migrated++ }
+		return err
+	}
+
+	switch unit.Kind {
+	case "ledger":
+		if err := migrate("Assemblies", &AssemblyID_Holder{}, nil); err != nil { return _migrated, err }
+		if err := migrate("Packages", &PackageCaseID_Holder{}, nil); err != nil { return _migrated, err }
+		if err := migrate("RMAs", &RMA_ID_Holder{}, nil); err != nil { return _migrated, err }
+		if err := migrate("Shipments", &Shipment_ID_Holder{}, nil); err != nil { return _migrated, err }
+		if err := migrate("Recalls", &Batch_Recall_Holder{}, nil); err != nil { return _migrated, err }
+		if err := migrate("PlantTimeZones", &Plant_TimeZones{}, nil); err != nil { return _migrated, err }
+
+	case "assembly":
+		zones, err := getPlantTimeZones(stub)
+		if err != nil { return _migrated, err }
+
+		//Records from before the content hashes get one, records from before the local dates get theirs
+		assem := AssemblyLine{}
+		if err := migrate(unit.Id, &assem, func() {
+			if len(assem.AssemblyHash) == 0 { assem.AssemblyHash = computeAssemblyHash(assem) }
+			if len(assem.AssemblyDateLocal) == 0 { assem.AssemblyDateLocal = zones.localDate(assem.AssemblyDate, assem.ManufacturingPlant) }
+		}); err != nil { return _migrated, err }
+
+		//Records kept under the AssemblyId with a suffix before version 3
+		if err := move(unit.Id + "H", historyKey(unit.Id), &AssemblyLine_Holder{}); err != nil { return _migrated, err }
+		if err := move(unit.Id + "S", serialNoHistoryKey(unit.Id), &SerialNo_History{}); err != nil { return _migrated, err }
+		if err := move(unit.Id + "Q", qaInspectionKey(unit.Id), &QA_Inspection_Holder{}); err != nil { return _migrated, err }
+		if err := move(unit.Id + "R", reworkKey(unit.Id), &Assembly_Rework_Holder{}); err != nil { return _migrated, err }
+		if err := move(unit.Id + "C", cancellationKey(unit.Id), &Cancellation{}); err != nil { return _migrated, err }
+		if err := move(unit.Id + "D", scrapKey(unit.Id), &Scrap_Record{}); err != nil { return _migrated, err }
+
+		if err := migrate(historyKey(unit.Id), &AssemblyLine_Holder{}, nil); err != nil { return _migrated, err }
+		if err := migrate(serialNoHistoryKey(unit.Id), &SerialNo_History{}, nil); err != nil { return _migrated, err }
+		if err := migrate(qaInspectionKey(unit.Id), &QA_Inspection_Holder{}, nil); err != nil { return _migrated, err }
+		if err := migrate(reworkKey(unit.Id), &Assembly_Rework_Holder{}, nil); err != nil { return _migrated, err }
+		if err := migrate(cancellationKey(unit.Id), &Cancellation{}, nil); err != nil { return _migrated, err }
+		if err := migrate(scrapKey(unit.Id), &Scrap_Record{}, nil); err != nil { return _migrated, err }
+
+		//Registry and batch records are shared, the first Assembly referring to them migrates them
+		assemblyAsBytes, err := stub.GetState(unit.Id)
+		if err != nil { return _migrated, tntError(ERR_CORRUPT_STATE, "", "Failed to get Assembly") }
+		if assemblyAsBytes == nil { return _migrated, nil }
+		json.Unmarshal(assemblyAsBytes, &assem)
+
+		if len(assem.DeviceSerialNo) > 0 {
+			if err := migrate(serialNoKey(assem.DeviceSerialNo), &SerialNo_Holder{}, nil); err != nil { return _migrated, err }
+		}
+		for _, _batchType := range []string{FIL_BATCH, LED_BATCH, CIR_BATCH, WRE_BATCH, CAS_BATCH, ADP_BATCH, STK_BATCH} {
+			_batchNo := *assemblyBatchField(&assem, _batchType)
+			if len(_batchNo) == 0 { continue }
+			if err := migrate(componentBatchKey(_batchType, _batchNo), &Component_Batch{}, nil); err != nil { return _migrated, err }
+		}
+
+	case "package":
+		//Addresses still held publicly go to the private data collection first
+		_changed, err := privatisePackageAddress(stub, unit.Id, _updatedOn, _updatedBy)
+		if err != nil { return _migrated, err }
+		if _changed { _migrated++ }
+
+		zones, err := getPlantTimeZones(stub)
+		if err != nil { return _migrated, err }
+
+		//The plant is that of the packed Assemblies, read ahead of the Package
+		pack := PackageLine{}
+		packageAsBytes, err := stub.GetState(unit.Id)
+		if err != nil { return _migrated, tntError(ERR_CORRUPT_STATE, "", "Failed to get Package") }
+		if packageAsBytes != nil { json.Unmarshal(packageAsBytes, &pack) }
+		_plant, err := packagePlant(stub, pack.HolderAssemblyId, pack.ChargerAssemblyId)
+		if err != nil { return _migrated, err }
+
+		if err := migrate(unit.Id, &pack, func() {
+			if len(pack.PackageHash) == 0 { pack.PackageHash = computePackageHash(pack) }
+			if len(pack.PackagingDateLocal) == 0 { pack.PackagingDateLocal = zones.localDate(pack.PackagingDate, _plant) }
+		}); err != nil { return _migrated, err }
+
+		//Records kept under the CaseId with a suffix before version 3
+		if err := move(unit.Id + "H", historyKey(unit.Id), &PackageLine_Holder{}); err != nil { return _migrated, err }
+		if err := move(unit.Id + "M", packageMerkleKey(unit.Id), &PackageMerkle_Holder{}); err != nil { return _migrated, err }
+		if err := move(unit.Id + "T", caseShipmentsKey(unit.Id), &Case_Shipment_Holder{}); err != nil { return _migrated, err }
+		if err := move(unit.Id + "C", cancellationKey(unit.Id), &Cancellation{}); err != nil { return _migrated, err }
+
+		if err := migrate(historyKey(unit.Id), &PackageLine_Holder{}, nil); err != nil { return _migrated, err }
+		if err := migrate(packageMerkleKey(unit.Id), &PackageMerkle_Holder{}, nil); err != nil { return _migrated, err }
+		if err := migrate(caseShipmentsKey(unit.Id), &Case_Shipment_Holder{}, nil); err != nil { return _migrated, err }
+		if err := migrate(cancellationKey(unit.Id), &Cancellation{}, nil); err != nil { return _migrated, err }
+
+		//Customer details kept publicly before version 3 move to the private data collection and leave the world state
+		_publicKey := publicPrivateDataKey(CUSTOMER_DETAILS_COLLECTION, unit.Id)
+		bytesPublic, err := stub.GetState(_publicKey)
+		if err != nil { return _migrated, tntError(ERR_CORRUPT_STATE, "", "Unable to get Customer details") }
+		if bytesPublic != nil {
+			details := Customer_Details{}
+			err = json.Unmarshal(bytesPublic, &details)
+			if err != nil {	return _migrated, tntError(ERR_CORRUPT_STATE, "", "Corrupt Customer details record") }
+			details.SchemaVersion = SCHEMA_VERSION
+			//Stored as they are so the hash on the PackageLine still matches - the next update salts it
+			err = storeCustomerDetails(stub, details)
+			if err != nil { return _migrated, err }
+			err = stub.DelState(_publicKey)
+			if err != nil { return _migrated, tntError(ERR_CORRUPT_STATE, "", "Unable to delete the state") }
+			_migrated++
+			break
+		}
+
+		//Customer details in the private data collection
+		if !hasPrivateData(stub) { break }
+		bytesDetails, err := getPrivateData(stub, CUSTOMER_DETAILS_COLLECTION, unit.Id)
+		if err != nil { return _migrated, err }
+		if bytesDetails != nil {
+			_version, err := storedSchemaVersion(bytesDetails)
+			if err != nil {	return _migrated, tntError(ERR_CORRUPT_STATE, "", "Corrupt Customer details record") }
+			if _version < SCHEMA_VERSION {
+				details, err := getCustomerDetails(stub, unit.Id)
+				if err != nil { return _migrated, err }
+				details.SchemaVersion = SCHEMA_VERSION
+				err = storeCustomerDetails(stub, details)
+				if err != nil { return _migrated, err }
+				_migrated++
+			}
+		}
+
+	case "rma":
+		if err := migrate(unit.Id, &RMA_Line{}, nil); err != nil { return _migrated, err }
+
+	case "shipment":
+		if err := migrate(unit.Id, &Shipment_Line{}, nil); err != nil { return _migrated, err }
+	}
+
+	return _migrated, nil
+}
+
+//Migrates the stored records to the current schema version, PAGESIZE units (Assemblies, Packages, RMAs, Shipments) per run
+//Progress is kept on the ledger; run again until getSchemaMigration shows done. A new SCHEMA_VERSION starts over
+//Parameters = PAGESIZE, USERNAME; transient customerDetailsSalt, for the addresses moved to the private data collection
+func (t *TnT) migrateSchema(stub Stub, args []string) ([]byte, error) {
+
+	user_name := args[1]
+
+	_pageSize, err := strconv.Atoi(args[0])
+	if err != nil || _pageSize <= 0 || _pageSize > MIGRATION_MAX_PAGE_SIZE {
+		return nil, tntError(ERR_INVALID_ARGUMENT, "pageSize", fmt.Sprintf("PageSize must be between 1 and %d", MIGRATION_MAX_PAGE_SIZE))
+	}
+
+	migration, err := getSchemaMigration(stub)
+	if err != nil { return nil, err }
+	if migration.TargetVersion != SCHEMA_VERSION {
+		migration = Schema_Migration{TargetVersion: SCHEMA_VERSION, SchemaVersion: SCHEMA_VERSION}
+	}
+
+	units, err := getMigrationUnits(stub)
+	if err != nil { return nil, err }
+
+	_time:= time.Now().UTC()
+	_updatedOn := _time.Format(DATE_FORMAT)
+
+	_end := migration.Bookmark + _pageSize
+	if _end > len(units) { _end = len(units) }
+
+	for _, unit := range units[migration.Bookmark:_end] {
+		_migrated, err := t.migrateUnit(stub, unit, _updatedOn, user_name)
+		if err != nil { return nil, err }
+		migration.Migrated += _migrated
+	}
+
+	migration.Bookmark = _end
+	migration.Units = len(units)
+	migration.Done = _end == len(units)
+	migration.LastRunOn = _updatedOn
+	migration.LastRunBy = user_name
+
+	bytesMigration, err := json.Marshal(migration)
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating Schema migration record") }
+
+	err = stub.PutState("SchemaMigration", bytesMigration)
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+
+	mapB, _ := json.Marshal(migration)
+	return mapB, nil
+}
+
+//Progress of the schema migration
+//Parameters = USERNAME
+func (t *TnT) getSchemaMigration(stub Stub, args []string) ([]byte, error) {
+	migration, err := getSchemaMigration(stub)
+	if err != nil { return nil, err }
+	if migration.TargetVersion != SCHEMA_VERSION {
+		migration = Schema_Migration{TargetVersion: SCHEMA_VERSION, SchemaVersion: SCHEMA_VERSION}
+	}
+
+	mapB, _ := json.Marshal(migration)
+	return mapB, nil
+}
+
+/* Rich query section */
+
+//Record found by a rich query
+type Query_Result struct {
+	Key 	string
+	Value 	[]byte
+}
+
+//Stubs on CouchDB state answer Mango queries - the contract package gives its stub this. Queries of stubs without it
+//(the legacy shim), or whose state database can't run them (LevelDB, ErrNoRichQuery), read every record of the
+//Assemblies / Packages index instead
+type RichQueryStub interface {
+	QueryState(query string) ([]Query_Result, error)
+}
+
+//Returned by a RichQueryStub on a state database without rich queries. Any other error of the query fails the call
+var ErrNoRichQuery = errors.New("Rich queries are not supported by the state database")
+
+//Fields of the shipped indexes by name (chaincode/v2/META-INF/statedb/couchdb/indexes), in index order - the
+//filtered field first, then the date the results are sorted by
+var richQueryIndexes = map[string][]string{
+	"assemblyDate": {"assemblyDate"},
+	"assemblyStatus": {"assemblyStatus", "assemblyDate"},
+	"manufacturingPlant": {"manufacturingPlant", "assemblyDate"},
+	"filamentBatchId": {"filamentBatchId", "assemblyDate"},
+	"ledBatchId": {"ledBatchId", "assemblyDate"},
+	"circuitBoardBatchId": {"circuitBoardBatchId", "assemblyDate"},
+	"wireBatchId": {"wireBatchId", "assemblyDate"},
+	"casingBatchId": {"casingBatchId", "assemblyDate"},
+	"adaptorBatchId": {"adaptorBatchId", "assemblyDate"},
+	"stickPodBatchId": {"stickPodBatchId", "assemblyDate"},
+	"packagingDate": {"packagingDate"},
+	"packageStatus": {"packageStatus", "packagingDate"},
+	"holderAssemblyId": {"holderAssemblyId", "packagingDate"},
+	"chargerAssemblyId": {"chargerAssemblyId", "packagingDate"},
+}
+
+//Runs a Mango query using the shipped index _index, sorted on its fields - every index field is added to the selector,
+//as CouchDB only picks an index for a selector covering it
+//false when the query can't run or the records aren't all at the current schema version yet, which the selectors rely on
+func richQuery(stub Stub, selector map[string]interface{}, _index string) ([]Query_Result, bool, error) {
+	richStub, ok := stub.(RichQueryStub)
+	if !ok { return nil, false, nil }
+
+	migration, err := getSchemaMigration(stub)
+	if err != nil { return nil, false, err }
+	if migration.TargetVersion != SCHEMA_VERSION || !migration.Done { return nil, false, nil }
+
+	query := map[string]interface{}{"selector": selector}
+	if fields, ok := richQueryIndexes[_index]; ok {
+		sortFields := []map[string]string{}
+		for _, field := range fields {
+			if _, ok := selector[field]; !ok { selector[field] = map[string]interface{}{"$exists": true} }
+			sortFields = append(sortFields, map[string]string{field: "asc"})
+		}
+		query["sort"] = sortFields
+		query["use_index"] = []string{"_design/" + RICH_QUERY_INDEX_DDOC + _index, _index}
+	}
+	bytesQuery, err := json.Marshal(query)
+	if err != nil { return nil, false, tntError(ERR_CORRUPT_STATE, "", "Error creating rich query") }
+
+	results, err := richStub.QueryState(string(bytesQuery))
+	if err == ErrNoRichQuery { return nil, false, nil }
+	if err != nil { return nil, false, tntError(ERR_CORRUPT_STATE, "", "Rich query failed: " + err.Error()) }
+	return results, true, nil
+}
+
+//Range selector on a date field, the bounds as given by parseDateArg / parseDateBound. Stored dates are YYYYMMDDHHMMSS
+//in UTC, so they compare as strings - callers still filter out older records with dates in other forms
+func dateSelector(_field string, _fromDate int64, _toDate int64) map[string]interface{} {
+	bounds := map[string]interface{}{"$exists": true}
+	if _fromDate > 0 { bounds["$gte"] = fmt.Sprintf("%014d", _fromDate) }
+	if _toDate < math.MaxInt64 { bounds["$lte"] = fmt.Sprintf("%014d", _toDate) }
+	return map[string]interface{}{_field: bounds}
+}
+
+//JSON field of a batch or assembly type argument, e.g. LedBatchId is stored as ledBatchId
+func jsonField(_fieldName string) string {
+	if len(_fieldName) == 0 { return _fieldName }
+	return strings.ToLower(_fieldName[:1]) + _fieldName[1:]
+}
+
+//Assemblies to filter - those matching selector (or listed in _alsoIds) in the order of _index on CouchDB, all of them
+//in the order of the Assemblies index otherwise. Callers still apply their filters, the selector only saves reading the others
+func selectAssemblies(stub Stub, selector map[string]interface{}, _index string, _alsoIds []string) ([]*AssemblyLine, error) {
+	results, ok, err := richQuery(stub, selector, _index)
+	if err != nil { return nil, err }
+
+	if ok {
+		matched := make(map[string]bool)
+		for _, result := range results { matched[result.Key] = true }
+		for _, _assemblyId := range _alsoIds {
+			if matched[_assemblyId] { continue }
+			assemblyAsBytes, err := stub.GetState(_assemblyId)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Assembly")}
+			if assemblyAsBytes == nil { continue }
+			results = append(results, Query_Result{Key: _assemblyId, Value: assemblyAsBytes})
+			matched[_assemblyId] = true
+		}
+	} else {
+		assemblyIds, err := getAssemblyIDs(stub)
+		if err != nil { return nil, err }
+		for _, _assemblyId := range assemblyIds {
+			assemblyAsBytes, err := stub.GetState(_assemblyId)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Assembly")}
+			if assemblyAsBytes == nil { continue }
+			results = append(results, Query_Result{Key: _assemblyId, Value: assemblyAsBytes})
+		}
+	}
+
+	res2E:= []*AssemblyLine{}
+	for _, result := range results {
+		res := new(AssemblyLine)
+		err = json.Unmarshal(result.Value, res)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Assembly record") }
+		res2E = append(res2E, res)
+	}
+	return res2E, nil
+}
+
+//Packages to filter - see selectAssemblies
+func selectPackages(stub Stub, selector map[string]interface{}, _index string) ([]*PackageLine, error) {
+	results, ok, err := richQuery(stub, selector, _index)
+	if err != nil { return nil, err }
+
+	if !ok {
+		caseIds, err := getPackageCaseIDs(stub)
+		if err != nil { return nil, err }
+		for _, _caseId := range caseIds {
+			packageAsBytes, err := stub.GetState(_caseId)
+			if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Failed to get Package")}
+			if packageAsBytes == nil { continue }
+			results = append(results, Query_Result{Key: _caseId, Value: packageAsBytes})
+		}
+	}
+
+	res2E:= []*PackageLine{}
+	for _, result := range results {
+		res := new(PackageLine)
+		err = json.Unmarshal(result.Value, res)
+		if err != nil {	return nil, tntError(ERR_CORRUPT_STATE, "", "Corrupt Package record") }
+		res2E = append(res2E, res)
+	}
+	return res2E, nil
+}
+
+//Assemblies a rework took the batch out of, found on CouchDB - nil when the query can't run
+//Rework records have no index, CouchDB reads them all but the chaincode only gets the matching ones
+func reworkedAssemblyIds(stub Stub, _batchType string, _batchNumber string) ([]string, error) {
+	selector := map[string]interface{}{
+		"reworks": map[string]interface{}{"$elemMatch": map[string]interface{}{"batchType": _batchType, "replacedBatchId": _batchNumber}},
+	}
+	results, ok, err := richQuery(stub, selector, "")
+	if err != nil || !ok { return nil, err }
+
+	res := []string{}
+	for _, result := range results {
+		if !strings.HasPrefix(result.Key, reworkKey("")) { continue }
+		res = append(res, strings.TrimPrefix(result.Key, reworkKey("")))
+	}
+	return res, nil
+}
+
+/* Dry run section */
+
+//Problems found checking an invoke - invokes stop at the first, dry runs report them all
+type Validation_Report struct {
+	Function 	string `json:"function"`
+	Valid 		bool `json:"valid"`
+	Problems 	[]*TnT_Error `json:"problems"`
+	Writes 		[]string `json:"writes,omitempty"` // Keys the invoke would write, when valid
+}
+
+func (r *Validation_Report) fail(_code string, _field string, _message string) {
+	r.Problems = append(r.Problems, &TnT_Error{Code: _code, Message: _message, Field: _field})
+}
+
+func (r *Validation_Report) add(err error) {
+	if tntErr, ok := err.(*TnT_Error); ok { r.Problems = append(r.Problems, tntErr); return }
+	r.fail(ERR_CORRUPT_STATE, "", err.Error())
+}
+
+//First problem found, nil when there is none
+func (r *Validation_Report) err() error {
+	if len(r.Problems) == 0 { return nil }
+	return r.Problems[0]
+}
+
+//Stub for dry runs - writes are kept in memory, where later reads of the run see them, and never reach the ledger
+type dryRunStub struct {
+	Stub
+	writes 		map[string][]byte // nil for a deleted key
+}
+
+func newDryRunStub(stub Stub) *dryRunStub {
+	return &dryRunStub{Stub: stub, writes: map[string][]byte{}}
+}
+
+func (s *dryRunStub) GetState(key string) ([]byte, error) {
+	if value, ok := s.writes[key]; ok { return value, nil }
+	return s.Stub.GetState(key)
+}
+
+func (s *dryRunStub) PutState(key string, value []byte) error {
+	s.writes[key] = value
+	return nil
+}
+
+func (s *dryRunStub) DelState(key string) error {
+	s.writes[key] = nil
+	return nil
+}
+
+func (s *dryRunStub) GetPrivateData(collection string, key string) ([]byte, error) {
+	if value, ok := s.writes["PRIVATE|" + collection + "|" + key]; ok { return value, nil }
+	return getPrivateData(s.Stub, collection, key)
+}
+
+func (s *dryRunStub) PutPrivateData(collection string, key string, value []byte) error {
+	if !hasPrivateData(s.Stub) { return errNoPrivateData() }
+	s.writes["PRIVATE|" + collection + "|" + key] = value
+	return nil
+}
+
+//The submitting identity and the transient map are those of the stub the run is made on
+func (s *dryRunStub) GetCreator() ([]byte, error) {
+	if creatorStub, ok := s.Stub.(creatorStub); ok { return creatorStub.GetCreator() }
+	return nil, nil
+}
+
+func (s *dryRunStub) GetTransient() (map[string][]byte, error) {
+	if transStub, ok := s.Stub.(transientStub); ok { return transStub.GetTransient() }
+	return nil, nil
+}
+
+//Keys written in the run, sorted
+func (s *dryRunStub) keys() []string {
+	res := []string{}
+	for key := range s.writes {
+		res = append(res, key)
+	}
+	sort.Strings(res)
+	return res
+}
+
+//Runs every check of an invoke - arguments, role, its validator and then the invoke itself on a dry run stub
+func (t *TnT) dryRunFunction(stub Stub, _function string, args []string) *Validation_Report {
+	report := &Validation_Report{Function: _function, Problems: []*TnT_Error{}}
+
+	def, ok := functionRegistry[_function]
+	if !ok || !def.Invoke { report.fail(ERR_INVALID_ARGUMENT, "function", "Unknown invoke function " + _function); return report }
+	if err := checkArgCount(args, def.argCounts()...); err != nil { report.add(err); return report }
+	if err := def.checkTransientArgs(args); err != nil { report.add(err) }
+
+	if err := def.checkUser(t, stub, args); err != nil { report.add(err) }
+	if def.validate != nil { def.validate(t, stub, args, report) }
+
+	//The invoke may still fail on checks of its own, e.g. in functions without a validator
+	if len(report.Problems) == 0 {
+		dryStub := newDryRunStub(stub)
+		_, err := def.handler(t, dryStub, args)
+		if err != nil {
+			report.add(err)
+		} else {
+			report.Writes = dryStub.keys()
+		}
+	}
+	report.Valid = len(report.Problems) == 0
+	return report
+}
+
+//Dry run of any invoke - reports all problems found without writing state
+//"args": ["createAssembly", "[\"ASM0101\",\"DEV0101\",...,\"aluser1\"]"]
+func (t *TnT) dryRun(stub Stub, args []string) ([]byte, error) {
+	_function := args[0]
+	var _args []string
+	err := json.Unmarshal([]byte(args[1]), &_args)
+	if err != nil { return nil, tntError(ERR_INVALID_ARGUMENT, "args", "Args must be a JSON array of strings") }
+
+	mapB, _ := json.Marshal(t.dryRunFunction(stub, _function, _args))
+	return mapB, nil
+}
+
+/* Dispatch section */
+
+//Argument of a chaincode function
+type Function_Arg struct {
+	Name 		string `json:"name"`
+	Optional 	bool `json:"optional,omitempty"` // Trailing argument that may be left out
+}
+
+//Chaincode function registered with the dispatcher - listed by the listFunctions query
+type Function_Def struct {
+	Name 		string `json:"name"`
+	Invoke 		bool `json:"invoke"` // false for a query
+	Args 		[]Function_Arg `json:"args"`
+	Transient 	[]string `json:"transient,omitempty"` // Fields read from the transient map - customer PII, kept out of the arguments; an argument of the same name is left empty
+	Roles 		[]string `json:"roles,omitempty"` // Roles allowed to call, any registered user when empty
+	Admin 		bool `json:"admin,omitempty"` // Only ledger administrators may call, see checkAdmin
+	handler 	functionHandler
+	validate 	functionValidator // Checks run before the handler and by dry runs, may be nil
+	call 		functionHandler // handler wrapped in the middleware pipeline
+}
+
+type functionHandler func(t *TnT, stub Stub, args []string) ([]byte, error)
+
+//Validator adds every problem found to the report instead of stopping at the first
+type functionValidator func(t *TnT, stub Stub, args []string, report *Validation_Report)
+
+//Middleware wraps the handler of a function, e.g. to check or log the call
+type functionMiddleware func(def *Function_Def, next functionHandler) functionHandler
+
+//Applied outermost first
+var functionPipeline = []functionMiddleware{logCalls, countCalls, checkArgs, checkRole, checkValid}
+
+var functionRegistry = map[string]*Function_Def{}
+var functionNames []string // Registration order
+
+//Argument list from names - a trailing "?" marks an optional argument
+func fnArgs(names ...string) []Function_Arg {
+	res := []Function_Arg{}
+	for _, name := range names {
+		if strings.HasSuffix(name, "?") {
+			res = append(res, Function_Arg{Name: strings.TrimSuffix(name, "?"), Optional: true})
+		} else {
+			res = append(res, Function_Arg{Name: name})
+		}
+	}
+	return res
+}
+
+func registerFunction(def *Function_Def) {
+	def.call = def.handler
+	for i := len(functionPipeline) - 1; i >= 0; i-- {
+		def.call = functionPipeline[i](def, def.call)
+	}
+	functionRegistry[def.Name] = def
+	functionNames = append(functionNames, def.Name)
+}
+
+//Index of the calling user argument, -1 if the function has none
+func (def *Function_Def) userArg() int {
+	for i, arg := range def.Args {
+		if arg.Name == "user" { return i }
+	}
+	return -1
+}
+
+func logCalls(def *Function_Def, next functionHandler) functionHandler {
+	return func(t *TnT, stub Stub, args []string) ([]byte, error) {
+		fmt.Printf("Function is %s", def.Name)
+		bytes, err := next(t, stub, args)
+		if err != nil { fmt.Printf("Function %s failed: %s", def.Name, err.Error()) }
+		return bytes, err
+	}
+}
+
+//Argument counts allowed by the declared arguments
+func (def *Function_Def) argCounts() []int {
+	var counts []int
+	_required := 0
+	for _, arg := range def.Args {
+		if !arg.Optional { _required++ }
+	}
+	for count := _required; count <= len(def.Args); count++ {
+		counts = append(counts, count)
+	}
+	return counts
+}
+
+//Checks the calling user is registered and has one of the declared roles, and the caller administers the ledger where
+//the function needs it
+func (def *Function_Def) checkUser(t *TnT, stub Stub, args []string) error {
+	if def.Admin {
+		if err := checkAdmin(stub); err != nil { return err }
+	}
+
+	_userArg := def.userArg()
+	if _userArg < 0 { return nil }
+
+	user_name, err := callingUser(stub, args, _userArg)
+	if err != nil { return err }
+	if len(user_name) == 0 { return tntError(ERR_INVALID_ARGUMENT, "user", "User name supplied as empty") }
+
+	ecert_role, err := t.get_ecert(stub, user_name)
+	if err != nil {return tntError(ERR_CORRUPT_STATE, "", "userrole couldn't be retrieved")}
+	if ecert_role == nil {return tntError(ERR_FORBIDDEN, "user", "username not defined")}
+
+	if len(def.Roles) == 0 { return nil }
+	user_role := string(ecert_role)
+	for _, role := range def.Roles {
+		if user_role == role { return nil }
+	}
+	return tntError(ERR_FORBIDDEN, "user", "Permission denied, " + def.Name + " needs role " + strings.Join(def.Roles, " or "))
+}
+
+//Arguments named after a transient field are the slots the customer PII had before it moved to the transient map -
+//they are kept so clients keep their argument positions, and must be left empty
+func (def *Function_Def) checkTransientArgs(args []string) error {
+	for i, arg := range def.Args {
+		if i >= len(args) || len(args[i]) == 0 { continue }
+		for _, _field := range def.Transient {
+			if arg.Name == _field { return tntError(ERR_INVALID_ARGUMENT, _field, _field + " is taken from the transient map only, leave its argument empty") }
+		}
+	}
+	return nil
+}
+
+//Checks the argument count against the declared arguments, and that the transient slots are empty
+func checkArgs(def *Function_Def, next functionHandler) functionHandler {
+	counts := def.argCounts()
+	return func(t *TnT, stub Stub, args []string) ([]byte, error) {
+		if err := checkArgCount(args, counts...); err != nil { return nil, err }
+		if err := def.checkTransientArgs(args); err != nil { return nil, err }
+		return next(t, stub, args)
+	}
+}
+
+func checkRole(def *Function_Def, next functionHandler) functionHandler {
+	if def.userArg() < 0 && !def.Admin { return next }
+	return func(t *TnT, stub Stub, args []string) ([]byte, error) {
+		if err := def.checkUser(t, stub, args); err != nil { return nil, err }
+		return next(t, stub, args)
+	}
+}
+
+//Runs the declared validator and fails with the first problem found
+func checkValid(def *Function_Def, next functionHandler) functionHandler {
+	if def.validate == nil { return next }
+	return func(t *TnT, stub Stub, args []string) ([]byte, error) {
+		report := &Validation_Report{Function: def.Name}
+		def.validate(t, stub, args, report)
+		if err := report.err(); err != nil { return nil, err }
+		return next(t, stub, args)
+	}
+}
+
+//Call counts and durations of a function since the peer started - not part of the ledger
+type Function_Metrics struct {
+	Name 			string `json:"name"`
+	Calls 			int64 `json:"calls"`
+	Errors 			int64 `json:"errors"`
+	TotalMillis 	float64 `json:"totalMillis"`
+	MaxMillis 		float64 `json:"maxMillis"`
+}
+
+var functionMetrics = map[string]*Function_Metrics{}
+var functionMetricsLock sync.Mutex
+
+func countCalls(def *Function_Def, next functionHandler) functionHandler {
+	return func(t *TnT, stub Stub, args []string) ([]byte, error) {
+		_start := time.Now()
+		bytes, err := next(t, stub, args)
+		_millis := float64(time.Since(_start)) / float64(time.Millisecond)
+
+		functionMetricsLock.Lock()
+		defer functionMetricsLock.Unlock()
+		metrics, ok := functionMetrics[def.Name]
+		if !ok {
+			metrics = &Function_Metrics{Name: def.Name}
+			functionMetrics[def.Name] = metrics
+		}
+		metrics.Calls++
+		if err != nil { metrics.Errors++ }
+		metrics.TotalMillis += _millis
+		if _millis > metrics.MaxMillis { metrics.MaxMillis = _millis }
+		return bytes, err
+	}
+}
+
+//List the registered functions with their arguments and roles, for client discovery
+func (t *TnT) listFunctions(stub Stub, args []string) ([]byte, error) {
+	res2E := []*Function_Def{}
+	for _, name := range functionNames {
+		res2E = append(res2E, functionRegistry[name])
+	}
+	mapB, _ := json.Marshal(res2E)
+	return mapB, nil
+}
+
+//Call counts of the functions on the queried peer, in registration order
+func (t *TnT) getFunctionMetrics(stub Stub, args []string) ([]byte, error) {
+	functionMetricsLock.Lock()
+	defer functionMetricsLock.Unlock()
+	res2E := []Function_Metrics{}
+	for _, name := range functionNames {
+		if metrics, ok := functionMetrics[name]; ok { res2E = append(res2E, *metrics) }
+	}
+	mapB, _ := json.Marshal(res2E)
+	return mapB, nil
+}
+
+var assemblyArgs = fnArgs("assemblyId", "deviceSerialNo", "deviceType", "filamentBatchId", "ledBatchId", "circuitBoardBatchId", "wireBatchId", "casingBatchId", "adaptorBatchId", "stickPodBatchId", "manufacturingPlant", "assemblyStatus", "assemblyDate", "assemblyPackage", "assemblyInfo1", "assemblyInfo2", "user")
+var packageArgs = fnArgs("caseId", "holderAssemblyId", "chargerAssemblyId", "packageStatus", "packagingDate", "shippingToAddress", "assemblyStatus", "packageInfo1", "packageInfo2", "user")
+var packageTransient = []string{"shippingToAddress", "customerDetailsSalt"}
+var customerDetailsTransient = []string{"customerName", "customerPhone", "customerEmail", "shippingToAddress", "customerDetailsSalt"}
+
+func init() {
+	for _, def := range []*Function_Def{
+		{Name: "createAssembly", Invoke: true, Args: assemblyArgs, Roles: []string{ASSEMBLYLINE_ROLE}, handler: (*TnT).createAssembly, validate: (*TnT).checkCreateAssembly},
+		{Name: "updateAssemblyByID", Invoke: true, Args: assemblyArgs, Roles: []string{ASSEMBLYLINE_ROLE}, handler: (*TnT).updateAssemblyByID, validate: (*TnT).checkUpdateAssembly},
+		{Name: "createPackage", Invoke: true, Args: packageArgs, Transient: packageTransient, Roles: []string{PACKAGELINE_ROLE}, handler: (*TnT).createPackage, validate: (*TnT).checkCreatePackage},
+		{Name: "updatePackage", Invoke: true, Args: packageArgs, Transient: packageTransient, Roles: []string{PACKAGELINE_ROLE}, handler: (*TnT).updatePackage, validate: (*TnT).checkUpdatePackage},
+		{Name: "updateAssemblyInfo2ByID", Invoke: true, Args: fnArgs("assemblyId", "assemblyInfo2", "user"), Roles: []string{ASSEMBLYLINE_ROLE}, handler: (*TnT).updateAssemblyInfo2ByID},
+		{Name: "updatePackageInfo2ById", Invoke: true, Args: fnArgs("caseId", "packageInfo2", "user"), Roles: []string{PACKAGELINE_ROLE}, handler: (*TnT).updatePackageInfo2ById},
+		{Name: "reassignDeviceSerialNo", Invoke: true, Args: fnArgs("assemblyId", "deviceSerialNo", "reason", "user"), Roles: []string{ASSEMBLYLINE_ROLE}, handler: (*TnT).reassignDeviceSerialNo},
+		{Name: "rebuildSerialNoRegistry", Invoke: true, Args: fnArgs("user"), Roles: []string{ASSEMBLYLINE_ROLE}, handler: (*TnT).rebuildSerialNoRegistry},
+		{Name: "recallBatch", Invoke: true, Args: fnArgs("batchType", "batchNumber", "recallReason", "user"), Roles: []string{ASSEMBLYLINE_ROLE}, handler: (*TnT).recallBatch},
+		{Name: "createQAInspection", Invoke: true, Args: fnArgs("assemblyId", "testStation", "measuredValues", "inspectionResult", "defectCodes", "inspectionDate", "user"), Roles: []string{QA_INSPECTOR_ROLE}, handler: (*TnT).createQAInspection},
+		{Name: "reworkAssembly", Invoke: true, Args: fnArgs("assemblyId", "replacementBatches", "reworkReason", "user"), Roles: []string{ASSEMBLYLINE_ROLE}, handler: (*TnT).reworkAssembly},
+		{Name: "createRMA", Invoke: true, Args: fnArgs("rmaId", "caseId", "deviceSerialNo", "returnReason", "user"), Roles: []string{PACKAGELINE_ROLE}, handler: (*TnT).createRMA},
+		{Name: "updateRMAStatus", Invoke: true, Args: fnArgs("rmaId", "rmaStatus", "replacementCaseId", "comment", "user"), Roles: []string{PACKAGELINE_ROLE}, handler: (*TnT).updateRMAStatus},
+		{Name: "breakRMACase", Invoke: true, Args: fnArgs("rmaId", "user"), Roles: []string{PACKAGELINE_ROLE}, handler: (*TnT).breakRMACase},
+		{Name: "createShipment", Invoke: true, Args: fnArgs("shipmentId", "caseIds", "carrier", "trackingNumber", "originPlant", "destination", "user"), Roles: []string{PACKAGELINE_ROLE}, handler: (*TnT).createShipment},
+		{Name: "handOverShipment", Invoke: true, Args: fnArgs("shipmentId", "toUser", "location", "user"), Roles: []string{PACKAGELINE_ROLE, CARRIER_ROLE, DISTRIBUTOR_ROLE}, handler: (*TnT).handOverShipment},
+		{Name: "acceptShipment", Invoke: true, Args: fnArgs("shipmentId", "location", "user"), Roles: []string{CARRIER_ROLE, DISTRIBUTOR_ROLE, RETAILER_ROLE}, handler: (*TnT).acceptShipment},
+		{Name: "updatePackageCustomerDetails", Invoke: true, Args: fnArgs("caseId", "customerName", "customerPhone", "customerEmail", "shippingToAddress", "user"), Transient: customerDetailsTransient, Roles: []string{PACKAGELINE_ROLE}, handler: (*TnT).updatePackageCustomerDetails},
+		{Name: "privatisePackageAddresses", Invoke: true, Args: fnArgs("user"), Transient: []string{"customerDetailsSalt"}, Roles: []string{PACKAGELINE_ROLE}, handler: (*TnT).privatisePackageAddresses},
+		{Name: "cancelAssembly", Invoke: true, Args: fnArgs("assemblyId", "reasonCode", "comment", "user"), Roles: []string{ASSEMBLYLINE_ROLE}, handler: (*TnT).cancelAssembly},
+		{Name: "cancelPackage", Invoke: true, Args: fnArgs("caseId", "reasonCode", "comment", "user"), Roles: []string{PACKAGELINE_ROLE}, handler: (*TnT).cancelPackage},
+		{Name: "receiveComponentBatch", Invoke: true, Args: fnArgs("batchType", "batchNo", "quantity", "receivedDate", "user"), Roles: []string{ASSEMBLYLINE_ROLE}, handler: (*TnT).receiveComponentBatch},
+		{Name: "scrapAssembly", Invoke: true, Args: fnArgs("assemblyId", "method", "witness", "scrapDate", "comment", "user"), Roles: []string{ASSEMBLYLINE_ROLE}, handler: (*TnT).scrapAssembly},
+		{Name: "scrapComponentBatch", Invoke: true, Args: fnArgs("batchType", "batchNo", "quantity", "method", "witness", "scrapDate", "comment", "user"), Roles: []string{ASSEMBLYLINE_ROLE}, handler: (*TnT).scrapComponentBatch},
+		{Name: "setPlantTimeZone", Invoke: true, Args: fnArgs("plant", "timeZone", "user"), Roles: []string{ASSEMBLYLINE_ROLE}, handler: (*TnT).setPlantTimeZone},
+		{Name: "registerUser", Invoke: true, Args: fnArgs("name", "role", "identity"), Admin: true, handler: (*TnT).registerUser},
+		{Name: "migrateSchema", Invoke: true, Args: fnArgs("pageSize", "user"), Transient: []string{"customerDetailsSalt"}, Roles: []string{ASSEMBLYLINE_ROLE, PACKAGELINE_ROLE}, handler: (*TnT).migrateSchema},
+		{Name: "getAssemblyByID", Args: fnArgs("assemblyId", "user"), Roles: []string{ASSEMBLYLINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getAssemblyByID},
+		{Name: "getPackageByID", Args: fnArgs("caseId"), handler: (*TnT).getPackageByID},
+		{Name: "getAllAssemblies", Args: fnArgs("user", "includeCancelled?"), Roles: []string{ASSEMBLYLINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getAllAssemblies},
+		{Name: "getAllPackages", Args: fnArgs("user", "includeCancelled?"), Roles: []string{PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getAllPackages},
+		{Name: "getAllAssemblyIDs", Args: fnArgs(), handler: (*TnT).getAllAssemblyIDs},
+		{Name: "getAllPackageCaseIDs", Args: fnArgs(), handler: (*TnT).getAllPackageCaseIDs},
+		{Name: "validateCreateAssembly", Args: assemblyArgs, Roles: []string{ASSEMBLYLINE_ROLE}, handler: (*TnT).validateCreateAssembly},
+		{Name: "validateUpdateAssembly", Args: assemblyArgs, Roles: []string{ASSEMBLYLINE_ROLE}, handler: (*TnT).validateUpdateAssembly},
+		{Name: "validateCreatePackage", Args: packageArgs, Transient: packageTransient, Roles: []string{PACKAGELINE_ROLE}, handler: (*TnT).validateCreatePackage},
+		{Name: "validateUpdatePackage", Args: packageArgs, Transient: packageTransient, Roles: []string{PACKAGELINE_ROLE}, handler: (*TnT).validateUpdatePackage},
+		{Name: "dryRun", Args: fnArgs("function", "args"), handler: (*TnT).dryRun},
+		{Name: "getAssemblyLineHistoryByID", Args: fnArgs("assemblyId", "user"), Roles: []string{ASSEMBLYLINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getAssemblyLineHistoryByID},
+		{Name: "getPackageLineHistoryByID", Args: fnArgs("caseId", "user"), Roles: []string{PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getPackageLineHistoryByID},
+		{Name: "getAssembliesByBatchNumber", Args: fnArgs("batchType", "batchNumber", "user", "includeCancelled?"), Roles: []string{ASSEMBLYLINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getAssembliesByBatchNumber},
+		{Name: "getAssembliesByDate", Args: fnArgs("fromDate", "toDate", "user", "includeCancelled?"), Roles: []string{ASSEMBLYLINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getAssembliesByDate},
+		{Name: "getAssembliesHistoryByDate", Args: fnArgs("fromDate", "toDate", "user", "includeCancelled?"), Roles: []string{ASSEMBLYLINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getAssembliesHistoryByDate},
+		{Name: "getAssembliesByBatchNumberAndByDate", Args: fnArgs("batchType", "batchNumber", "fromDate", "toDate", "user", "includeCancelled?"), Roles: []string{ASSEMBLYLINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getAssembliesByBatchNumberAndByDate},
+		{Name: "getAssembliesHistoryByBatchNumberAndByDate", Args: fnArgs("batchType", "batchNumber", "fromDate", "toDate", "user", "includeCancelled?"), Roles: []string{ASSEMBLYLINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getAssembliesHistoryByBatchNumberAndByDate},
+		{Name: "getPackagesByAssemblyId", Args: fnArgs("assemblyType", "assemblyId", "user", "includeCancelled?"), Roles: []string{PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getPackagesByAssemblyId},
+		{Name: "getPackagesByDate", Args: fnArgs("fromDate", "toDate", "user", "includeCancelled?"), Roles: []string{PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getPackagesByDate},
+		{Name: "getPackageByAssemblyIdAndByDate", Args: fnArgs("assemblyType", "assemblyId", "fromDate", "toDate", "user", "includeCancelled?"), Roles: []string{PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getPackageByAssemblyIdAndByDate},
+		{Name: "getPackagesHistoryByDate", Args: fnArgs("fromDate", "toDate", "user", "includeCancelled?"), Roles: []string{PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getPackagesHistoryByDate},
+		{Name: "verifyAssembly", Args: fnArgs("assemblyId", "assemblyRecord", "user"), Roles: []string{ASSEMBLYLINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).verifyAssembly},
+		{Name: "verifyPackage", Args: fnArgs("caseId", "packageRecord", "user"), Roles: []string{PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).verifyPackage},
+		{Name: "getPackageInclusionProof", Args: fnArgs("caseId", "deviceSerialNo", "user"), Roles: []string{PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getPackageInclusionProof},
+		{Name: "getAssembliesBySerialNo", Args: fnArgs("deviceSerialNo", "deviceType", "user", "includeCancelled?"), Roles: []string{ASSEMBLYLINE_ROLE, PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getAssembliesBySerialNo},
+		{Name: "getSerialNoHistoryByID", Args: fnArgs("assemblyId", "user"), Roles: []string{ASSEMBLYLINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getSerialNoHistoryByID},
+		{Name: "getProductAuthenticity", Args: fnArgs("deviceSerialNo", "user"), Roles: []string{CONSUMER_ROLE, ASSEMBLYLINE_ROLE, PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getProductAuthenticity},
+		{Name: "getQAInspectionsByAssemblyID", Args: fnArgs("assemblyId", "user"), Roles: []string{ASSEMBLYLINE_ROLE, QA_INSPECTOR_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getQAInspectionsByAssemblyID},
+		{Name: "getDefectRatesByBatch", Args: fnArgs("batchType", "user"), Roles: []string{ASSEMBLYLINE_ROLE, QA_INSPECTOR_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getDefectRatesByBatch},
+		{Name: "getDefectRatesByPlant", Args: fnArgs("user"), Roles: []string{ASSEMBLYLINE_ROLE, QA_INSPECTOR_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getDefectRatesByPlant},
+		{Name: "getReworksByAssemblyID", Args: fnArgs("assemblyId", "user"), Roles: []string{ASSEMBLYLINE_ROLE, QA_INSPECTOR_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getReworksByAssemblyID},
+		{Name: "getRMAByID", Args: fnArgs("rmaId", "user"), Roles: []string{PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getRMAByID},
+		{Name: "getAllRMAs", Args: fnArgs("user"), Roles: []string{PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getAllRMAs},
+		{Name: "getReturnRatesByBatch", Args: fnArgs("batchType", "user"), Roles: []string{PACKAGELINE_ROLE, ASSEMBLYLINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getReturnRatesByBatch},
+		{Name: "getReturnRatesByPlant", Args: fnArgs("user"), Roles: []string{PACKAGELINE_ROLE, ASSEMBLYLINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getReturnRatesByPlant},
+		{Name: "getShipmentByID", Args: fnArgs("shipmentId", "user"), Roles: []string{PACKAGELINE_ROLE, CARRIER_ROLE, DISTRIBUTOR_ROLE, RETAILER_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getShipmentByID},
+		{Name: "getCustodyChainByCaseId", Args: fnArgs("caseId", "user"), Roles: []string{PACKAGELINE_ROLE, CARRIER_ROLE, DISTRIBUTOR_ROLE, RETAILER_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getCustodyChainByCaseId},
+		{Name: "getPackageCustomerDetails", Args: fnArgs("caseId", "user?"), Roles: []string{PACKAGELINE_ROLE, DISTRIBUTOR_ROLE, RETAILER_ROLE}, handler: (*TnT).getPackageCustomerDetails},
+		{Name: "getCancellationByID", Args: fnArgs("id", "user"), Roles: []string{ASSEMBLYLINE_ROLE, PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getCancellationByID},
+		{Name: "getScrapByAssemblyID", Args: fnArgs("assemblyId", "user"), Roles: []string{ASSEMBLYLINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getScrapByAssemblyID},
+		{Name: "getBatchReconciliation", Args: fnArgs("batchType", "batchNo", "user"), Roles: []string{ASSEMBLYLINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getBatchReconciliation},
+		{Name: "getAssemblyCounts", Args: fnArgs("groupBy", "fromDate", "toDate", "user", "includeCancelled?"), Roles: []string{ASSEMBLYLINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getAssemblyCounts},
+		{Name: "getPackageCounts", Args: fnArgs("groupBy", "fromDate", "toDate", "user", "includeCancelled?"), Roles: []string{PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getPackageCounts},
+		{Name: "getCycleTimes", Args: fnArgs("groupBy", "fromDate", "toDate", "user", "includeCancelled?"), Roles: []string{ASSEMBLYLINE_ROLE, PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getCycleTimes},
+		{Name: "getStuckAssemblies", Args: fnArgs("status", "thresholdHours", "user"), Roles: []string{ASSEMBLYLINE_ROLE, PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getStuckAssemblies},
+		{Name: "exportAssembliesCSV", Args: fnArgs("fields", "bookmark", "pageSize", "user", "includeCancelled?"), Roles: []string{ASSEMBLYLINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).exportAssembliesCSV},
+		{Name: "exportPackagesCSV", Args: fnArgs("fields", "bookmark", "pageSize", "user", "includeCancelled?"), Roles: []string{PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).exportPackagesCSV},
+		{Name: "exportAssembliesHistoryCSV", Args: fnArgs("fields", "fromDate", "toDate", "bookmark", "pageSize", "user", "includeCancelled?"), Roles: []string{ASSEMBLYLINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).exportAssembliesHistoryCSV},
+		{Name: "exportPackagesHistoryCSV", Args: fnArgs("fields", "fromDate", "toDate", "bookmark", "pageSize", "user", "includeCancelled?"), Roles: []string{PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).exportPackagesHistoryCSV},
+		{Name: "listFunctions", Args: fnArgs(), handler: (*TnT).listFunctions},
+		{Name: "getPlantTimeZones", Args: fnArgs("user"), Roles: []string{ASSEMBLYLINE_ROLE, PACKAGELINE_ROLE, QA_VIEWER_ROLE}, handler: (*TnT).getPlantTimeZones},
+		{Name: "getSchemaMigration", Args: fnArgs("user"), Roles: []string{ASSEMBLYLINE_ROLE, PACKAGELINE_ROLE}, handler: (*TnT).getSchemaMigration},
+		{Name: "getFunctionMetrics", Args: fnArgs(), handler: (*TnT).getFunctionMetrics},
+		{Name: "getCallerIdentity", Args: fnArgs(), handler: (*TnT).getCallerIdentity},
+	} {
+		registerFunction(def)
+	}
+}
+
+
+/*Standard Calls*/
+
+//Creates the Assemblies and Packages holders when absent and registers the default users - run by the peer only, on
+//instantiate and upgrade of the legacy chaincode (chaincode/legacy). Entry points any client reaches use InitNewLedger
+//Parameters = USERNAME, ROLE, USERNAME, ROLE...
+func (t *TnT) InitLedger(stub Stub, args []string) ([]byte, error) {
+	if len(args) % 2 != 0 { return nil, tntError(ERR_INVALID_ARGUMENT, "args", "Users must be given as USERNAME, ROLE pairs") }
+
+	/* GetAll changes-------------------------starts--------------------------*/
+
+	//An upgrade keeps the existing records, older schema versions are moved on by migrateSchema
+	bytesAssembly, err := stub.GetState("Assemblies")
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+	if bytesAssembly == nil {
+		var assemID_Holder AssemblyID_Holder
+		assemID_Holder.SchemaVersion = SCHEMA_VERSION
+		bytesAssembly, err = json.Marshal(assemID_Holder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating assemID_Holder record") }
+		err = stub.PutState("Assemblies", bytesAssembly)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+
+		//A new ledger has no records to migrate
+		bytesMigration, err := json.Marshal(Schema_Migration{TargetVersion: SCHEMA_VERSION, Done: true, SchemaVersion: SCHEMA_VERSION})
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating Schema migration record") }
+		err = stub.PutState("SchemaMigration", bytesMigration)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+	}
+
+	bytesPackage, err := stub.GetState("Packages")
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Packages") }
+	if bytesPackage == nil {
+		var packageCaseID_Holder PackageCaseID_Holder
+		packageCaseID_Holder.SchemaVersion = SCHEMA_VERSION
+		bytesPackage, err = json.Marshal(packageCaseID_Holder)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Error creating packageCaseID_Holder record") }
+		err = stub.PutState("Packages", bytesPackage)
+		if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to put the state") }
+	}
+	
+	/* GetAll changes---------------------------ends------------------------ */
+
+	// creating minimum default user and roles
+	//"AssemblyLine_User1","assemblyline_role","PackageLine_User1", "packageline_role","Consumer_User1", "consumer_role"
+	for i:=0; i < len(args); i=i+2 {
+		_, err = t.add_ecert(stub, args[i], args[i+1])
+		if err != nil { return nil, err }
+	}
+
+	return nil, nil
+
+}
+
+//Sets up a ledger that isn't set up yet, see InitLedger. Only a ledger administrator can, and only once, later users are
+//registered with registerUser - for entry points any client can reach, as the contract API's InitLedger
+func (t *TnT) InitNewLedger(stub Stub, args []string) ([]byte, error) {
+	if err := checkAdmin(stub); err != nil { return nil, err }
+
+	bytesAssembly, err := stub.GetState("Assemblies")
+	if err != nil { return nil, tntError(ERR_CORRUPT_STATE, "", "Unable to get Assemblies") }
+	if bytesAssembly != nil { return nil, tntError(ERR_ALREADY_EXISTS, "", "Ledger is set up already, users are registered through it only once") }
+
+	return t.InitLedger(stub, args)
+}
+
+//Runs a registered function, invoke or query alike - the entry point for the Fabric contract API, where the client
+//decides between submitting and evaluating a transaction. Ledger setup is not a function, see InitLedger
+func (t *TnT) Call(stub Stub, function string, args []string) ([]byte, error) {
+	def, ok := functionRegistry[function]
+	if !ok { return nil, tntError(ERR_INVALID_ARGUMENT, "function", "Received unknown function " + function) }
+	return def.call(t, stub, args)
+}
+
+//Index of the calling user argument of function, -1 if it has none or isn't registered - for entry points submitting as
+//the user, as the mock client does
+func UserArgument(function string) int {
+	def, ok := functionRegistry[function]
+	if !ok { return -1 }
+	return def.userArg()
+}
+
+//Whether function is an invoke, and whether it is registered at all - for entry points telling transactions from
+//queries, as the legacy shim does
+func IsInvokeFunction(function string) (bool, bool) {
+	def, ok := functionRegistry[function]
+	if !ok { return false, false }
+	return def.Invoke, true
+}
@@ -0,0 +1,1003 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package tnt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// memStub is an in memory ledger with a private data collection, and the transient map of the calls made on it
+type memStub struct {
+	state     map[string][]byte
+	private   map[string][]byte
+	transient map[string][]byte
+	admin     bool // Calls are made by a ledger administrator
+}
+
+func (s *memStub) GetState(key string) ([]byte, error) { return s.state[key], nil }
+
+func (s *memStub) PutState(key string, value []byte) error {
+	s.state[key] = value
+	return nil
+}
+
+func (s *memStub) DelState(key string) error {
+	delete(s.state, key)
+	return nil
+}
+
+func (s *memStub) GetPrivateData(collection string, key string) ([]byte, error) {
+	return s.private[collection+"/"+key], nil
+}
+
+func (s *memStub) PutPrivateData(collection string, key string, value []byte) error {
+	s.private[collection+"/"+key] = value
+	return nil
+}
+
+func (s *memStub) GetTransient() (map[string][]byte, error) { return s.transient, nil }
+
+func (s *memStub) IsAdmin() (bool, error) { return s.admin, nil }
+
+// legacyStub is the ledger as the legacy shim has it, without private data or a transient map
+type legacyStub struct {
+	Stub
+}
+
+// identityStub adds the identity submitting the transaction
+type identityStub struct {
+	*memStub
+	creator string
+}
+
+func (s identityStub) GetCreator() ([]byte, error) { return []byte(s.creator), nil }
+
+// failingStub fails the writes of key
+type failingStub struct {
+	*memStub
+	key string
+}
+
+func (s failingStub) PutState(key string, value []byte) error {
+	if key == s.key {
+		return errors.New("disk full")
+	}
+	return s.memStub.PutState(key, value)
+}
+
+// richStub answers the Mango queries the chaincode runs, as CouchDB does, and counts the reads of each key
+type richStub struct {
+	*memStub
+	reads    map[string]int
+	queryErr error
+}
+
+func (s richStub) GetState(key string) ([]byte, error) {
+	s.reads[key]++
+	return s.memStub.GetState(key)
+}
+
+func (s richStub) QueryState(query string) ([]Query_Result, error) {
+	if s.queryErr != nil {
+		return nil, s.queryErr
+	}
+	var q struct {
+		Selector map[string]interface{} `json:"selector"`
+		Sort     []map[string]string    `json:"sort"`
+	}
+	if err := json.Unmarshal([]byte(query), &q); err != nil {
+		return nil, err
+	}
+	var results []Query_Result
+	docs := map[string]map[string]interface{}{}
+	for key, value := range s.state {
+		var doc map[string]interface{}
+		if json.Unmarshal(value, &doc) == nil && mangoMatch(doc, q.Selector) {
+			results = append(results, Query_Result{Key: key, Value: value})
+			docs[key] = doc
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		for _, field := range q.Sort {
+			for name := range field {
+				a, b := fmt.Sprint(docs[results[i].Key][name]), fmt.Sprint(docs[results[j].Key][name])
+				if a != b {
+					return a < b
+				}
+			}
+		}
+		return results[i].Key < results[j].Key
+	})
+	return results, nil
+}
+
+// mangoMatch matches doc against the selector operators the chaincode uses
+func mangoMatch(doc map[string]interface{}, selector map[string]interface{}) bool {
+	for field, cond := range selector {
+		value, found := doc[field]
+		ops, isOps := cond.(map[string]interface{})
+		if !isOps {
+			if !found || !reflect.DeepEqual(value, cond) {
+				return false
+			}
+			continue
+		}
+		for op, arg := range ops {
+			switch op {
+			case "$exists":
+				if found != arg.(bool) {
+					return false
+				}
+			case "$gte":
+				if !found || fmt.Sprint(value) < arg.(string) {
+					return false
+				}
+			case "$lte":
+				if !found || fmt.Sprint(value) > arg.(string) {
+					return false
+				}
+			case "$elemMatch":
+				elems, _ := value.([]interface{})
+				matched := false
+				for _, elem := range elems {
+					if elemDoc, ok := elem.(map[string]interface{}); ok && mangoMatch(elemDoc, arg.(map[string]interface{})) {
+						matched = true
+					}
+				}
+				if !matched {
+					return false
+				}
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+type testLedger struct {
+	t    *testing.T
+	cc   *TnT
+	stub *memStub
+}
+
+const testAddress = `{"line1":"1 Main St","city":"Kolkata","postalCode":"700001","country":"IN"}`
+const testSalt = "test salt, 16 bytes or more"
+
+func newTestLedger(t *testing.T) *testLedger {
+	l := &testLedger{t: t, cc: new(TnT), stub: &memStub{
+		state:     map[string][]byte{},
+		private:   map[string][]byte{},
+		transient: map[string][]byte{"shippingToAddress": []byte(testAddress), "customerDetailsSalt": []byte(testSalt)},
+	}}
+	_, err := l.cc.InitLedger(l.stub, []string{
+		"al", ASSEMBLYLINE_ROLE,
+		"pl", PACKAGELINE_ROLE,
+		"qa", QA_INSPECTOR_ROLE,
+		"viewer", QA_VIEWER_ROLE,
+		"carrier", CARRIER_ROLE,
+		"dist", DISTRIBUTOR_ROLE,
+		"shop", RETAILER_ROLE,
+	})
+	if err != nil {
+		t.Fatalf("initLedger: %v", err)
+	}
+	l.stub.admin = true
+	for _, user := range []string{"al", "pl", "qa", "viewer", "carrier", "dist", "shop"} {
+		l.mustCall("registerUser", user, string(l.stub.state[user]), identityOf(user))
+	}
+	l.stub.admin = false
+	return l
+}
+
+// certOf is the certificate user submits with on the test ledger
+func certOf(user string) string { return "cert-" + user }
+
+// identityOf is the identity of user's certificate, see creatorIdentity
+func identityOf(user string) string {
+	identity, _ := creatorIdentity(identityStub{nil, certOf(user)})
+	return identity
+}
+
+// call runs function as the user in its arguments, submitting with that user's certificate
+func (l *testLedger) call(function string, args ...string) ([]byte, error) {
+	creator := ""
+	if def, ok := functionRegistry[function]; ok {
+		if i := def.userArg(); i >= 0 && i < len(args) {
+			creator = certOf(args[i])
+		}
+	}
+	return l.cc.Call(identityStub{l.stub, creator}, function, args)
+}
+
+func (l *testLedger) mustCall(function string, args ...string) []byte {
+	l.t.Helper()
+	payload, err := l.call(function, args...)
+	if err != nil {
+		l.t.Fatalf("%s %v: %v", function, args, err)
+	}
+	return payload
+}
+
+// wantCode fails the test unless err is a TnT_Error with code
+func (l *testLedger) wantCode(err error, code string, what string) {
+	l.t.Helper()
+	tntErr, ok := err.(*TnT_Error)
+	if !ok || tntErr.Code != code {
+		l.t.Errorf("%s: err = %v, want %s", what, err, code)
+	}
+}
+
+func (l *testLedger) createAssembly(assemblyId string, status string) {
+	l.t.Helper()
+	l.mustCall("createAssembly", assemblyId, "SN-"+assemblyId, "HOLDER", "F1", "L1", "C1", "W1", "CA1", "AD1", "ST1", "KOL", status, "20170608101500", "", "", "", "al")
+}
+
+func (l *testLedger) packageArgs(caseId string, holderAssemblyId string, packageStatus string, assemblyStatus string) []string {
+	return []string{caseId, holderAssemblyId, "", packageStatus, "20170609000000", "", assemblyStatus, "", "", "pl"}
+}
+
+func (l *testLedger) assembly(assemblyId string) AssemblyLine {
+	l.t.Helper()
+	assem := AssemblyLine{}
+	if err := json.Unmarshal(l.stub.state[assemblyId], &assem); err != nil {
+		l.t.Fatalf("assembly %s: %v", assemblyId, err)
+	}
+	return assem
+}
+
+// setStatus writes the status straight to the ledger, as records from before the checks may have it
+func (l *testLedger) setStatus(assemblyId string, status string) {
+	assem := l.assembly(assemblyId)
+	assem.AssemblyStatus = status
+	l.stub.state[assemblyId], _ = json.Marshal(assem)
+}
+
+func (l *testLedger) inspect(assemblyId string, result string) {
+	l.t.Helper()
+	defectCodes := ""
+	if result == INSPECTION_FAIL {
+		defectCodes = `["D012"]`
+	}
+	l.mustCall("createQAInspection", assemblyId, "STATION01", "", result, defectCodes, "20170608120000", "qa")
+}
+
+// pack creates a ready Assembly and packs it into caseId
+func (l *testLedger) pack(caseId string, assemblyId string) {
+	l.t.Helper()
+	l.createAssembly(assemblyId, ASSEMBLYSTATUS_RFP)
+	l.mustCall("createPackage", l.packageArgs(caseId, assemblyId, PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)...)
+}
+
+// ship sends caseId out of the plant with the carrier
+func (l *testLedger) ship(shipmentId string, caseId string) {
+	l.t.Helper()
+	l.mustCall("createShipment", shipmentId, `["`+caseId+`"]`, "DHL", "TRK1", "KOL", "Retail DC", "pl")
+	l.mustCall("handOverShipment", shipmentId, "carrier", "KOL dock", "pl")
+	l.mustCall("acceptShipment", shipmentId, "KOL dock", "carrier")
+}
+
+func TestQAFailedStatus(t *testing.T) {
+	l := newTestLedger(t)
+
+	_, err := l.call("createAssembly", "A0", "SN-A0", "HOLDER", "F1", "L1", "C1", "W1", "CA1", "AD1", "ST1", "KOL", ASSEMBLYSTATUS_QAF, "20170608101500", "", "", "", "al")
+	l.wantCode(err, ERR_INVALID_TRANSITION, "create as QA Failed")
+
+	// A failed Assembly can't be packed, a pass restores its status
+	l.createAssembly("A1", ASSEMBLYSTATUS_RFP)
+	l.inspect("A1", INSPECTION_FAIL)
+	if status := l.assembly("A1").AssemblyStatus; status != ASSEMBLYSTATUS_QAF {
+		t.Fatalf("status after FAIL = %s, want %s", status, ASSEMBLYSTATUS_QAF)
+	}
+	_, err = l.call("createPackage", l.packageArgs("P1", "A1", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)...)
+	l.wantCode(err, ERR_INVALID_TRANSITION, "pack a QA Failed Assembly")
+	l.inspect("A1", INSPECTION_PASS)
+	if status := l.assembly("A1").AssemblyStatus; status != ASSEMBLYSTATUS_RFP {
+		t.Errorf("status after PASS = %s, want %s", status, ASSEMBLYSTATUS_RFP)
+	}
+
+	// 'QA Failed' without a failed inspection on record, e.g. an imported Assembly
+	l.createAssembly("A2", "1")
+	l.setStatus("A2", ASSEMBLYSTATUS_QAF)
+	l.inspect("A2", INSPECTION_PASS)
+	if status := l.assembly("A2").AssemblyStatus; status != ASSEMBLYSTATUS_RFP {
+		t.Errorf("status after PASS without a failed inspection = %s, want %s", status, ASSEMBLYSTATUS_RFP)
+	}
+}
+
+func TestUpdatePackageAssemblyStatus(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAssembly("A1", ASSEMBLYSTATUS_RFP)
+	l.mustCall("createPackage", l.packageArgs("P1", "A1", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)...)
+
+	for _, status := range []string{ASSEMBLYSTATUS_QAF, ASSEMBLYSTATUS_RFP, ASSEMBLYSTATUS_RET, ASSEMBLYSTATUS_SCR} {
+		_, err := l.call("updatePackage", l.packageArgs("P1", "", PACKAGESTATUS_PKG, status)...)
+		l.wantCode(err, ERR_INVALID_TRANSITION, "updatePackage with assemblyStatus "+status)
+		if got := l.assembly("A1").AssemblyStatus; got != ASSEMBLYSTATUS_PKG {
+			t.Errorf("updatePackage with assemblyStatus %s moved the Assembly to %s", status, got)
+		}
+	}
+	l.mustCall("updatePackage", l.packageArgs("P1", "", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)...)
+}
+
+func TestPackedAssemblyMustBeReady(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAssembly("A1", "1")
+	_, err := l.call("createPackage", l.packageArgs("P1", "A1", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)...)
+	l.wantCode(err, ERR_INVALID_TRANSITION, "pack an Assembly not Ready For Packaging")
+
+	// Rework waiting for QA
+	l.createAssembly("A2", ASSEMBLYSTATUS_RFP)
+	l.inspect("A2", INSPECTION_FAIL)
+	l.mustCall("reworkAssembly", "A2", `{"LedBatchId":"L2"}`, "LED flicker", "al")
+	l.setStatus("A2", ASSEMBLYSTATUS_RFP)
+	_, err = l.call("createPackage", l.packageArgs("P2", "A2", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)...)
+	l.wantCode(err, ERR_INVALID_TRANSITION, "pack a reworked Assembly waiting for QA")
+
+	l.inspect("A2", INSPECTION_PASS)
+	l.mustCall("createPackage", l.packageArgs("P2", "A2", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)...)
+}
+
+func TestCreateAssemblyStatus(t *testing.T) {
+	l := newTestLedger(t)
+	for _, status := range []string{ASSEMBLYSTATUS_QAF, ASSEMBLYSTATUS_CAN, ASSEMBLYSTATUS_SCR} {
+		_, err := l.call("createAssembly", "A1", "SN-A1", "HOLDER", "F1", "L1", "C1", "W1", "CA1", "AD1", "ST1", "KOL", status, "20170608101500", "", "", "", "al")
+		l.wantCode(err, ERR_INVALID_TRANSITION, "createAssembly with status "+status)
+	}
+	l.createAssembly("A1", ASSEMBLYSTATUS_RFP)
+}
+
+func TestDoublePacking(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAssembly("A1", ASSEMBLYSTATUS_RFP)
+	l.createAssembly("A2", ASSEMBLYSTATUS_RFP)
+
+	_, err := l.call("createPackage", "P0", "A1", "A1", PACKAGESTATUS_PKG, "20170609000000", "", ASSEMBLYSTATUS_PKG, "", "", "pl")
+	l.wantCode(err, ERR_INVALID_ARGUMENT, "same Assembly as Holder and Charger")
+
+	l.mustCall("createPackage", l.packageArgs("P1", "A1", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)...)
+	_, err = l.call("createPackage", "P2", "A2", "A1", PACKAGESTATUS_PKG, "20170609000000", "", ASSEMBLYSTATUS_PKG, "", "", "pl")
+	l.wantCode(err, ERR_INVALID_TRANSITION, "pack an Assembly already in a case")
+
+	// Packed with its status set back, as records from before the checks may have it
+	l.setStatus("A1", ASSEMBLYSTATUS_RFP)
+	_, err = l.call("createPackage", l.packageArgs("P2", "A1", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)...)
+	l.wantCode(err, ERR_INVALID_TRANSITION, "pack an Assembly with AssemblyPackage set")
+	if got := l.assembly("A1").AssemblyPackage; got != "P1" {
+		t.Errorf("AssemblyPackage = %q, want P1", got)
+	}
+}
+
+func TestReservedAssemblyStatuses(t *testing.T) {
+	l := newTestLedger(t)
+	for status := range reservedAssemblyStatuses {
+		_, err := l.call("validateCreateAssembly", "A1", "SN-A1", "HOLDER", "F1", "L1", "C1", "W1", "CA1", "AD1", "ST1", "KOL", status, "20170608101500", "", "", "", "al")
+		l.wantCode(err, ERR_INVALID_TRANSITION, "validateCreateAssembly with status "+status)
+	}
+
+	update := func(status string) error {
+		_, err := l.call("updateAssemblyByID", "A1", "SN-A1", "HOLDER", "F1", "L1", "C1", "W1", "CA1", "AD1", "ST1", "KOL", status, "20170608101500", "", "", "", "al")
+		return err
+	}
+	l.createAssembly("A1", "1")
+	for _, status := range []string{ASSEMBLYSTATUS_PKG, ASSEMBLYSTATUS_RET} {
+		l.wantCode(update(status), ERR_INVALID_TRANSITION, "updateAssemblyByID to "+status)
+	}
+
+	if err := update(ASSEMBLYSTATUS_RFP); err != nil {
+		t.Fatalf("updateAssemblyByID to %s: %v", ASSEMBLYSTATUS_RFP, err)
+	}
+	l.mustCall("createPackage", l.packageArgs("P1", "A1", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)...)
+	l.wantCode(update("1"), ERR_INVALID_TRANSITION, "updateAssemblyByID of a packed Assembly")
+}
+
+func TestUpdatePackageStatus(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAssembly("A1", ASSEMBLYSTATUS_RFP)
+	l.mustCall("createPackage", l.packageArgs("P1", "A1", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)...)
+
+	tests := []struct {
+		status string
+		code   string
+	}{
+		{PACKAGESTATUS_CAN, ERR_INVALID_TRANSITION},
+		{PACKAGESTATUS_RET, ERR_INVALID_TRANSITION},
+		{"3", ERR_INVALID_ARGUMENT},
+	}
+	for _, tc := range tests {
+		_, err := l.call("updatePackage", l.packageArgs("P1", "", tc.status, ASSEMBLYSTATUS_PKG)...)
+		l.wantCode(err, tc.code, "updatePackage to status "+tc.status)
+	}
+	l.mustCall("updatePackage", l.packageArgs("P1", "", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)...)
+}
+
+func TestRMA(t *testing.T) {
+	l := newTestLedger(t)
+	l.pack("P1", "A1")
+	l.pack("P2", "A2")
+	l.pack("P3", "A3")
+	l.mustCall("cancelPackage", "P3", CANCEL_DAMAGED, "", "pl")
+
+	_, err := l.call("createRMA", "R1", "P1", "", "Not working", "pl")
+	l.wantCode(err, ERR_INVALID_TRANSITION, "RMA of a Package never shipped")
+	_, err = l.call("createRMA", "R1", "P3", "", "Not working", "pl")
+	l.wantCode(err, ERR_INVALID_TRANSITION, "RMA of a cancelled Package")
+
+	l.ship("S1", "P1")
+	l.mustCall("createRMA", "R1", "P1", "", "Not working", "pl")
+	pack := PackageLine{}
+	json.Unmarshal(l.stub.state["P1"], &pack)
+	if pack.PackageStatus != PACKAGESTATUS_RET || pack.PackageRMAId != "R1" {
+		t.Errorf("Package after createRMA: status %s, RMA %q, want %s, R1", pack.PackageStatus, pack.PackageRMAId, PACKAGESTATUS_RET)
+	}
+	_, err = l.call("updatePackage", l.packageArgs("P1", "", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)...)
+	l.wantCode(err, ERR_INVALID_TRANSITION, "updatePackage of a returned Package")
+
+	rma := RMA_Line{}
+	if err := json.Unmarshal(l.mustCall("getRMAByID", "R1", "pl"), &rma); err != nil || rma.RMAId != "R1" {
+		t.Errorf("getRMAByID R1 = %+v (%v)", rma, err)
+	}
+	for _, id := range []string{"A1", "P1", "nope"} {
+		_, err := l.call("getRMAByID", id, "pl")
+		l.wantCode(err, ERR_NOT_FOUND, "getRMAByID "+id)
+	}
+
+	// P1 shipped and returned, P2 packaged but still at the plant
+	rates := []Return_Rate{}
+	json.Unmarshal(l.mustCall("getReturnRatesByPlant", "pl"), &rates)
+	if len(rates) != 1 || rates[0].AssembliesShipped != 1 || rates[0].AssembliesReturned != 1 {
+		t.Errorf("return rates = %+v, want 1 shipped and 1 returned", rates)
+	}
+}
+
+func TestShipment(t *testing.T) {
+	l := newTestLedger(t)
+	l.pack("P1", "A1")
+	l.pack("P2", "A2")
+	l.mustCall("cancelPackage", "P2", CANCEL_DAMAGED, "", "pl")
+
+	_, err := l.call("createShipment", "S1", `["P1","P2"]`, "DHL", "TRK1", "KOL", "Retail DC", "pl")
+	l.wantCode(err, ERR_INVALID_TRANSITION, "ship a cancelled Package")
+
+	as := func(creator string, function string, args ...string) error {
+		_, err := l.cc.Call(identityStub{l.stub, creator}, function, args)
+		return err
+	}
+	createArgs := []string{"S1", `["P1"]`, "DHL", "TRK1", "KOL", "Retail DC", "pl"}
+	l.wantCode(as(certOf("carrier"), "createShipment", createArgs...), ERR_FORBIDDEN, "create as the plant with the carrier identity")
+	_, err = l.cc.Call(legacyStub{l.stub}, "createShipment", createArgs)
+	l.wantCode(err, ERR_FORBIDDEN, "create without a submitting identity")
+	l.mustCall("createShipment", createArgs...)
+
+	// A user registered without an identity can't take custody
+	l.cc.InitLedger(l.stub, []string{"carrier2", CARRIER_ROLE})
+	_, err = l.call("handOverShipment", "S1", "carrier2", "KOL dock", "pl")
+	l.wantCode(err, ERR_INVALID_ARGUMENT, "hand over to a user without an identity")
+
+	l.wantCode(as(certOf("carrier"), "handOverShipment", "S1", "carrier", "KOL dock", "pl"), ERR_FORBIDDEN, "hand over with another identity than the custodian's")
+	l.mustCall("handOverShipment", "S1", "carrier", "KOL dock", "pl")
+	l.wantCode(as(certOf("pl"), "acceptShipment", "S1", "KOL dock", "carrier"), ERR_FORBIDDEN, "accept with the identity that handed over")
+	l.wantCode(as(certOf("dist"), "acceptShipment", "S1", "KOL dock", "carrier"), ERR_FORBIDDEN, "accept with another user's identity")
+	_, err = l.cc.Call(legacyStub{l.stub}, "acceptShipment", []string{"S1", "KOL dock", "carrier"})
+	l.wantCode(err, ERR_FORBIDDEN, "accept without a submitting identity")
+	l.mustCall("acceptShipment", "S1", "KOL dock", "carrier")
+
+	shipment, _ := getShipment(l.stub, "S1")
+	transfer := shipment.CustodyTransfers[0]
+	if transfer.HandedOverBy != identityOf("pl") || transfer.AcceptedBy != identityOf("carrier") || shipment.CustodianIdentity != identityOf("carrier") {
+		t.Errorf("custody transfer identities not recorded: %+v, custodian %s", transfer, shipment.CustodianIdentity)
+	}
+	l.wantCode(as(certOf("pl"), "handOverShipment", "S1", "dist", "DC", "carrier"), ERR_FORBIDDEN, "hand over as the carrier with the plant identity")
+
+	// A renewed certificate takes over once the administrator binds it
+	l.wantCode(as("renewed-cert-carrier", "handOverShipment", "S1", "dist", "DC", "carrier"), ERR_FORBIDDEN, "hand over with an unregistered certificate")
+	renewed, _ := creatorIdentity(identityStub{nil, "renewed-cert-carrier"})
+	l.stub.admin = true
+	l.mustCall("registerUser", "carrier", CARRIER_ROLE, renewed)
+	l.stub.admin = false
+	if err := as("renewed-cert-carrier", "handOverShipment", "S1", "dist", "DC", "carrier"); err != nil {
+		t.Errorf("hand over with the renewed certificate: %v", err)
+	}
+}
+
+func TestSingleGettersNotFound(t *testing.T) {
+	l := newTestLedger(t)
+	l.pack("P1", "A1")
+
+	tests := []struct {
+		function string
+		args     []string
+	}{
+		{"getAssemblyByID", []string{"A9", "viewer"}},
+		{"getAssemblyByID", []string{"P1", "viewer"}},
+		{"getAssemblyByID", []string{"Assemblies", "viewer"}},
+		{"getPackageByID", []string{"P9"}},
+		{"getPackageByID", []string{"A1"}},
+		{"getAssemblyLineHistoryByID", []string{"A9", "viewer"}},
+		{"getPackageLineHistoryByID", []string{"P9", "viewer"}},
+		{"getShipmentByID", []string{"A1", "viewer"}},
+		{"getRMAByID", []string{"P1", "viewer"}},
+		{"getCancellationByID", []string{"A1", "viewer"}},
+	}
+	for _, tc := range tests {
+		_, err := l.call(tc.function, tc.args...)
+		l.wantCode(err, ERR_NOT_FOUND, tc.function+" of "+tc.args[0])
+	}
+
+	if payload := l.mustCall("getPackageByID", "P1"); !strings.Contains(string(payload), `"caseId":"P1"`) {
+		t.Errorf("getPackageByID(P1) = %s", payload)
+	}
+}
+
+func TestSchemaVersionKeptUntilMigrated(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAssembly("A1", ASSEMBLYSTATUS_RFP)
+	if got := l.assembly("A1").SchemaVersion; got != SCHEMA_VERSION {
+		t.Fatalf("new Assembly at schema version %d, want %d", got, SCHEMA_VERSION)
+	}
+
+	//A record from version 1, on a ledger upgraded from it
+	var stored map[string]interface{}
+	json.Unmarshal(l.stub.state["A1"], &stored)
+	stored["schemaVersion"] = 1
+	delete(stored, "assemblyDateLocal")
+	l.stub.state["A1"], _ = json.Marshal(stored)
+	l.stub.state["SchemaMigration"], _ = json.Marshal(Schema_Migration{TargetVersion: 1, Done: true, SchemaVersion: 1})
+
+	l.mustCall("reassignDeviceSerialNo", "A1", "SN-A1-NEW", "Label misprinted", "al")
+	if assem := l.assembly("A1"); assem.SchemaVersion != 1 || assem.DeviceSerialNo != "SN-A1-NEW" {
+		t.Fatalf("updated Assembly at schema version %d, want it kept at 1", assem.SchemaVersion)
+	}
+
+	var migration Schema_Migration
+	json.Unmarshal(l.mustCall("migrateSchema", "50", "al"), &migration)
+	if !migration.Done || migration.Migrated == 0 {
+		t.Fatalf("migration %+v", migration)
+	}
+	if assem := l.assembly("A1"); assem.SchemaVersion != SCHEMA_VERSION || assem.AssemblyDateLocal == "" {
+		t.Errorf("migrated Assembly at schema version %d with local date %q", assem.SchemaVersion, assem.AssemblyDateLocal)
+	}
+}
+
+func TestRecordKeys(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAssembly("A1", ASSEMBLYSTATUS_RFP)
+	l.createAssembly("A1C", ASSEMBLYSTATUS_RFP)
+	l.createAssembly("A1Q", ASSEMBLYSTATUS_RFP)
+	l.createAssembly("A1H", ASSEMBLYSTATUS_RFP)
+	l.inspect("A1", INSPECTION_PASS)
+	l.mustCall("cancelAssembly", "A1", CANCEL_DAMAGED, "", "al")
+	for _, id := range []string{"A1C", "A1Q", "A1H"} {
+		if assem := l.assembly(id); assem.AssemblyId != id || assem.AssemblyStatus != ASSEMBLYSTATUS_RFP {
+			t.Errorf("Assembly %s overwritten by the records of A1: %+v", id, assem)
+		}
+	}
+	var history AssemblyLine_Holder
+	json.Unmarshal(l.mustCall("getAssemblyLineHistoryByID", "A1", "al"), &history)
+	if len(history.AssemblyLines) != 2 || history.AssemblyLines[0].AssemblyId != "A1" {
+		t.Errorf("history of A1 = %+v", history.AssemblyLines)
+	}
+
+	_, err := l.call("createAssembly", "QA|A2", "SN-QA|A2", "HOLDER", "", "", "", "", "", "", "", "Pune", ASSEMBLYSTATUS_RFP, "20170608101500", "", "", "", "al")
+	l.wantCode(err, ERR_INVALID_ARGUMENT, "create an Assembly whose ID holds the key separator")
+}
+
+func TestRecordKeysMigrated(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAssembly("A1", ASSEMBLYSTATUS_RFP)
+	l.createAssembly("A2", ASSEMBLYSTATUS_RFP)
+	l.createAssembly("A2C", ASSEMBLYSTATUS_RFP)
+	l.inspect("A1", INSPECTION_PASS)
+	l.mustCall("cancelAssembly", "A1", CANCEL_DAMAGED, "", "al")
+
+	//The records of A1 as a version 2 ledger kept them
+	for oldKey, key := range map[string]string{"A1Q": qaInspectionKey("A1"), "A1C": cancellationKey("A1"), "A1H": historyKey("A1")} {
+		l.stub.state[oldKey] = l.stub.state[key]
+		delete(l.stub.state, key)
+	}
+	l.stub.state["SchemaMigration"], _ = json.Marshal(Schema_Migration{TargetVersion: 2, Done: true, SchemaVersion: 2})
+
+	if payload := l.mustCall("getQAInspectionsByAssemblyID", "A1", "viewer"); !strings.Contains(string(payload), INSPECTION_PASS) {
+		t.Errorf("inspections of A1 not read from the old key: %s", payload)
+	}
+	l.mustCall("getCancellationByID", "A1", "al")
+	if payload := l.mustCall("getAssemblyLineHistoryByID", "A1", "al"); !strings.Contains(string(payload), ASSEMBLYSTATUS_CAN) {
+		t.Errorf("history of A1 not read from the old key: %s", payload)
+	}
+	//A2C is an Assembly, not the Cancellation of A2
+	_, err := l.call("getCancellationByID", "A2", "al")
+	l.wantCode(err, ERR_NOT_FOUND, "Assembly A2C read as the Cancellation of A2")
+
+	l.mustCall("migrateSchema", "50", "al")
+	for _, key := range []string{"A1Q", "A1C", "A1H"} {
+		if _, ok := l.stub.state[key]; ok {
+			t.Errorf("old key %s not moved", key)
+		}
+	}
+	if l.stub.state[qaInspectionKey("A1")] == nil || l.stub.state[cancellationKey("A1")] == nil || l.stub.state[historyKey("A1")] == nil {
+		t.Error("records of A1 not moved to their keys")
+	}
+	if assem := l.assembly("A2C"); assem.AssemblyId != "A2C" {
+		t.Errorf("Assembly A2C moved as a Cancellation: %+v", assem)
+	}
+	l.mustCall("getCancellationByID", "A1", "al")
+}
+
+func TestCustomerDetailsPrivate(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAssembly("A1", ASSEMBLYSTATUS_RFP)
+	l.createAssembly("A2", ASSEMBLYSTATUS_RFP)
+
+	// Peers without private data take Packages without an address
+	if _, err := l.cc.Call(legacyStub{l.stub}, "createPackage", l.packageArgs("P0", "A2", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)); err != nil {
+		t.Fatalf("createPackage on a peer without private data: %v", err)
+	}
+	if _, ok := l.stub.private[CUSTOMER_DETAILS_COLLECTION+"/P0"]; ok {
+		t.Error("customer details stored for a Package created without private data")
+	}
+
+	// The address only comes in the transient map, its legacy argument stays empty
+	args := l.packageArgs("P1", "A1", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)
+	args[5] = testAddress
+	_, err := l.call("createPackage", args...)
+	l.wantCode(err, ERR_INVALID_ARGUMENT, "shipping address as an argument")
+	delete(l.stub.transient, "customerDetailsSalt")
+	_, err = l.call("createPackage", l.packageArgs("P1", "A1", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)...)
+	l.wantCode(err, ERR_INVALID_ARGUMENT, "shipping address without a salt")
+	l.stub.transient["customerDetailsSalt"] = []byte("too short")
+	_, err = l.call("createPackage", l.packageArgs("P1", "A1", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)...)
+	l.wantCode(err, ERR_INVALID_ARGUMENT, "shipping address with a short salt")
+	l.stub.transient["customerDetailsSalt"] = []byte(testSalt)
+	l.mustCall("createPackage", l.packageArgs("P1", "A1", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG)...)
+
+	// The salt stays with the details, later updates need none
+	l.stub.transient = map[string][]byte{"customerName": []byte("Jane Doe"), "customerPhone": []byte("+91 33 4000 0000"), "customerEmail": []byte("jane@example.com")}
+	_, err = l.call("updatePackageCustomerDetails", "P1", "Jane Doe", "", "", "", "pl")
+	l.wantCode(err, ERR_INVALID_ARGUMENT, "customer name as an argument")
+	l.mustCall("updatePackageCustomerDetails", "P1", "", "", "", "", "pl")
+	for key, value := range l.stub.state {
+		if strings.Contains(string(value), "Main St") || strings.Contains(string(value), "Jane Doe") {
+			t.Errorf("customer details in the world state under %s", key)
+		}
+	}
+
+	var details Customer_Details
+	json.Unmarshal(l.mustCall("getPackageCustomerDetails", "P1", "pl"), &details)
+	if details.CustomerName != "Jane Doe" || !strings.Contains(details.ShippingToAddress, "Main St") {
+		t.Errorf("customer details %+v", details)
+	}
+
+	// The public hash is salted, so it can't be matched against guessed details
+	var pack PackageLine
+	json.Unmarshal(l.stub.state["P1"], &pack)
+	unsalted := details
+	unsalted.Salt = ""
+	if len(details.Salt) == 0 || strings.Contains(details.Salt, testSalt) || pack.CustomerDetailsHash != computeCustomerDetailsHash(details) ||
+		pack.CustomerDetailsHash == computeCustomerDetailsHash(unsalted) {
+		t.Errorf("customer details hash %s not salted, salt %q", pack.CustomerDetailsHash, details.Salt)
+	}
+
+	_, err = l.cc.Call(legacyStub{l.stub}, "getPackageCustomerDetails", []string{"P1", "pl"})
+	l.wantCode(err, ERR_FORBIDDEN, "read customer details on a peer without private data")
+	_, err = l.cc.Call(identityStub{l.stub, certOf("dist")}, "getPackageCustomerDetails", []string{"P1", "pl"})
+	l.wantCode(err, ERR_FORBIDDEN, "read customer details as the shipper with another user's identity")
+
+	// Left out, the user is the one of the submitting identity
+	payload, err := l.cc.Call(identityStub{l.stub, certOf("pl")}, "getPackageCustomerDetails", []string{"P1"})
+	if err != nil || !strings.Contains(string(payload), "Jane Doe") {
+		t.Errorf("customer details for the submitting identity: %s, %v", payload, err)
+	}
+	_, err = l.cc.Call(identityStub{l.stub, "cert-mallory"}, "getPackageCustomerDetails", []string{"P1"})
+	l.wantCode(err, ERR_FORBIDDEN, "customer details for an unregistered identity")
+
+	//Recipients see the details once they accepted the Shipment
+	_, err = l.call("getPackageCustomerDetails", "P1", "dist")
+	l.wantCode(err, ERR_FORBIDDEN, "customer details for a distributor not receiving the Package")
+	l.ship("S1", "P1")
+	l.mustCall("handOverShipment", "S1", "dist", "DC", "carrier")
+	l.mustCall("acceptShipment", "S1", "DC", "dist")
+	l.mustCall("getPackageCustomerDetails", "P1", "dist")
+	_, err = l.call("getPackageCustomerDetails", "P1", "shop")
+	l.wantCode(err, ERR_FORBIDDEN, "customer details for a retailer not receiving the Package")
+}
+
+func TestPrivatisePackageAddress(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAssembly("A1", ASSEMBLYSTATUS_RFP)
+	l.cc.Call(legacyStub{l.stub}, "createPackage", l.packageArgs("P1", "A1", PACKAGESTATUS_PKG, ASSEMBLYSTATUS_PKG))
+
+	// The address as the Package kept it publicly before private data
+	var history PackageLine_Holder
+	json.Unmarshal(l.stub.state[historyKey("P1")], &history)
+	exported := history.PackageLines[0]
+	exported.ShippingToAddress = "1 Main St, Kolkata"
+	exported.PackageHash = computePackageHash(exported)
+	history.PackageLines[0] = exported
+	l.stub.state["P1"], _ = json.Marshal(exported)
+	l.stub.state[historyKey("P1")], _ = json.Marshal(history)
+	record, _ := json.Marshal(exported)
+
+	l.stub.transient = nil
+	_, err := l.call("privatisePackageAddresses", "pl")
+	l.wantCode(err, ERR_INVALID_ARGUMENT, "privatise addresses without a salt")
+	l.stub.transient = map[string][]byte{"customerDetailsSalt": []byte(testSalt)}
+	l.mustCall("privatisePackageAddresses", "pl")
+
+	for key, value := range l.stub.state {
+		if strings.Contains(string(value), "Main St") {
+			t.Errorf("shipping address left in the world state under %s", key)
+		}
+	}
+	json.Unmarshal(l.stub.state[historyKey("P1")], &history)
+	if len(history.PackageLines) != 2 {
+		t.Fatalf("%d history versions, want the original and the privatised one", len(history.PackageLines))
+	}
+	original, privatised := history.PackageLines[0], history.PackageLines[1]
+	if original.PackageHash != exported.PackageHash || !original.ShippingToAddressRedacted {
+		t.Errorf("original version %+v, want its hash %s kept and the address redacted", original, exported.PackageHash)
+	}
+	if privatised.PrivatisedFromHash != exported.PackageHash || len(privatised.CustomerDetailsHash) == 0 ||
+		privatised.PackageHash != computePackageHash(privatised) || privatised.PackageLastUpdatedBy != "pl" {
+		t.Errorf("privatised version %+v", privatised)
+	}
+
+	// Records exported before still verify
+	var verification Hash_Verification
+	json.Unmarshal(l.mustCall("verifyPackage", "P1", string(record), "pl"), &verification)
+	if !verification.Valid || verification.MatchedVersion != 0 {
+		t.Errorf("exported record: %+v", verification)
+	}
+	exported.ShippingToAddress = "2 Other St, Kolkata"
+	record, _ = json.Marshal(exported)
+	json.Unmarshal(l.mustCall("verifyPackage", "P1", string(record), "pl"), &verification)
+	if verification.Valid {
+		t.Errorf("exported record with another address verified: %+v", verification)
+	}
+
+	var details Customer_Details
+	json.Unmarshal(l.mustCall("getPackageCustomerDetails", "P1", "pl"), &details)
+	if details.ShippingToAddress != "1 Main St, Kolkata" || privatised.CustomerDetailsHash != computeCustomerDetailsHash(details) {
+		t.Errorf("privatised customer details %+v", details)
+	}
+}
+
+func TestCustomerDetailsMigratedFromWorldState(t *testing.T) {
+	l := newTestLedger(t)
+	l.pack("P1", "A1")
+
+	//The details as a peer without private data kept them before version 3
+	key := CUSTOMER_DETAILS_COLLECTION + "/P1"
+	var details Customer_Details
+	json.Unmarshal(l.stub.private[key], &details)
+	details.SchemaVersion = 2
+	l.stub.state[publicPrivateDataKey(CUSTOMER_DETAILS_COLLECTION, "P1")], _ = json.Marshal(details)
+	delete(l.stub.private, key)
+	l.stub.state["SchemaMigration"], _ = json.Marshal(Schema_Migration{TargetVersion: 2, Done: true, SchemaVersion: 2})
+
+	_, err := l.cc.Call(legacyStub{l.stub}, "migrateSchema", []string{"50", "al"})
+	l.wantCode(err, ERR_FORBIDDEN, "migrate public customer details on a peer without private data")
+
+	l.mustCall("migrateSchema", "50", "al")
+	if _, ok := l.stub.state[publicPrivateDataKey(CUSTOMER_DETAILS_COLLECTION, "P1")]; ok {
+		t.Error("customer details left in the world state")
+	}
+	var migrated Customer_Details
+	json.Unmarshal(l.stub.private[key], &migrated)
+	if migrated.ShippingToAddress != details.ShippingToAddress || migrated.SchemaVersion != SCHEMA_VERSION {
+		t.Errorf("migrated customer details %+v", migrated)
+	}
+}
+
+func TestInitNotReachableByClients(t *testing.T) {
+	l := newTestLedger(t)
+
+	_, err := l.call("init", "mallory", ASSEMBLYLINE_ROLE)
+	l.wantCode(err, ERR_INVALID_ARGUMENT, "init through Call")
+	_, err = l.cc.InitNewLedger(l.stub, []string{"mallory", ASSEMBLYLINE_ROLE})
+	l.wantCode(err, ERR_FORBIDDEN, "set up a ledger as a client")
+	l.stub.admin = true
+	_, err = l.cc.InitNewLedger(l.stub, []string{"mallory", ASSEMBLYLINE_ROLE})
+	l.wantCode(err, ERR_ALREADY_EXISTS, "set up a ledger set up already")
+	_, err = l.call("createAssembly", "A1", "SN-A1", "HOLDER", "", "", "", "", "", "", "", "KOL", ASSEMBLYSTATUS_RFP, "20170608101500", "", "", "", "mallory")
+	l.wantCode(err, ERR_FORBIDDEN, "create an Assembly as a user registered by a client")
+
+	fresh := &memStub{state: map[string][]byte{}, private: map[string][]byte{}}
+	_, err = l.cc.InitNewLedger(fresh, []string{"al", ASSEMBLYLINE_ROLE})
+	l.wantCode(err, ERR_FORBIDDEN, "set up a new ledger as a client")
+	_, err = l.cc.InitNewLedger(legacyStub{fresh}, []string{"al", ASSEMBLYLINE_ROLE})
+	l.wantCode(err, ERR_FORBIDDEN, "set up a new ledger on the legacy shim")
+	fresh.admin = true
+	if _, err := l.cc.InitNewLedger(fresh, []string{"al", ASSEMBLYLINE_ROLE}); err != nil {
+		t.Fatalf("InitNewLedger on a new ledger: %v", err)
+	}
+}
+
+func TestRegisterUser(t *testing.T) {
+	l := newTestLedger(t)
+	l.createAssembly("A1", ASSEMBLYSTATUS_RFP)
+
+	payload, err := l.cc.Call(identityStub{l.stub, "cert-carrier2"}, "getCallerIdentity", nil)
+	if err != nil {
+		t.Fatalf("getCallerIdentity: %v", err)
+	}
+	var caller Caller_Identity
+	json.Unmarshal(payload, &caller)
+	if want, _ := creatorIdentity(identityStub{l.stub, "cert-carrier2"}); caller.Identity != want || len(want) != 64 {
+		t.Fatalf("getCallerIdentity = %s, want %s", caller.Identity, want)
+	}
+	_, err = l.call("getCallerIdentity")
+	l.wantCode(err, ERR_NOT_FOUND, "getCallerIdentity without a submitting identity")
+
+	_, err = l.call("registerUser", "carrier2", CARRIER_ROLE, caller.Identity)
+	l.wantCode(err, ERR_FORBIDDEN, "register a user as a client")
+	_, err = l.cc.Call(legacyStub{l.stub}, "registerUser", []string{"carrier2", CARRIER_ROLE, caller.Identity})
+	l.wantCode(err, ERR_FORBIDDEN, "register a user on the legacy shim")
+
+	l.stub.admin = true
+	for _, c := range []struct {
+		args  []string
+		field string
+	}{
+		{[]string{"", CARRIER_ROLE, caller.Identity}, "name"},
+		{[]string{"carrier|2", CARRIER_ROLE, caller.Identity}, "name"},
+		{[]string{"carrier2", "admin_role", caller.Identity}, "role"},
+		{[]string{"carrier2", CARRIER_ROLE, "cert-carrier2"}, "identity"},
+		{[]string{"carrier2", CARRIER_ROLE, caller.Identity[:62]}, "identity"},
+	} {
+		_, err := l.call("registerUser", c.args...)
+		if tntErr, ok := err.(*TnT_Error); !ok || tntErr.Code != ERR_INVALID_ARGUMENT || tntErr.Field != c.field {
+			t.Errorf("registerUser %v: err = %v, want %s of %s", c.args, err, ERR_INVALID_ARGUMENT, c.field)
+		}
+	}
+	_, err = l.call("registerUser", "A1", CARRIER_ROLE, caller.Identity)
+	l.wantCode(err, ERR_ALREADY_EXISTS, "register a user named as an Assembly")
+
+	l.mustCall("registerUser", "carrier2", CARRIER_ROLE, strings.ToUpper(caller.Identity))
+	if role := string(l.stub.state["carrier2"]); role != CARRIER_ROLE {
+		t.Errorf("registered role %q, want %s", role, CARRIER_ROLE)
+	}
+	if identity := string(l.stub.state[userIdentityKey("carrier2")]); identity != caller.Identity {
+		t.Errorf("registered identity %q, want %s", identity, caller.Identity)
+	}
+	if user := string(l.stub.state[identityUserKey(caller.Identity)]); user != "carrier2" {
+		t.Errorf("user of the identity %q, want carrier2", user)
+	}
+	// Role changes keep the user
+	l.mustCall("registerUser", "carrier2", DISTRIBUTOR_ROLE, caller.Identity)
+	if role := string(l.stub.state["carrier2"]); role != DISTRIBUTOR_ROLE {
+		t.Errorf("role after re-registering %q, want %s", role, DISTRIBUTOR_ROLE)
+	}
+
+	// An identity belongs to one user; a renewed one replaces the old
+	_, err = l.call("registerUser", "carrier3", CARRIER_ROLE, caller.Identity)
+	if tntErr, ok := err.(*TnT_Error); !ok || tntErr.Code != ERR_ALREADY_EXISTS || tntErr.Field != "identity" {
+		t.Errorf("register a second user with an identity: err = %v, want %s of identity", err, ERR_ALREADY_EXISTS)
+	}
+	renewed, _ := creatorIdentity(identityStub{nil, "renewed-cert-carrier2"})
+	l.mustCall("registerUser", "carrier2", DISTRIBUTOR_ROLE, renewed)
+	if _, ok := l.stub.state[identityUserKey(caller.Identity)]; ok {
+		t.Error("replaced identity still finds its user")
+	}
+	l.mustCall("registerUser", "carrier3", CARRIER_ROLE, caller.Identity)
+}
+
+func TestInitLedgerWriteFails(t *testing.T) {
+	for _, key := range []string{"Assemblies", "Packages"} {
+		stub := failingStub{&memStub{state: map[string][]byte{}, private: map[string][]byte{}}, key}
+		_, err := new(TnT).InitLedger(stub, []string{"al", ASSEMBLYLINE_ROLE})
+		if tntErr, ok := err.(*TnT_Error); !ok || tntErr.Code != ERR_CORRUPT_STATE {
+			t.Errorf("InitLedger failing to write %s: err = %v, want %s", key, err, ERR_CORRUPT_STATE)
+		}
+	}
+}
+
+func TestRichQueryIndexesShipped(t *testing.T) {
+	files, err := filepath.Glob("../v2/META-INF/statedb/couchdb/indexes/*.json")
+	if err != nil || len(files) != len(richQueryIndexes) {
+		t.Fatalf("%d shipped indexes, want %d (%v)", len(files), len(richQueryIndexes), err)
+	}
+	for _, file := range files {
+		var index struct {
+			Index struct {
+				Fields []string `json:"fields"`
+			} `json:"index"`
+			Ddoc string `json:"ddoc"`
+			Name string `json:"name"`
+		}
+		bytes, _ := ioutil.ReadFile(file)
+		if err := json.Unmarshal(bytes, &index); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if !reflect.DeepEqual(index.Index.Fields, richQueryIndexes[index.Name]) || index.Ddoc != RICH_QUERY_INDEX_DDOC+index.Name {
+			t.Errorf("%s indexes %v in %s, the chaincode queries %v", file, index.Index.Fields, index.Ddoc, richQueryIndexes[index.Name])
+		}
+	}
+}
+
+func TestRichQuery(t *testing.T) {
+	l := newTestLedger(t)
+	l.mustCall("createAssembly", "A1", "SN-A1", "HOLDER", "F1", "L1", "C1", "W1", "CA1", "AD1", "ST1", "KOL", "1", "20170610000000", "", "", "", "al")
+	l.mustCall("createAssembly", "A2", "SN-A2", "HOLDER", "F1", "L2", "C1", "W1", "CA1", "AD1", "ST1", "KOL", "1", "20170608000000", "", "", "", "al")
+	l.mustCall("createAssembly", "A3", "SN-A3", "HOLDER", "F1", "L1", "C1", "W1", "CA1", "AD1", "ST1", "KOL", "1", "20170609000000", "", "", "", "al")
+
+	assemblyIds := func(payload []byte) []string {
+		var assemblies []AssemblyLine
+		json.Unmarshal(payload, &assemblies)
+		ids := []string{}
+		for _, assem := range assemblies {
+			ids = append(ids, assem.AssemblyId)
+		}
+		return ids
+	}
+
+	// CouchDB answers in the order of the index, without the Assemblies index being read
+	stub := richStub{memStub: l.stub, reads: map[string]int{}}
+	payload, err := l.cc.Call(stub, "getAssembliesByBatchNumber", []string{LED_BATCH, "L1", "al"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := assemblyIds(payload); !reflect.DeepEqual(ids, []string{"A3", "A1"}) {
+		t.Errorf("Assemblies of LED batch L1 = %v, want [A3 A1]", ids)
+	}
+	payload, err = l.cc.Call(stub, "getAssembliesByDate", []string{"20170609000000", "20171231235959", "al"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := assemblyIds(payload); !reflect.DeepEqual(ids, []string{"A3", "A1"}) {
+		t.Errorf("Assemblies from 20170609 = %v, want [A3 A1]", ids)
+	}
+	if stub.reads["Assemblies"] != 0 {
+		t.Errorf("Assemblies index read %d times by rich queries", stub.reads["Assemblies"])
+	}
+
+	// LevelDB scans the index instead, any other failure fails the query
+	stub = richStub{memStub: l.stub, reads: map[string]int{}, queryErr: ErrNoRichQuery}
+	payload, err = l.cc.Call(stub, "getAssembliesByBatchNumber", []string{LED_BATCH, "L1", "al"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := assemblyIds(payload); !reflect.DeepEqual(ids, []string{"A1", "A3"}) || stub.reads["Assemblies"] == 0 {
+		t.Errorf("Assemblies of LED batch L1 on LevelDB = %v, want [A1 A3] from the index", ids)
+	}
+	stub = richStub{memStub: l.stub, reads: map[string]int{}, queryErr: errors.New("couchdb timeout")}
+	_, err = l.cc.Call(stub, "getAssembliesByBatchNumber", []string{LED_BATCH, "L1", "al"})
+	l.wantCode(err, ERR_CORRUPT_STATE, "rich query failing on CouchDB")
+}
+
+func TestUserRolesNotQueryable(t *testing.T) {
+	l := newTestLedger(t)
+	_, err := l.call("get_ecert", "al")
+	l.wantCode(err, ERR_INVALID_ARGUMENT, "get_ecert")
+
+	// Shipment handlers are checked by the registered roles alone
+	l.pack("P1", "A1")
+	l.mustCall("createShipment", "S1", `["P1"]`, "DHL", "TRK1", "KOL", "Retail DC", "pl")
+	_, err = l.call("handOverShipment", "S1", "carrier", "KOL dock", "shop")
+	l.wantCode(err, ERR_FORBIDDEN, "hand over as a Retailer")
+	l.mustCall("handOverShipment", "S1", "carrier", "KOL dock", "pl")
+	_, err = l.call("acceptShipment", "S1", "KOL dock", "pl")
+	l.wantCode(err, ERR_FORBIDDEN, "accept as a PackageLine user")
+}
@@ -0,0 +1,112 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package tnt
+
+import (
+	"testing"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		date string
+		want string // DATE_FORMAT in UTC, empty when the date must be rejected
+	}{
+		{"20170608101500", "20170608101500"},
+		{"2017-06-08T15:45:00+05:30", "20170608101500"},
+		{"2017-06-08T10:15:00Z", "20170608101500"},
+		{"2017-06-07T22:15:00-12:00", "20170608101500"},
+		{"2017-06-08T15:45:00.250+05:30", "20170608101500"},
+		{"2017-06-08T15:45:00+0530", "20170608101500"},
+		{"20170608T154500+0530", "20170608101500"},
+		{"20170608T101500Z", "20170608101500"},
+		{"2018-01-01T01:00:00+02:00", "20171231230000"},
+		{"20160229120000", "20160229120000"},
+
+		{"20171399999999", ""},
+		{"20170230120000", ""},
+		{"20170229120000", ""},
+		{"20170608250000", ""},
+		{"2017060810150", ""},
+		{"201706081015000", ""},
+		{"2017-06-08T15:45:00", ""},
+		{"2017-06-08 15:45:00+05:30", ""},
+		{"2017-06-08", ""},
+		{"", ""},
+		{"yesterday", ""},
+	}
+	for _, tc := range tests {
+		got, err := ParseDate(tc.date)
+		if tc.want == "" {
+			if err == nil {
+				t.Errorf("ParseDate(%q) = %s, want an error", tc.date, got.Format(DATE_FORMAT))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDate(%q): %v", tc.date, err)
+			continue
+		}
+		if got.Location().String() != "UTC" || got.Format(DATE_FORMAT) != tc.want {
+			t.Errorf("ParseDate(%q) = %s %s, want %s UTC", tc.date, got.Format(DATE_FORMAT), got.Location(), tc.want)
+		}
+	}
+}
+
+func TestCanonicalDateError(t *testing.T) {
+	_, err := canonicalDate("20171399999999", "assemblyDate")
+	tntErr, ok := err.(*TnT_Error)
+	if !ok || tntErr.Code != ERR_INVALID_ARGUMENT || tntErr.Field != "assemblyDate" {
+		t.Errorf("canonicalDate of an invalid date = %v, want %s on assemblyDate", err, ERR_INVALID_ARGUMENT)
+	}
+}
+
+func TestLocalDate(t *testing.T) {
+	zones := Plant_TimeZones{TimeZones: map[string]string{
+		"Pune":    "Asia/Kolkata",
+		"Offset":  "-03:30",
+		"Unknown": "Mars/Olympus",
+	}}
+	tests := []struct {
+		date, plant, want string
+	}{
+		{"20170608101500", "Pune", "2017-06-08T15:45:00+05:30"},
+		{"20170608101500", "Offset", "2017-06-08T06:45:00-03:30"},
+		{"20170608101500", "Unknown", "2017-06-08T10:15:00Z"},
+		{"20170608101500", "NoZone", "2017-06-08T10:15:00Z"},
+		{"2017-06-08T10:15:00Z", "Pune", ""},
+		{"", "Pune", ""},
+	}
+	for _, tc := range tests {
+		if got := zones.localDate(tc.date, tc.plant); got != tc.want {
+			t.Errorf("localDate(%q, %q) = %q, want %q", tc.date, tc.plant, got, tc.want)
+		}
+	}
+}
+
+func TestLoadTimeZone(t *testing.T) {
+	for _, zone := range []string{"UTC", "Asia/Kolkata", "+05:30", "-12:00", "+14:00", "+00:00"} {
+		if _, err := loadTimeZone(zone); err != nil {
+			t.Errorf("loadTimeZone(%q): %v", zone, err)
+		}
+	}
+	for _, zone := range []string{"", "Local", "+15:00", "+05:60", "+5:30", "05:30", "+0a:30", "Mars/Olympus"} {
+		if _, err := loadTimeZone(zone); err == nil {
+			t.Errorf("loadTimeZone(%q) should fail", zone)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Chaincode binary for Fabric peers on the contract API, see the contract package
+package main
+
+import (
+	"fmt"
+
+	"github.com/GHSagarnil/TracknTrace3/chaincode/contract"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// main function
+func main() {
+	cc, err := contractapi.NewChaincode(contract.New())
+	if err != nil {
+		fmt.Printf("Error creating TnT chaincode: %s", err)
+		return
+	}
+	if err := cc.Start(); err != nil {
+		fmt.Printf("Error starting TnT chaincode: %s", err)
+	}
+}
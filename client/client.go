@@ -0,0 +1,220 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Package client is the transport used by the TnT command line tools to reach the chaincode.
+// Tools depend on the Client interface only, so they can run against a peer or an in-process stub.
+package client
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Client invokes and queries TnT chaincode functions.
+// Args are passed exactly as the chaincode expects them, user name included.
+type Client interface {
+	Invoke(function string, args []string) ([]byte, error)
+	Query(function string, args []string) ([]byte, error)
+}
+
+// TransientClient is a Client that can pass a transient map with a call. The chaincode reads it
+// but the ledger doesn't record it, so customer details kept in the private data collection are
+// sent this way rather than as arguments.
+type TransientClient interface {
+	InvokeTransient(function string, args []string, transient map[string][]byte) ([]byte, error)
+	QueryTransient(function string, args []string, transient map[string][]byte) ([]byte, error)
+}
+
+// ErrNoTransient is returned when transient data is sent through a client that can't pass it
+var ErrNoTransient = errors.New("the client can't pass transient data, e.g. customer details, to the chaincode")
+
+// SaltField is the transient field of the random salt the chaincode hashes customer details with, so
+// their hash on the public ledger can't be reversed by trying likely values
+const SaltField = "customerDetailsSalt"
+
+// saltLength is the number of random bytes in a SaltField
+const saltLength = 32
+
+// salted returns transient with a fresh random SaltField added, unless it has one
+func salted(transient map[string][]byte) (map[string][]byte, error) {
+	if _, ok := transient[SaltField]; ok {
+		return transient, nil
+	}
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generating the customer details salt: %v", err)
+	}
+	res := map[string][]byte{SaltField: salt}
+	for field, value := range transient {
+		res[field] = value
+	}
+	return res, nil
+}
+
+// Invoke invokes function through c, with transient when there is any. Through a TransientClient
+// the call also carries a random SaltField.
+func Invoke(c Client, function string, args []string, transient map[string][]byte) ([]byte, error) {
+	tc, ok := c.(TransientClient)
+	if !ok {
+		if len(transient) > 0 {
+			return nil, ErrNoTransient
+		}
+		return c.Invoke(function, args)
+	}
+	transient, err := salted(transient)
+	if err != nil {
+		return nil, err
+	}
+	return tc.InvokeTransient(function, args, transient)
+}
+
+// Query queries function through c, with transient when there is any. Through a TransientClient
+// the call also carries a random SaltField, which dry runs of invokes need.
+func Query(c Client, function string, args []string, transient map[string][]byte) ([]byte, error) {
+	tc, ok := c.(TransientClient)
+	if !ok {
+		if len(transient) > 0 {
+			return nil, ErrNoTransient
+		}
+		return c.Query(function, args)
+	}
+	transient, err := salted(transient)
+	if err != nil {
+		return nil, err
+	}
+	return tc.QueryTransient(function, args, transient)
+}
+
+// RESTClient talks to a peer's REST API (POST /chaincode, JSON-RPC 2.0). The API has no transient
+// map, see TransientClient.
+type RESTClient struct {
+	PeerURL       string // e.g. http://localhost:7050
+	ChaincodeName string
+	SecureContext string // enrolled user the peer signs with
+	HTTPClient    *http.Client
+
+	id int64
+}
+
+// NewRESTClient returns a RESTClient with a 30 second timeout
+func NewRESTClient(peerURL, chaincodeName, secureContext string) *RESTClient {
+	return &RESTClient{
+		PeerURL:       peerURL,
+		ChaincodeName: chaincodeName,
+		SecureContext: secureContext,
+		HTTPClient:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type rpcRequest struct {
+	JSONRPC string    `json:"jsonrpc"`
+	Method  string    `json:"method"`
+	Params  rpcParams `json:"params"`
+	ID      int64     `json:"id"`
+}
+
+type rpcParams struct {
+	Type        int               `json:"type"`
+	ChaincodeID map[string]string `json:"chaincodeID"`
+	CtorMsg     rpcCtorMsg        `json:"ctorMsg"`
+	SecureCtx   string            `json:"secureContext,omitempty"`
+}
+
+type rpcCtorMsg struct {
+	Function string   `json:"function"`
+	Args     []string `json:"args"`
+}
+
+type rpcResponse struct {
+	Result *struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	} `json:"result"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    string `json:"data"`
+	} `json:"error"`
+}
+
+// Invoke submits a transaction and returns its transaction ID
+func (c *RESTClient) Invoke(function string, args []string) ([]byte, error) {
+	return c.call("invoke", function, args)
+}
+
+// Query evaluates a query function and returns its payload
+func (c *RESTClient) Query(function string, args []string) ([]byte, error) {
+	return c.call("query", function, args)
+}
+
+func (c *RESTClient) call(method, function string, args []string) ([]byte, error) {
+	if args == nil {
+		args = []string{}
+	}
+	req := rpcRequest{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params: rpcParams{
+			Type:        1, // GOLANG
+			ChaincodeID: map[string]string{"name": c.ChaincodeName},
+			CtorMsg:     rpcCtorMsg{Function: function, Args: args},
+			SecureCtx:   c.SecureContext,
+		},
+		ID: atomic.AddInt64(&c.id, 1),
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Post(c.PeerURL+"/chaincode", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var rpc rpcResponse
+	if err := json.Unmarshal(respBody, &rpc); err != nil {
+		return nil, fmt.Errorf("%s %s: unexpected peer response (HTTP %d)", method, function, resp.StatusCode)
+	}
+	if rpc.Error != nil {
+		if rpc.Error.Data != "" {
+			return nil, chaincodeError(errors.New(rpc.Error.Data))
+		}
+		return nil, chaincodeError(errors.New(rpc.Error.Message))
+	}
+	if rpc.Result == nil {
+		return nil, fmt.Errorf("%s %s: empty peer response", method, function)
+	}
+	return []byte(rpc.Result.Message), nil
+}
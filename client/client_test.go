@@ -0,0 +1,172 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// peer is a peer REST API answering every call with response, recording the last request
+type peer struct {
+	request  rpcRequest
+	response string
+}
+
+func (p *peer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" || r.URL.Path != "/chaincode" {
+		http.NotFound(w, r)
+		return
+	}
+	json.NewDecoder(r.Body).Decode(&p.request)
+	w.Write([]byte(p.response))
+}
+
+func TestRESTClient(t *testing.T) {
+	p := &peer{response: `{"jsonrpc":"2.0","result":{"status":"OK","message":"[1,2]"},"id":1}`}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+	c := NewRESTClient(srv.URL, "tnt", "aluser1")
+
+	payload, err := c.Query("getAllAssemblies", []string{"aluser1"})
+	if err != nil || string(payload) != "[1,2]" {
+		t.Fatalf("Query = %q, %v", payload, err)
+	}
+	req := p.request
+	if req.Method != "query" || req.Params.ChaincodeID["name"] != "tnt" || req.Params.SecureCtx != "aluser1" ||
+		req.Params.CtorMsg.Function != "getAllAssemblies" || !reflect.DeepEqual(req.Params.CtorMsg.Args, []string{"aluser1"}) {
+		t.Errorf("query request = %+v", req)
+	}
+
+	if _, err := c.Invoke("getAllAssemblies", nil); err != nil {
+		t.Fatal(err)
+	}
+	if p.request.Method != "invoke" || p.request.Params.CtorMsg.Args == nil || p.request.ID <= req.ID {
+		t.Errorf("invoke request = %+v, want args [] and a new ID", p.request)
+	}
+}
+
+func TestRESTClientErrors(t *testing.T) {
+	tests := []struct {
+		response string
+		want     error
+	}{
+		{`{"jsonrpc":"2.0","error":{"code":-32003,"message":"Query failure","data":"Error when querying chaincode: {\"code\":\"NOT_FOUND\",\"message\":\"Assembly not found\",\"field\":\"assemblyId\"}"},"id":1}`,
+			&ChaincodeError{Code: NotFound, Message: "Assembly not found", Field: "assemblyId"}},
+		{`{"jsonrpc":"2.0","error":{"code":-32003,"message":"{\"code\":\"FORBIDDEN\",\"message\":\"Permission denied\"}"},"id":1}`,
+			&ChaincodeError{Code: Forbidden, Message: "Permission denied"}},
+	}
+	for _, tc := range tests {
+		srv := httptest.NewServer(&peer{response: tc.response})
+		_, err := NewRESTClient(srv.URL, "tnt", "").Query("getAssemblyByID", []string{"A1", "al"})
+		srv.Close()
+		if !reflect.DeepEqual(err, tc.want) {
+			t.Errorf("error of %s = %#v, want %#v", tc.response, err, tc.want)
+		}
+	}
+
+	for _, response := range []string{`<html>bad gateway</html>`, `{"jsonrpc":"2.0","id":1}`} {
+		srv := httptest.NewServer(&peer{response: response})
+		_, err := NewRESTClient(srv.URL, "tnt", "").Query("getAssemblyByID", nil)
+		srv.Close()
+		if err == nil {
+			t.Errorf("response %s accepted", response)
+		} else if _, ok := err.(*ChaincodeError); ok {
+			t.Errorf("response %s: %v is a chaincode error", response, err)
+		}
+	}
+}
+
+// transientClient records the transient map of its last call
+type transientClient struct {
+	invoke    bool
+	transient map[string][]byte
+}
+
+func (c *transientClient) Invoke(function string, args []string) ([]byte, error) {
+	return c.InvokeTransient(function, args, nil)
+}
+
+func (c *transientClient) Query(function string, args []string) ([]byte, error) {
+	return c.QueryTransient(function, args, nil)
+}
+
+func (c *transientClient) InvokeTransient(function string, args []string, transient map[string][]byte) ([]byte, error) {
+	c.invoke, c.transient = true, transient
+	return nil, nil
+}
+
+func (c *transientClient) QueryTransient(function string, args []string, transient map[string][]byte) ([]byte, error) {
+	c.invoke, c.transient = false, transient
+	return nil, nil
+}
+
+func TestTransientCalls(t *testing.T) {
+	c := &transientClient{}
+	if _, err := Invoke(c, "updatePackageCustomerDetails", nil, map[string][]byte{"customerName": []byte("Jane")}); err != nil {
+		t.Fatal(err)
+	}
+	if !c.invoke || string(c.transient["customerName"]) != "Jane" || len(c.transient[SaltField]) != saltLength {
+		t.Errorf("invoke transient = %q, want customerName and a %d byte salt", c.transient, saltLength)
+	}
+	first := string(c.transient[SaltField])
+
+	if _, err := Query(c, "dryRun", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if c.invoke || len(c.transient[SaltField]) != saltLength {
+		t.Errorf("query transient = %q, want a salt", c.transient)
+	}
+	if string(c.transient[SaltField]) == first {
+		t.Error("two calls got the same salt")
+	}
+
+	// A salt given is kept
+	if _, err := Invoke(c, "updatePackageCustomerDetails", nil, map[string][]byte{SaltField: []byte("pepper")}); err != nil {
+		t.Fatal(err)
+	}
+	if string(c.transient[SaltField]) != "pepper" {
+		t.Errorf("salt = %q, want the one given", c.transient[SaltField])
+	}
+}
+
+func TestNoTransient(t *testing.T) {
+	p := &peer{response: `{"jsonrpc":"2.0","result":{"status":"OK","message":"tx1"},"id":1}`}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+	c := NewRESTClient(srv.URL, "tnt", "pluser1")
+
+	transient := map[string][]byte{"customerName": []byte("Jane")}
+	if _, err := Invoke(c, "updatePackageCustomerDetails", []string{"C1", "", "", "", "", "pluser1"}, transient); err != ErrNoTransient {
+		t.Errorf("Invoke with transient data: err = %v, want ErrNoTransient", err)
+	}
+	if _, err := Query(c, "dryRun", nil, transient); err != ErrNoTransient {
+		t.Errorf("Query with transient data: err = %v, want ErrNoTransient", err)
+	}
+	if p.request.Method != "" {
+		t.Errorf("peer called with transient data it can't pass: %+v", p.request)
+	}
+
+	// Without transient data the client is called as it is, with no salt
+	if payload, err := Invoke(c, "createAssembly", nil, map[string][]byte{}); err != nil || string(payload) != "tx1" {
+		t.Errorf("Invoke without transient data = %q, %v", payload, err)
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Error codes of the TnT chaincode
+const (
+	NotFound          = "NOT_FOUND"
+	AlreadyExists     = "ALREADY_EXISTS"
+	Forbidden         = "FORBIDDEN"
+	InvalidArgument   = "INVALID_ARGUMENT"
+	InvalidTransition = "INVALID_TRANSITION"
+	CorruptState      = "CORRUPT_STATE"
+)
+
+// ChaincodeError is an error reported by the chaincode. The chaincode returns its errors as
+// JSON text; both clients decode it into a ChaincodeError.
+type ChaincodeError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"` // argument at fault, when known
+}
+
+func (e *ChaincodeError) Error() string {
+	if e.Field != "" {
+		return e.Code + ": " + e.Message + " (" + e.Field + ")"
+	}
+	return e.Code + ": " + e.Message
+}
+
+// chaincodeError decodes a structured chaincode error, leaving other errors as they are.
+// A peer may wrap the chaincode error text, e.g. "Error when querying chaincode: ... {...}".
+func chaincodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	text := err.Error()
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return err
+	}
+	var ce ChaincodeError
+	if json.Unmarshal([]byte(text[start:end+1]), &ce) != nil || ce.Code == "" {
+		return err
+	}
+	return &ce
+}
@@ -0,0 +1,214 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/GHSagarnil/TracknTrace3/chaincode/tnt"
+)
+
+// MockClient runs the chaincode in process on an in memory ledger, for local testing without a network.
+// The ledger keeps private data collections and passes the transient map of a call, as a peer does.
+// Each call is submitted with the certificate of the user in its arguments, see MockCertificate, by
+// an administrator of the ledger.
+type MockClient struct {
+	cc   *tnt.TnT
+	stub *mockStub
+	mu   sync.Mutex
+}
+
+// mockStub is the in memory ledger, with private data, and the transient map and submitting
+// certificate of the current call
+type mockStub struct {
+	state     map[string][]byte
+	private   map[string]map[string][]byte // collection to key to value
+	transient map[string][]byte
+	creator   []byte
+}
+
+func newMockStub() *mockStub {
+	return &mockStub{state: make(map[string][]byte), private: make(map[string]map[string][]byte)}
+}
+
+func (s *mockStub) GetState(key string) ([]byte, error) {
+	return s.state[key], nil
+}
+
+func (s *mockStub) PutState(key string, value []byte) error {
+	s.state[key] = value
+	return nil
+}
+
+func (s *mockStub) DelState(key string) error {
+	delete(s.state, key)
+	return nil
+}
+
+func (s *mockStub) GetPrivateData(collection, key string) ([]byte, error) {
+	return s.private[collection][key], nil
+}
+
+func (s *mockStub) PutPrivateData(collection, key string, value []byte) error {
+	if s.private[collection] == nil {
+		s.private[collection] = make(map[string][]byte)
+	}
+	s.private[collection][key] = value
+	return nil
+}
+
+func (s *mockStub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+func (s *mockStub) GetCreator() ([]byte, error) {
+	return s.creator, nil
+}
+
+// IsAdmin is always true, the mock client administers its own ledger
+func (s *mockStub) IsAdmin() (bool, error) {
+	return true, nil
+}
+
+// mockState is the mock ledger as SaveState writes it. Files from before private data hold the
+// world state alone, as a flat object.
+type mockState struct {
+	State       map[string][]byte            `json:"state"`
+	PrivateData map[string]map[string][]byte `json:"privateData,omitempty"`
+}
+
+// MockCertificate is the certificate user submits with on a mock ledger
+func MockCertificate(user string) []byte {
+	return []byte("mock certificate of " + user)
+}
+
+// NewMockClient sets cc up on an empty mock ledger.
+// initArgs are the InitLedger arguments, user and role pairs. The users are bound to their
+// MockCertificate, as registerUser does.
+func NewMockClient(cc *tnt.TnT, initArgs []string) (*MockClient, error) {
+	c := &MockClient{cc: cc, stub: newMockStub()}
+	if _, err := cc.InitLedger(c.stub, initArgs); err != nil {
+		return nil, chaincodeError(err)
+	}
+	for i := 0; i+1 < len(initArgs); i += 2 {
+		c.stub.creator = MockCertificate(initArgs[i])
+		payload, err := cc.Call(c.stub, "getCallerIdentity", nil)
+		if err != nil {
+			return nil, chaincodeError(err)
+		}
+		var caller tnt.Caller_Identity
+		if err := json.Unmarshal(payload, &caller); err != nil {
+			return nil, err
+		}
+		if _, err := cc.Call(c.stub, "registerUser", []string{initArgs[i], initArgs[i+1], caller.Identity}); err != nil {
+			return nil, chaincodeError(err)
+		}
+	}
+	c.stub.creator = nil
+	return c, nil
+}
+
+// NewMockClientFromState restores a mock ledger saved with SaveState, without running InitLedger
+func NewMockClientFromState(cc *tnt.TnT, r io.Reader) (*MockClient, error) {
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&fields); err != nil {
+		return nil, err
+	}
+	var saved mockState
+	if raw, ok := fields["state"]; ok && len(raw) > 0 && raw[0] == '{' {
+		if err := json.Unmarshal(raw, &saved.State); err != nil {
+			return nil, err
+		}
+		if raw, ok := fields["privateData"]; ok {
+			if err := json.Unmarshal(raw, &saved.PrivateData); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		saved.State = make(map[string][]byte, len(fields))
+		for key, raw := range fields {
+			var value []byte
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return nil, err
+			}
+			saved.State[key] = value
+		}
+	}
+
+	c := &MockClient{cc: cc, stub: newMockStub()}
+	for key, value := range saved.State {
+		c.stub.PutState(key, value)
+	}
+	for collection, values := range saved.PrivateData {
+		for key, value := range values {
+			c.stub.PutPrivateData(collection, key, value)
+		}
+	}
+	return c, nil
+}
+
+// SaveState writes the mock ledger, private data included, so a later process can carry on with it
+func (c *MockClient) SaveState(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return json.NewEncoder(w).Encode(mockState{State: c.stub.state, PrivateData: c.stub.private})
+}
+
+// Invoke runs an invoke function as one mock transaction.
+// Unlike on a peer, writes made before an invoke fails are not rolled back.
+func (c *MockClient) Invoke(function string, args []string) ([]byte, error) {
+	return c.InvokeTransient(function, args, nil)
+}
+
+// InvokeTransient runs an invoke function with a transient map
+func (c *MockClient) InvokeTransient(function string, args []string, transient map[string][]byte) ([]byte, error) {
+	return c.call(true, function, args, transient)
+}
+
+// Query runs a query function
+func (c *MockClient) Query(function string, args []string) ([]byte, error) {
+	return c.QueryTransient(function, args, nil)
+}
+
+// QueryTransient runs a query function with a transient map
+func (c *MockClient) QueryTransient(function string, args []string, transient map[string][]byte) ([]byte, error) {
+	return c.call(false, function, args, transient)
+}
+
+// call runs function, failing as a peer does when it is not the kind of function asked for
+func (c *MockClient) call(invoke bool, function string, args []string, transient map[string][]byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if isInvoke, ok := tnt.IsInvokeFunction(function); !ok || isInvoke != invoke {
+		kind := "query"
+		if invoke {
+			kind = "invocation"
+		}
+		return nil, &ChaincodeError{Code: InvalidArgument, Message: "Received unknown function " + kind, Field: "function"}
+	}
+	c.stub.transient = transient
+	if i := tnt.UserArgument(function); i >= 0 && i < len(args) {
+		c.stub.creator = MockCertificate(args[i])
+	}
+	payload, err := c.cc.Call(c.stub, function, args)
+	c.stub.transient = nil
+	c.stub.creator = nil
+	return payload, chaincodeError(err)
+}
@@ -0,0 +1,96 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/GHSagarnil/TracknTrace3/chaincode/tnt"
+)
+
+var assemblyArgs = []string{"A1", "S1", "HOLDER", "F1", "L1", "C1", "W1", "K1", "A1", "S1", "Plant1", "1", "20170608101500", "", "", "", "al"}
+
+func newMock(t *testing.T) *MockClient {
+	t.Helper()
+	c, err := NewMockClient(new(tnt.TnT), []string{"al", tnt.ASSEMBLYLINE_ROLE, "pl", tnt.PACKAGELINE_ROLE})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestMockClient(t *testing.T) {
+	c := newMock(t)
+	if _, err := c.Invoke("createAssembly", assemblyArgs); err != nil {
+		t.Fatal(err)
+	}
+	payload, err := c.Query("getAssemblyByID", []string{"A1", "al"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var assembly tnt.AssemblyLine
+	if err := json.Unmarshal(payload, &assembly); err != nil || assembly.DeviceSerialNo != "S1" {
+		t.Errorf("getAssemblyByID = %s, %v", payload, err)
+	}
+
+	// A peer runs invokes and queries as asked only
+	if _, err := c.Query("createAssembly", assemblyArgs); err == nil {
+		t.Error("invoke function run as a query")
+	}
+	if _, err := c.Invoke("getAssemblyByID", []string{"A1", "al"}); err == nil {
+		t.Error("query function run as an invoke")
+	}
+	if _, err := c.Query("noSuchFunction", nil); err == nil {
+		t.Error("unknown function run")
+	}
+}
+
+func TestMockClientState(t *testing.T) {
+	c := newMock(t)
+	if _, err := c.Invoke("createAssembly", assemblyArgs); err != nil {
+		t.Fatal(err)
+	}
+	var saved bytes.Buffer
+	if err := c.SaveState(&saved); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := NewMockClientFromState(new(tnt.TnT), bytes.NewReader(saved.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := restored.Query("getAssemblyByID", []string{"A1", "al"}); err != nil {
+		t.Errorf("assembly lost in the saved state: %v", err)
+	}
+
+	// Files from before private data hold the world state alone
+	var fields struct {
+		State map[string][]byte `json:"state"`
+	}
+	json.Unmarshal(saved.Bytes(), &fields)
+	flat, _ := json.Marshal(fields.State)
+	restored, err = NewMockClientFromState(new(tnt.TnT), bytes.NewReader(flat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := restored.Query("getAssemblyByID", []string{"A1", "al"}); err != nil {
+		t.Errorf("assembly lost in a flat state file: %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+// param is one chaincode argument, set with --Name or positionally in declaration order
+type param struct {
+	Name     string
+	Usage    string
+	Required bool
+	Optional bool // trailing argument left out when not set
+	Bool     bool // set by the flag alone, sent as "true"
+	// Transient values go in the transient map under Key instead of the arguments, so they stay
+	// out of the transaction; the argument is sent empty
+	Transient bool
+	Key       string
+}
+
+// userParam stands for the calling user, taken from --user
+var userParam = param{Name: "user"}
+
+func req(name, usage string) param { return param{Name: name, Usage: usage, Required: true} }
+func opt(name, usage string) param { return param{Name: name, Usage: usage} }
+
+var includeCancelled = param{Name: "include-cancelled", Usage: "include cancelled records", Optional: true, Bool: true}
+
+// command maps a tnt command line to a chaincode function
+type command struct {
+	Path     string
+	Function string
+	Invoke   bool
+	Summary  string
+	Params   []param
+	Columns  []string // default table columns, all when empty
+}
+
+var assemblyFields = []param{
+	req("id", "assembly ID"),
+	req("serial", "device serial number"),
+	req("device-type", "device type, e.g. HOLDER or CHARGER"),
+	opt("filament-batch", "filament batch"),
+	opt("led-batch", "LED batch"),
+	opt("circuit-board-batch", "circuit board batch"),
+	opt("wire-batch", "wire batch"),
+	opt("casing-batch", "casing batch"),
+	opt("adaptor-batch", "adaptor batch"),
+	opt("stick-pod-batch", "stick pod batch"),
+	opt("plant", "manufacturing plant"),
+	req("status", "assembly status"),
+	req("date", "assembly date YYYYMMDDHHMMSS (UTC) or ISO-8601, e.g. 2017-06-08T15:45:00+05:30"),
+	opt("package", "case ID of the package"),
+	opt("info1", "free text"),
+	opt("info2", "free text"),
+	userParam,
+}
+
+var packageFields = []param{
+	req("case-id", "case ID"),
+	opt("holder", "holder assembly ID"),
+	opt("charger", "charger assembly ID"),
+	req("status", "package status, 7 Packaged or 9 Returned (update only)"),
+	req("date", "packaging date YYYYMMDDHHMMSS (UTC) or ISO-8601, e.g. 2017-06-08T15:45:00+05:30"),
+	{Name: "address", Usage: `shipping address JSON e.g. {"line1":"1 Main St","city":"Kolkata","postalCode":"700001","country":"IN"}, ` +
+		"sent as transient data and kept in the private data collection", Transient: true, Key: "shippingToAddress"},
+	req("assembly-status", "status set on the packed assemblies"),
+	opt("info1", "free text"),
+	opt("info2", "free text"),
+	userParam,
+}
+
+var assemblyColumns = []string{"assemblyId", "deviceSerialNo", "deviceType", "manufacturingPlant", "assemblyStatus", "assemblyDate", "assemblyPackage"}
+var packageColumns = []string{"caseId", "holderAssemblyId", "chargerAssemblyId", "packageStatus", "packagingDate", "packageRMAId"}
+
+var commands = []command{
+	{Path: "assembly create", Function: "createAssembly", Invoke: true, Summary: "create an assembly", Params: assemblyFields},
+	{Path: "assembly update", Function: "updateAssemblyByID", Invoke: true, Summary: "update an assembly", Params: assemblyFields},
+	{Path: "assembly reassign-serial", Function: "reassignDeviceSerialNo", Invoke: true, Summary: "give an assembly a new serial number",
+		Params: []param{req("id", "assembly ID"), req("serial", "new serial number"), req("reason", "reason"), userParam}},
+	{Path: "assembly cancel", Function: "cancelAssembly", Invoke: true, Summary: "cancel an assembly",
+		Params: []param{req("id", "assembly ID"), req("reason", "DUPLICATE, DATA_ERROR, DAMAGED, ORDER_WITHDRAWN or OTHER"), opt("comment", "comment"), userParam}},
+	{Path: "assembly scrap", Function: "scrapAssembly", Invoke: true, Summary: "record the destruction of an assembly",
+		Params: []param{req("id", "assembly ID"), req("method", "destruction method"), req("witness", "witnessing user"), req("date", "scrap date YYYYMMDDHHMMSS"), opt("comment", "comment"), userParam}},
+	{Path: "assembly get", Function: "getAssemblyByID", Summary: "show an assembly",
+		Params: []param{req("id", "assembly ID"), userParam}},
+	{Path: "assembly list", Function: "getAllAssemblies", Summary: "list all assemblies",
+		Params: []param{userParam, includeCancelled}, Columns: assemblyColumns},
+	{Path: "assembly history", Function: "getAssemblyLineHistoryByID", Summary: "show every version of an assembly",
+		Params: []param{req("id", "assembly ID"), userParam}, Columns: append([]string{"assemblyLastUpdateOn", "assemblyLastUpdatedBy"}, assemblyColumns...)},
+	{Path: "assembly by-date", Function: "getAssembliesByDate", Summary: "list assemblies built between two dates",
+		Params: []param{req("from", "from YYYYMMDDHHMMSS"), req("to", "to YYYYMMDDHHMMSS"), userParam, includeCancelled}, Columns: assemblyColumns},
+	{Path: "assembly by-serial", Function: "getAssembliesBySerialNo", Summary: "find assemblies by serial number",
+		Params: []param{req("serial", "serial number"), opt("device-type", "device type, all when empty"), userParam, includeCancelled}, Columns: assemblyColumns},
+	{Path: "assembly verify", Function: "verifyAssembly", Summary: "check an assembly against its content hash",
+		Params: []param{req("id", "assembly ID"), opt("exported", "exported assembly JSON to compare"), userParam}},
+
+	{Path: "package create", Function: "createPackage", Invoke: true, Summary: "pack assemblies into a case", Params: packageFields},
+	{Path: "package update", Function: "updatePackage", Invoke: true, Summary: "update a package", Params: packageFields},
+	{Path: "package cancel", Function: "cancelPackage", Invoke: true, Summary: "cancel a package and free its assemblies",
+		Params: []param{req("case-id", "case ID"), req("reason", "DUPLICATE, DATA_ERROR, DAMAGED, ORDER_WITHDRAWN or OTHER"), opt("comment", "comment"), userParam}},
+	{Path: "package get", Function: "getPackageByID", Summary: "show a package",
+		Params: []param{req("case-id", "case ID")}},
+	{Path: "package list", Function: "getAllPackages", Summary: "list all packages",
+		Params: []param{userParam, includeCancelled}, Columns: packageColumns},
+	{Path: "package history", Function: "getPackageLineHistoryByID", Summary: "show every version of a package",
+		Params: []param{req("case-id", "case ID"), userParam}, Columns: append([]string{"packageLastUpdateOn", "packageLastUpdatedBy"}, packageColumns...)},
+	{Path: "package by-date", Function: "getPackagesByDate", Summary: "list packages packed between two dates",
+		Params: []param{req("from", "from YYYYMMDDHHMMSS"), req("to", "to YYYYMMDDHHMMSS"), userParam, includeCancelled}, Columns: packageColumns},
+	{Path: "package by-assembly", Function: "getPackagesByAssemblyId", Summary: "find the packages of an assembly",
+		Params: []param{req("assembly-type", "HolderAssemblyId or ChargerAssemblyId"), req("assembly-id", "assembly ID"), userParam, includeCancelled}, Columns: packageColumns},
+	{Path: "package proof", Function: "getPackageInclusionProof", Summary: "Merkle proof that a device is in a case",
+		Params: []param{req("case-id", "case ID"), req("serial", "device serial number"), userParam}},
+
+	{Path: "trace batch", Function: "getAssembliesByBatchNumber", Summary: "list assemblies built with a component batch",
+		Params: []param{req("batch-type", "e.g. LedBatchId"), req("batch", "batch number"), userParam, includeCancelled}, Columns: assemblyColumns},
+	{Path: "trace batch-range", Function: "getAssembliesByBatchNumberAndByDate", Summary: "list assemblies built with a batch between two dates",
+		Params: []param{req("batch-type", "e.g. LedBatchId"), req("batch", "batch number"), req("from", "from YYYYMMDDHHMMSS"), req("to", "to YYYYMMDDHHMMSS"), userParam, includeCancelled}, Columns: assemblyColumns},
+	{Path: "product check", Function: "getProductAuthenticity", Summary: "authenticity check by serial number",
+		Params: []param{req("serial", "device serial number"), userParam}},
+
+	{Path: "batch receive", Function: "receiveComponentBatch", Invoke: true, Summary: "record a goods receipt of a component batch",
+		Params: []param{req("batch-type", "e.g. LedBatchId"), req("batch", "batch number"), req("quantity", "quantity received"), req("date", "received date YYYYMMDDHHMMSS"), userParam}},
+	{Path: "batch scrap", Function: "scrapComponentBatch", Invoke: true, Summary: "record the destruction of loose components",
+		Params: []param{req("batch-type", "e.g. LedBatchId"), req("batch", "batch number"), req("quantity", "quantity scrapped"), req("method", "destruction method"), req("witness", "witnessing user"), req("date", "scrap date YYYYMMDDHHMMSS"), opt("comment", "comment"), userParam}},
+	{Path: "batch recall", Function: "recallBatch", Invoke: true, Summary: "recall a component batch",
+		Params: []param{req("batch-type", "e.g. LedBatchId"), req("batch", "batch number"), req("reason", "reason"), userParam}},
+	{Path: "batch reconcile", Function: "getBatchReconciliation", Summary: "received vs consumed vs scrapped vs remaining",
+		Params: []param{req("batch-type", "e.g. LedBatchId"), req("batch", "batch number"), userParam}},
+
+	{Path: "stats assemblies", Function: "getAssemblyCounts", Summary: "assembly counts by plant, deviceType, status, day or week",
+		Params: []param{opt("group-by", "comma separated, e.g. plant,deviceType,day"), opt("from", "from YYYYMMDDHHMMSS"), opt("to", "to YYYYMMDDHHMMSS"), userParam, includeCancelled}},
+	{Path: "stats packages", Function: "getPackageCounts", Summary: "package counts by status, day or week",
+		Params: []param{opt("group-by", "comma separated, e.g. status,week"), opt("from", "from YYYYMMDDHHMMSS"), opt("to", "to YYYYMMDDHHMMSS"), userParam, includeCancelled}},
+	{Path: "stats cycle-times", Function: "getCycleTimes", Summary: "cycle time percentiles between statuses",
+		Params: []param{opt("group-by", "comma separated, e.g. plant,deviceType"), opt("from", "from YYYYMMDDHHMMSS"), opt("to", "to YYYYMMDDHHMMSS"), userParam, includeCancelled}},
+	{Path: "stats stuck", Function: "getStuckAssemblies", Summary: "assemblies in their status longer than a threshold",
+		Params: []param{opt("status", "status, all when empty"), req("threshold-hours", "threshold in hours"), userParam}},
+
+	{Path: "plant set-timezone", Function: "setPlantTimeZone", Invoke: true, Summary: "set the time zone the local dates of a plant are shown in",
+		Params: []param{req("plant", "manufacturing plant"), opt("time-zone", "IANA time zone e.g. Asia/Kolkata, or UTC offset e.g. +05:30; empty removes it"), userParam}},
+	{Path: "plant timezones", Function: "getPlantTimeZones", Summary: "time zones of the plants",
+		Params: []param{userParam}},
+
+	{Path: "schema migrate", Function: "migrateSchema", Invoke: true, Summary: "move stored records to the current schema version, run until done",
+		Params: []param{req("page-size", "assemblies, packages, RMAs or shipments per run, at most 100"), userParam}},
+	{Path: "schema status", Function: "getSchemaMigration", Summary: "progress of the schema migration",
+		Params: []param{userParam}},
+
+	{Path: "user register", Function: "registerUser", Invoke: true, Summary: "register a user, or change its role - ledger administrators only",
+		Params: []param{req("name", "user name"), req("role", "role, e.g. carrier_role"), req("identity", "the identity the user submits with, from tnt user identity")}},
+	{Path: "user identity", Function: "getCallerIdentity", Summary: "the identity submitting the command"},
+
+	{Path: "functions list", Function: "listFunctions", Summary: "the chaincode functions with their arguments and roles",
+		Columns: []string{"name", "invoke", "roles"}},
+	{Path: "functions metrics", Function: "getFunctionMetrics", Summary: "call counts and latencies per function on the peer"},
+}
@@ -0,0 +1,429 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Command tnt is the command line client for the TnT chaincode.
+//
+//	tnt assembly create --id ASM0101 --serial DEV0101 --device-type HOLDER --led-batch LED0002 ... --status 1 --date 20170608101500
+//	tnt package create --case-id CAS0001 --holder ASM0101 --charger ASM0102 --status 1 --date 20170609101500 --assembly-status 7
+//	tnt trace batch LedBatchId LED0002
+//	tnt query getAllAssemblies aluser1
+//
+// Arguments are set with named flags or positionally in the order listed by "tnt <command> -h".
+// The calling user comes from --user (or TNT_USER). Results print as a table, or as JSON with -o json.
+// --dry-run checks an invoke and lists every problem found, without submitting it.
+// Customer details, such as --address, are sent as transient data and stay out of the transaction;
+// --transient adds transient data to any call. Only the mock transport can pass it.
+//
+// --transport rest (default) talks to a peer's REST API at --peer for chaincode --chaincode.
+// --transport mock runs the chaincode in process on a mock stub; the mock ledger is kept in
+// --state between runs and starts with the users of --mock-users.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/GHSagarnil/TracknTrace3/chaincode/tnt"
+	"github.com/GHSagarnil/TracknTrace3/client"
+)
+
+const defaultMockUsers = "aluser1:assemblyline_role,pluser1:packageline_role,qauser1:qaviewer_role," +
+	"qiuser1:qainspector_role,consumer1:consumer_role,carrier1:carrier_role,distributor1:distributor_role,retailer1:retailer_role"
+
+// globals are the flags every command takes
+type globals struct {
+	user      string
+	output    string
+	transport string
+	peer      string
+	chaincode string
+	state     string
+	mockUsers string
+	dryRun    bool
+	transient string
+}
+
+func (g *globals) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.user, "user", os.Getenv("TNT_USER"), "calling user")
+	fs.StringVar(&g.output, "o", "table", "output: table or json")
+	fs.StringVar(&g.transport, "transport", envOr("TNT_TRANSPORT", "rest"), "rest or mock")
+	fs.StringVar(&g.peer, "peer", envOr("TNT_PEER", "http://localhost:7050"), "peer REST endpoint")
+	fs.StringVar(&g.chaincode, "chaincode", os.Getenv("TNT_CHAINCODE"), "chaincode name")
+	fs.StringVar(&g.state, "state", envOr("TNT_MOCK_STATE", "tnt-mock.json"), "mock ledger file")
+	fs.StringVar(&g.mockUsers, "mock-users", defaultMockUsers, "user:role pairs of a new mock ledger")
+	fs.BoolVar(&g.dryRun, "dry-run", false, "check an invoke and report every problem, without submitting it")
+	fs.StringVar(&g.transient, "transient", "", `transient data as a JSON object of strings, e.g. {"customerName":"Jane Doe"}`)
+}
+
+// transientMap decodes --transient
+func (g *globals) transientMap() (map[string][]byte, error) {
+	transient := make(map[string][]byte)
+	if g.transient == "" {
+		return transient, nil
+	}
+	var values map[string]string
+	if err := json.Unmarshal([]byte(g.transient), &values); err != nil {
+		return nil, fmt.Errorf("--transient must be a JSON object of strings: %v", err)
+	}
+	for key, v := range values {
+		transient[key] = []byte(v)
+	}
+	return transient, nil
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "tnt:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(out)
+		return nil
+	}
+	if args[0] == "invoke" || args[0] == "query" {
+		return runRaw(args[0] == "invoke", args[1:], out)
+	}
+
+	cmd, rest := findCommand(args)
+	if cmd == nil {
+		usage(out)
+		return fmt.Errorf("unknown command %q", strings.Join(args, " "))
+	}
+
+	var g globals
+	fs := flag.NewFlagSet("tnt "+cmd.Path, flag.ContinueOnError)
+	fs.SetOutput(out)
+	g.register(fs)
+	values := make(map[string]*string)
+	flags := make(map[string]*bool)
+	var names []string
+	for _, p := range cmd.Params {
+		if p == userParam {
+			continue
+		}
+		names = append(names, p.Name)
+		if p.Bool {
+			flags[p.Name] = fs.Bool(p.Name, false, p.Usage)
+		} else {
+			values[p.Name] = fs.String(p.Name, "", p.Usage)
+		}
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(out, "tnt %s [flags] %s\n  %s\n\n", cmd.Path, strings.Join(names, " "), cmd.Summary)
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseInterleaved(fs, rest)
+	if err == flag.ErrHelp {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	transient, err := g.transientMap()
+	if err != nil {
+		return err
+	}
+	chaincodeArgs, err := buildArgs(cmd, fs, values, flags, positional, g.user, transient)
+	if err != nil {
+		return err
+	}
+
+	c, save, err := connect(g)
+	if err != nil {
+		return err
+	}
+	if cmd.Invoke && g.dryRun {
+		return dryRun(c, cmd.Function, chaincodeArgs, transient, g.output, out)
+	}
+	return call(c, save, cmd.Invoke, cmd.Function, chaincodeArgs, transient, g.output, cmd.Columns, out)
+}
+
+// runRaw calls any chaincode function with the arguments exactly as given
+func runRaw(invoke bool, args []string, out io.Writer) error {
+	var g globals
+	fs := flag.NewFlagSet("tnt raw", flag.ContinueOnError)
+	fs.SetOutput(out)
+	g.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(out, "tnt invoke|query [flags] FUNCTION [ARG...]")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterleaved(fs, args)
+	if err == flag.ErrHelp {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return errors.New("function name is required")
+	}
+	transient, err := g.transientMap()
+	if err != nil {
+		return err
+	}
+
+	c, save, err := connect(g)
+	if err != nil {
+		return err
+	}
+	if invoke && g.dryRun {
+		return dryRun(c, positional[0], positional[1:], transient, g.output, out)
+	}
+	return call(c, save, invoke, positional[0], positional[1:], transient, g.output, nil, out)
+}
+
+func call(c client.Client, save func() error, invoke bool, function string, args []string, transient map[string][]byte, output string, columns []string, out io.Writer) error {
+	if !invoke {
+		payload, err := client.Query(c, function, args, transient)
+		if err != nil {
+			return err
+		}
+		return printResult(out, payload, output, columns)
+	}
+
+	payload, err := client.Invoke(c, function, args, transient)
+	if err != nil {
+		return err
+	}
+	if err := save(); err != nil {
+		return err
+	}
+	if len(payload) > 0 {
+		fmt.Fprintf(out, "ok %s\n", payload)
+	} else {
+		fmt.Fprintln(out, "ok")
+	}
+	return nil
+}
+
+// dryRun prints the chaincode's report on an invoke, failing when the invoke would fail
+func dryRun(c client.Client, function string, args []string, transient map[string][]byte, output string, out io.Writer) error {
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	payload, err := client.Query(c, "dryRun", []string{function, string(encoded)}, transient)
+	if err != nil {
+		return err
+	}
+
+	var report struct {
+		Valid    bool              `json:"valid"`
+		Problems []json.RawMessage `json:"problems"`
+	}
+	if err := json.Unmarshal(payload, &report); err != nil {
+		return err
+	}
+	if output == "json" {
+		if err := printResult(out, payload, output, nil); err != nil {
+			return err
+		}
+	} else if report.Valid {
+		fmt.Fprintln(out, "ok, "+function+" would succeed")
+	} else {
+		problems, _ := json.Marshal(report.Problems)
+		if err := printResult(out, problems, output, []string{"code", "field", "message"}); err != nil {
+			return err
+		}
+	}
+	if !report.Valid {
+		return fmt.Errorf("dry run of %s found %d problem(s)", function, len(report.Problems))
+	}
+	return nil
+}
+
+// findCommand matches the longest command path at the start of args
+func findCommand(args []string) (*command, []string) {
+	for words := 2; words >= 1; words-- {
+		if len(args) < words {
+			continue
+		}
+		path := strings.Join(args[:words], " ")
+		for i := range commands {
+			if commands[i].Path == path {
+				return &commands[i], args[words:]
+			}
+		}
+	}
+	return nil, nil
+}
+
+// parseInterleaved lets flags and positional arguments be mixed
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// buildArgs lays the flag and positional values out in chaincode argument order, putting the
+// Transient ones in transient and leaving their argument empty
+func buildArgs(cmd *command, fs *flag.FlagSet, values map[string]*string, flags map[string]*bool, positional []string, user string, transient map[string][]byte) ([]string, error) {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	// Positional arguments fill the parameters not given as flags, in order
+	for _, p := range cmd.Params {
+		if len(positional) == 0 {
+			break
+		}
+		if p == userParam || p.Bool || set[p.Name] {
+			continue
+		}
+		*values[p.Name] = positional[0]
+		set[p.Name] = true
+		positional = positional[1:]
+	}
+	if len(positional) > 0 {
+		return nil, fmt.Errorf("unexpected arguments %s", strings.Join(positional, " "))
+	}
+
+	var args []string
+	for _, p := range cmd.Params {
+		switch {
+		case p == userParam:
+			if user == "" {
+				return nil, errors.New("--user (or TNT_USER) is required")
+			}
+			args = append(args, user)
+		case p.Bool:
+			if *flags[p.Name] {
+				args = append(args, "true")
+			} else if !p.Optional {
+				args = append(args, "false")
+			}
+		default:
+			v := *values[p.Name]
+			if p.Required && v == "" {
+				return nil, fmt.Errorf("--%s is required", p.Name)
+			}
+			if p.Transient {
+				if v != "" {
+					transient[p.Key] = []byte(v)
+				}
+				args = append(args, "")
+				continue
+			}
+			if p.Optional && v == "" {
+				continue
+			}
+			args = append(args, v)
+		}
+	}
+	return args, nil
+}
+
+// connect returns the client for the chosen transport and a function persisting the mock ledger
+func connect(g globals) (client.Client, func() error, error) {
+	switch g.transport {
+	case "rest":
+		if g.chaincode == "" {
+			return nil, nil, errors.New("--chaincode (or TNT_CHAINCODE) is required")
+		}
+		return client.NewRESTClient(g.peer, g.chaincode, g.user), func() error { return nil }, nil
+	case "mock":
+		c, err := openMock(g)
+		if err != nil {
+			return nil, nil, err
+		}
+		return c, func() error { return saveMock(c, g.state) }, nil
+	}
+	return nil, nil, fmt.Errorf("unknown transport %q, expecting rest or mock", g.transport)
+}
+
+func openMock(g globals) (*client.MockClient, error) {
+	f, err := os.Open(g.state)
+	if err == nil {
+		defer f.Close()
+		return client.NewMockClientFromState(new(tnt.TnT), f)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var initArgs []string
+	for _, pair := range strings.Split(g.mockUsers, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid --mock-users entry %q, expecting user:role", pair)
+		}
+		initArgs = append(initArgs, parts[0], parts[1])
+	}
+	c, err := client.NewMockClient(new(tnt.TnT), initArgs)
+	if err != nil {
+		return nil, err
+	}
+	return c, saveMock(c, g.state)
+}
+
+func saveMock(c *client.MockClient, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := c.SaveState(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func usage(out io.Writer) {
+	fmt.Fprintln(out, "usage: tnt <command> [flags] [args]")
+	fmt.Fprintln(out)
+	paths := make([]string, 0, len(commands))
+	summaries := make(map[string]string)
+	for _, c := range commands {
+		paths = append(paths, c.Path)
+		summaries[c.Path] = c.Summary
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		fmt.Fprintf(out, "  %-26s %s\n", p, summaries[p])
+	}
+	fmt.Fprintf(out, "  %-26s %s\n", "invoke FUNCTION [ARG...]", "call any invoke function with raw arguments")
+	fmt.Fprintf(out, "  %-26s %s\n", "query FUNCTION [ARG...]", "call any query function with raw arguments")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `Run "tnt <command> -h" for the flags of a command.`)
+}
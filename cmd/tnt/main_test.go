@@ -0,0 +1,197 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parse parses a command line of cmdPath as run does, returning the chaincode arguments
+func parse(t *testing.T, cmdPath string, user string, args ...string) ([]string, map[string][]byte, error) {
+	t.Helper()
+	cmd, _ := findCommand(strings.Fields(cmdPath))
+	if cmd == nil {
+		t.Fatalf("no command %q", cmdPath)
+	}
+	fs := flag.NewFlagSet(cmdPath, flag.ContinueOnError)
+	values := make(map[string]*string)
+	flags := make(map[string]*bool)
+	for _, p := range cmd.Params {
+		if p == userParam {
+			continue
+		}
+		if p.Bool {
+			flags[p.Name] = fs.Bool(p.Name, false, p.Usage)
+		} else {
+			values[p.Name] = fs.String(p.Name, "", p.Usage)
+		}
+	}
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transient := make(map[string][]byte)
+	chaincodeArgs, err := buildArgs(cmd, fs, values, flags, positional, user, transient)
+	return chaincodeArgs, transient, err
+}
+
+func TestBuildArgs(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		args []string
+		want []string
+	}{
+		{"assembly get", []string{"A1"}, []string{"A1", "al"}},
+		{"assembly get", []string{"--id", "A1"}, []string{"A1", "al"}},
+		{"assembly list", nil, []string{"al"}},
+		{"assembly list", []string{"--include-cancelled"}, []string{"al", "true"}},
+		{"assembly by-serial", []string{"S1"}, []string{"S1", "", "al"}},
+		{"assembly cancel", []string{"--reason", "DUPLICATE", "A1"}, []string{"A1", "DUPLICATE", "", "al"}},
+		{"trace batch", []string{"LedBatchId", "--include-cancelled", "L1"}, []string{"LedBatchId", "L1", "al", "true"}},
+	}
+	for _, tc := range tests {
+		got, _, err := parse(t, tc.cmd, "al", tc.args...)
+		if err != nil {
+			t.Errorf("%s %q: %v", tc.cmd, tc.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s %q = %q, want %q", tc.cmd, tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestBuildArgsErrors(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		user string
+		args []string
+		want string
+	}{
+		{"assembly get", "al", nil, "--id is required"},
+		{"assembly get", "", []string{"A1"}, "--user"},
+		{"assembly get", "al", []string{"A1", "A2"}, "unexpected arguments A2"},
+		{"assembly cancel", "al", []string{"--id", "A1"}, "--reason is required"},
+	}
+	for _, tc := range tests {
+		_, _, err := parse(t, tc.cmd, tc.user, tc.args...)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s %q: err = %v, want %q", tc.cmd, tc.args, err, tc.want)
+		}
+	}
+}
+
+func TestTransientArgs(t *testing.T) {
+	address := `{"line1":"1 Main St","city":"Kolkata","postalCode":"700001","country":"IN"}`
+	got, transient, err := parse(t, "package create", "pl", "--case-id", "C1", "--holder", "H1", "--charger", "C2",
+		"--status", "7", "--date", "20170609101500", "--address", address, "--assembly-status", "7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"C1", "H1", "C2", "7", "20170609101500", "", "7", "", "", "pl"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("args = %q, want %q with the address slot empty", got, want)
+	}
+	if string(transient["shippingToAddress"]) != address {
+		t.Errorf("transient shippingToAddress = %q, want %q", transient["shippingToAddress"], address)
+	}
+}
+
+// runMock runs a command line on the mock ledger in dir
+func runMock(dir string, args ...string) (string, error) {
+	var out bytes.Buffer
+	args = append(args, "--transport", "mock", "--state", filepath.Join(dir, "ledger.json"))
+	err := run(args, &out)
+	return out.String(), err
+}
+
+func TestMockTransport(t *testing.T) {
+	dir := t.TempDir()
+	create := []string{"assembly", "create", "A1", "S1", "HOLDER", "--user", "aluser1", "--status", "1", "--date", "20170608101500",
+		"--filament-batch", "F1", "--led-batch", "L1", "--circuit-board-batch", "C1", "--wire-batch", "W1",
+		"--casing-batch", "K1", "--adaptor-batch", "A1", "--stick-pod-batch", "S1"}
+
+	out, err := runMock(dir, append(create, "--dry-run")...)
+	if err != nil || !strings.Contains(out, "ok, createAssembly would succeed") {
+		t.Fatalf("dry run: %q, %v", out, err)
+	}
+	if out, err := runMock(dir, "assembly", "get", "A1", "--user", "aluser1", "-o", "json"); err == nil {
+		t.Fatalf("dry run created the assembly: %s", out)
+	}
+
+	if out, err := runMock(dir, create...); err != nil || !strings.HasPrefix(out, "ok") {
+		t.Fatalf("create: %q, %v", out, err)
+	}
+	// The ledger is kept in the state file between runs
+	out, err = runMock(dir, "assembly", "get", "A1", "--user", "aluser1", "-o", "json")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	var assembly struct {
+		AssemblyId     string `json:"assemblyId"`
+		DeviceSerialNo string `json:"deviceSerialNo"`
+	}
+	if err := json.Unmarshal([]byte(out), &assembly); err != nil || assembly.AssemblyId != "A1" || assembly.DeviceSerialNo != "S1" {
+		t.Errorf("get = %q, %v", out, err)
+	}
+
+	out, err = runMock(dir, append(create, "--dry-run")...)
+	if err == nil || !strings.Contains(out, "ALREADY_EXISTS") {
+		t.Errorf("dry run of a duplicate: %q, %v, want the problem listed and an error", out, err)
+	}
+
+	out, err = runMock(dir, "assembly", "list", "--user", "aluser1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "assemblyId") || !strings.HasPrefix(lines[1], "A1") {
+		t.Errorf("list table =\n%s", out)
+	}
+}
+
+func TestPrintTable(t *testing.T) {
+	tests := []struct {
+		payload string
+		columns []string
+		want    string
+	}{
+		{`[{"id":"A1","n":1},{"id":"A22","n":null}]`, nil, "id   n\nA1   1\nA22  \n"},
+		{`[{"id":"A1","n":1}]`, []string{"n"}, "n\n1\n"},
+		{`{"assemblyLines":[{"id":"A1"}]}`, nil, "id\nA1\n"},
+		{`{"b":"x","a":[1,2]}`, nil, "b  x\na  [1,2]\n"},
+		{`[]`, nil, "(none)\n"},
+		{`"text"`, nil, "text\n"},
+	}
+	for _, tc := range tests {
+		var out bytes.Buffer
+		if err := printResult(&out, []byte(tc.payload), "table", tc.columns); err != nil {
+			t.Errorf("%s: %v", tc.payload, err)
+			continue
+		}
+		if out.String() != tc.want {
+			t.Errorf("%s as a table = %q, want %q", tc.payload, out.String(), tc.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// printResult writes a query payload as indented JSON or as a table
+func printResult(w io.Writer, payload []byte, format string, columns []string) error {
+	payload = bytes.TrimSpace(payload)
+	if len(payload) == 0 {
+		return nil
+	}
+	if !json.Valid(payload) {
+		_, err := fmt.Fprintln(w, string(payload))
+		return err
+	}
+
+	if format == "json" {
+		var out bytes.Buffer
+		if err := json.Indent(&out, payload, "", "  "); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(w, out.String())
+		return err
+	}
+	return printTable(w, payload, columns)
+}
+
+func printTable(w io.Writer, payload []byte, columns []string) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	switch payload[0] {
+	case '[':
+		var rows []json.RawMessage
+		if err := json.Unmarshal(payload, &rows); err != nil {
+			return err
+		}
+		writeRows(tw, rows, columns)
+	case '{':
+		keys, err := objectKeys(payload)
+		if err != nil {
+			return err
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(payload, &fields); err != nil {
+			return err
+		}
+		// Holders such as {"assemblyLines":[...]} are shown as their list
+		if len(keys) == 1 && isObjectList(fields[keys[0]]) {
+			var rows []json.RawMessage
+			json.Unmarshal(fields[keys[0]], &rows)
+			writeRows(tw, rows, columns)
+			break
+		}
+		for _, key := range keys {
+			fmt.Fprintf(tw, "%s\t%s\n", key, cell(fields[key]))
+		}
+	default:
+		fmt.Fprintln(tw, cell(payload))
+	}
+	return tw.Flush()
+}
+
+func writeRows(tw io.Writer, rows []json.RawMessage, columns []string) {
+	if len(rows) == 0 {
+		fmt.Fprintln(tw, "(none)")
+		return
+	}
+	if len(columns) == 0 {
+		columns, _ = objectKeys(rows[0])
+	}
+	if len(columns) == 0 { // not objects
+		for _, r := range rows {
+			fmt.Fprintln(tw, cell(r))
+		}
+		return
+	}
+
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	for _, r := range rows {
+		var fields map[string]json.RawMessage
+		json.Unmarshal(r, &fields)
+		cells := make([]string, len(columns))
+		for i, c := range columns {
+			cells[i] = cell(fields[c])
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+}
+
+func isObjectList(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || raw[0] != '[' {
+		return false
+	}
+	var rows []json.RawMessage
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return false
+	}
+	return len(rows) == 0 || bytes.HasPrefix(bytes.TrimSpace(rows[0]), []byte("{"))
+}
+
+// objectKeys returns the keys of a JSON object in the order they appear
+func objectKeys(raw []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	tok, err := dec.Token()
+	if err != nil || tok != json.Delim('{') {
+		return nil, err
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, tok.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// cell renders a JSON value for a table cell: strings unquoted, null empty, the rest compact JSON
+func cell(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if raw[0] == '"' && json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var out bytes.Buffer
+	if json.Compact(&out, raw) != nil {
+		return string(raw)
+	}
+	return out.String()
+}
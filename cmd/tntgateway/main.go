@@ -0,0 +1,103 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Command tntgateway serves the TnT chaincode as an HTTP/JSON API, see package gateway.
+//
+//	tntgateway -listen :8080 -peer http://localhost:7050 -chaincode mycc
+//	tntgateway -listen :8080 -backend memory
+//	tntgateway -openapi > openapi.json
+//
+// The memory backend runs the chaincode in process on a mock stub, for developing web apps
+// without a network. Its ledger starts with the users of -mock-users and is lost on exit.
+//
+// Shipment custody is bound to the identity each user is registered with. The rest backend
+// submits every request as -secure-context, so only the user registered with that identity can
+// create, hand over or accept shipments through it.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/GHSagarnil/TracknTrace3/chaincode/tnt"
+	"github.com/GHSagarnil/TracknTrace3/client"
+	"github.com/GHSagarnil/TracknTrace3/gateway"
+)
+
+const defaultMockUsers = "aluser1:assemblyline_role,pluser1:packageline_role,qauser1:qaviewer_role," +
+	"qiuser1:qainspector_role,consumer1:consumer_role,carrier1:carrier_role,distributor1:distributor_role,retailer1:retailer_role"
+
+func main() {
+	listen := flag.String("listen", ":8080", "address to serve on")
+	backend := flag.String("backend", "rest", "rest (a peer) or memory (in-process mock stub)")
+	peer := flag.String("peer", "http://localhost:7050", "peer REST endpoint")
+	chaincode := flag.String("chaincode", "", "chaincode name on the peer")
+	secureContext := flag.String("secure-context", "", "enrolled user the peer signs invokes with")
+	mockUsers := flag.String("mock-users", defaultMockUsers, "user:role pairs of the memory backend")
+	openapi := flag.Bool("openapi", false, "print the OpenAPI document and exit")
+	flag.Parse()
+
+	if *openapi {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(gateway.OpenAPI(gateway.Routes)); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	var c client.Client
+	switch *backend {
+	case "rest":
+		if *chaincode == "" {
+			log.Fatal("-chaincode is required with the rest backend")
+		}
+		c = client.NewRESTClient(*peer, *chaincode, *secureContext)
+	case "memory":
+		initArgs, err := parseUsers(*mockUsers)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if c, err = client.NewMockClient(new(tnt.TnT), initArgs); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		log.Fatalf("unknown backend %q, expecting rest or memory", *backend)
+	}
+
+	g := gateway.New(c)
+	g.Logger = log.New(os.Stderr, "", log.LstdFlags)
+	log.Printf("serving the %s backend on %s", *backend, *listen)
+	log.Fatal(http.ListenAndServe(*listen, g))
+}
+
+func parseUsers(s string) ([]string, error) {
+	var args []string
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid -mock-users entry %q, expecting user:role", pair)
+		}
+		args = append(args, parts[0], parts[1])
+	}
+	return args, nil
+}
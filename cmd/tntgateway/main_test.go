@@ -0,0 +1,110 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GHSagarnil/TracknTrace3/chaincode/tnt"
+	"github.com/GHSagarnil/TracknTrace3/client"
+	"github.com/GHSagarnil/TracknTrace3/gateway"
+)
+
+func TestParseUsers(t *testing.T) {
+	got, err := parseUsers("al:assemblyline_role, pl:packageline_role")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"al", "assemblyline_role", "pl", "packageline_role"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseUsers = %q, want %q", got, want)
+	}
+	if _, err := parseUsers("al:assemblyline_role,pl"); err == nil {
+		t.Error("entry without a role accepted")
+	}
+}
+
+// send makes a request to srv as user and returns the status and body
+func send(t *testing.T, srv *httptest.Server, method, path, user, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(gateway.UserHeader, user)
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func TestMemoryBackend(t *testing.T) {
+	initArgs, err := parseUsers(defaultMockUsers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.NewMockClient(new(tnt.TnT), initArgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(gateway.New(c))
+	defer srv.Close()
+
+	assembly := `{"assemblyId":"A1","deviceSerialNo":"S1","deviceType":"HOLDER","filamentBatchId":"F1","ledBatchId":"L1",` +
+		`"circuitBoardBatchId":"C1","wireBatchId":"W1","casingBatchId":"K1","adaptorBatchId":"A1","stickPodBatchId":"S1",` +
+		`"assemblyStatus":"1","assemblyDate":"2017-06-08T15:45:00+05:30"}`
+
+	if status, body := send(t, srv, "POST", "/assemblies", "pluser1", assembly); status != http.StatusForbidden {
+		t.Errorf("create by a package line user: %d %s, want 403", status, body)
+	}
+	if status, body := send(t, srv, "POST", "/assemblies?dryRun=true", "aluser1", assembly); status != http.StatusOK || !strings.Contains(body, `"valid":true`) {
+		t.Errorf("dry run: %d %s", status, body)
+	}
+	if status, body := send(t, srv, "GET", "/assemblies/A1", "aluser1", ""); status != http.StatusNotFound {
+		t.Errorf("get after a dry run: %d %s, want 404", status, body)
+	}
+
+	if status, body := send(t, srv, "POST", "/assemblies", "aluser1", assembly); status != http.StatusCreated {
+		t.Fatalf("create: %d %s, want 201", status, body)
+	}
+	status, body := send(t, srv, "GET", "/assemblies/A1", "aluser1", "")
+	if status != http.StatusOK {
+		t.Fatalf("get: %d %s", status, body)
+	}
+	var got tnt.AssemblyLine
+	if err := json.Unmarshal([]byte(body), &got); err != nil || got.AssemblyId != "A1" || got.DeviceSerialNo != "S1" {
+		t.Errorf("get = %s, %v", body, err)
+	}
+
+	status, body = send(t, srv, "POST", "/assemblies", "aluser1", assembly)
+	var e struct {
+		Error gateway.Error `json:"error"`
+	}
+	json.Unmarshal([]byte(body), &e)
+	if status != http.StatusConflict || e.Error.Code != client.AlreadyExists {
+		t.Errorf("duplicate create: %d %s, want 409 %s", status, body, client.AlreadyExists)
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Command tntimport loads legacy assembly records (CSV with a header row, or JSON lines)
+// into the ledger through createAssembly.
+//
+// Every row is validated first - known component batches and unique AssemblyIds and
+// DeviceSerialNos in the file, then createAssembly's own rules (AssemblyDate, AssemblyStatus,
+// IDs and serial numbers on the ledger) through validateCreateAssembly. -offline runs those
+// against an empty in process ledger. With -dry-run only the validation report is printed. Rows are submitted in batches of -batch-size; after each
+// batch the checkpoint file is updated so an interrupted import is rerun with -resume.
+//
+//	tntimport -peer http://localhost:7050 -chaincode tnt -user aluser1 -in mes.csv -dry-run
+//	tntimport -peer http://localhost:7050 -chaincode tnt -user aluser1 -in mes.jsonl -format jsonl -resume
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GHSagarnil/TracknTrace3/chaincode/tnt"
+	"github.com/GHSagarnil/TracknTrace3/client"
+)
+
+type options struct {
+	in          string
+	format      string
+	user        string
+	batchesFile string
+	batchSize   int
+	dryRun      bool
+	offline     bool
+	skipInvalid bool
+	resume      bool
+	checkpoint  string
+}
+
+func main() {
+	var opts options
+	peer := flag.String("peer", "http://localhost:7050", "peer REST endpoint")
+	chaincode := flag.String("chaincode", "", "chaincode name")
+	flag.StringVar(&opts.in, "in", "", "input file")
+	flag.StringVar(&opts.format, "format", "", "csv or jsonl (default from the file extension)")
+	flag.StringVar(&opts.user, "user", "", "AssemblyLine user the records are created by")
+	flag.StringVar(&opts.batchesFile, "batches", "", "CSV of known batchType,batchNo (default: ask the ledger)")
+	flag.IntVar(&opts.batchSize, "batch-size", 100, "rows submitted between checkpoints")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "validate and report only")
+	flag.BoolVar(&opts.offline, "offline", false, "validate against an empty in process ledger (needs -batches, implies -dry-run)")
+	flag.BoolVar(&opts.skipInvalid, "skip-invalid", false, "submit the valid rows even if some rows are invalid")
+	flag.BoolVar(&opts.resume, "resume", false, "carry on from the checkpoint file")
+	flag.StringVar(&opts.checkpoint, "checkpoint", "", "checkpoint file (default <in>.checkpoint)")
+	flag.Parse()
+
+	if opts.in == "" || opts.user == "" {
+		fmt.Fprintln(os.Stderr, "tntimport: -in and -user are required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if opts.offline {
+		if opts.batchesFile == "" {
+			fmt.Fprintln(os.Stderr, "tntimport: -offline needs -batches")
+			os.Exit(2)
+		}
+		opts.dryRun = true
+	}
+
+	var c client.Client
+	if opts.offline {
+		mock, err := client.NewMockClient(new(tnt.TnT), []string{opts.user, "assemblyline_role"})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "tntimport:", err)
+			os.Exit(1)
+		}
+		c = mock
+	} else {
+		if *chaincode == "" {
+			fmt.Fprintln(os.Stderr, "tntimport: -chaincode is required")
+			os.Exit(2)
+		}
+		c = client.NewRESTClient(*peer, *chaincode, opts.user)
+	}
+
+	if err := run(opts, c, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "tntimport:", err)
+		os.Exit(1)
+	}
+}
+
+// run validates and, unless dry running, submits the rows through c
+func run(opts options, c client.Client, out io.Writer) error {
+	if opts.format == "" {
+		opts.format = strings.TrimPrefix(strings.ToLower(filepath.Ext(opts.in)), ".")
+		if opts.format == "json" || opts.format == "ndjson" {
+			opts.format = "jsonl"
+		}
+	}
+	if opts.checkpoint == "" {
+		opts.checkpoint = opts.in + ".checkpoint"
+	}
+	if opts.batchSize < 1 {
+		return fmt.Errorf("-batch-size must be at least 1")
+	}
+
+	f, err := os.Open(opts.in)
+	if err != nil {
+		return err
+	}
+	rows, err := readRows(f, opts.format)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	cp := checkpoint{Source: opts.in, NextRow: 1}
+	if opts.resume {
+		saved, err := loadCheckpoint(opts.checkpoint)
+		if err != nil {
+			return err
+		}
+		if saved.Source != "" {
+			if saved.Source != opts.in {
+				return fmt.Errorf("checkpoint %s is for %s", opts.checkpoint, saved.Source)
+			}
+			cp = saved
+			fmt.Fprintf(out, "resuming at row %d, %d rows already submitted\n", cp.NextRow, cp.Submitted)
+		}
+	}
+
+	known := make(map[string]bool)
+	if opts.batchesFile != "" {
+		if known, err = loadBatches(opts.batchesFile); err != nil {
+			return err
+		}
+	}
+	batches := &batchChecker{known: known, client: c, user: opts.user}
+	if opts.offline {
+		batches.client = nil
+	}
+	v := newValidator(batches, c, opts.user)
+
+	// Validate every row, including submitted ones so duplicates against them are caught
+	var valid []row
+	invalid := 0
+	for _, r := range rows {
+		if r.Number < cp.NextRow {
+			v.register(r)
+			continue
+		}
+		problems := v.validate(r)
+		if len(problems) > 0 {
+			invalid++
+			fmt.Fprintf(out, "row %d (%s): %s\n", r.Number, r.Record.AssemblyId, strings.Join(problems, "; "))
+			continue
+		}
+		valid = append(valid, r)
+	}
+	fmt.Fprintf(out, "%d rows read, %d to import, %d invalid, %d already submitted\n",
+		len(rows), len(valid), invalid, cp.Submitted)
+
+	if opts.dryRun {
+		return nil
+	}
+	if invalid > 0 && !opts.skipInvalid {
+		return fmt.Errorf("%d invalid rows, fix them or rerun with -skip-invalid", invalid)
+	}
+
+	for start := 0; start < len(valid); start += opts.batchSize {
+		end := start + opts.batchSize
+		if end > len(valid) {
+			end = len(valid)
+		}
+		for _, r := range valid[start:end] {
+			if _, err := c.Invoke("createAssembly", r.Record.createArgs(opts.user)); err != nil {
+				if saveErr := cp.save(opts.checkpoint); saveErr != nil {
+					return fmt.Errorf("row %d: %v (checkpoint not saved: %v)", r.Number, err, saveErr)
+				}
+				return fmt.Errorf("row %d (%s): %v - rerun with -resume", r.Number, r.Record.AssemblyId, err)
+			}
+			cp.NextRow = r.Number + 1
+			cp.Submitted++
+		}
+		if err := cp.save(opts.checkpoint); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "submitted %d rows, next row %d\n", cp.Submitted, cp.NextRow)
+	}
+
+	// Rows skipped as invalid are behind the checkpoint now; fixing them means a new import file
+	cp.NextRow = len(rows) + 1
+	return cp.save(opts.checkpoint)
+}
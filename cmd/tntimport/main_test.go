@@ -0,0 +1,227 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeLedger accepts every row validateCreateAssembly is asked about, has received no batches
+// and records the createAssembly invokes, failing the one of failId
+type fakeLedger struct {
+	created []string
+	failId  string
+}
+
+func (l *fakeLedger) Invoke(function string, args []string) ([]byte, error) {
+	if function != "createAssembly" {
+		return nil, errors.New("unexpected invoke " + function)
+	}
+	if args[0] == l.failId {
+		return nil, errors.New("peer unavailable")
+	}
+	l.created = append(l.created, args[0])
+	return []byte("tx"), nil
+}
+
+func (l *fakeLedger) Query(function string, args []string) ([]byte, error) {
+	switch function {
+	case "validateCreateAssembly":
+		return []byte(`{"valid":true}`), nil
+	case "getBatchReconciliation":
+		return []byte(`{"received":0}`), nil
+	}
+	return nil, errors.New("unexpected query " + function)
+}
+
+const batchesCSV = "FilamentBatchId,F1\nLedBatchId,L1\nCircuitBoardBatchId,C1\nWireBatchId,W1\nCasingBatchId,K1\nAdaptorBatchId,A1\nStickPodBatchId,S1\n"
+
+const recordsHeader = "assemblyId,deviceSerialNo,deviceType,filamentBatchId,ledBatchId,circuitBoardBatchId,wireBatchId,casingBatchId,adaptorBatchId,stickPodBatchId,assemblyStatus,assemblyDate\n"
+
+// recordLine returns a CSV line of an assembly built from the known batches
+func recordLine(id, serial string) string {
+	return id + "," + serial + ",HOLDER,F1,L1,C1,W1,K1,A1,S1,1,20170608101500\n"
+}
+
+// importFiles writes the records and known batches to a temporary directory, returning the options
+// importing them
+func importFiles(t *testing.T, records string) options {
+	t.Helper()
+	dir := t.TempDir()
+	opts := options{
+		in:          filepath.Join(dir, "mes.csv"),
+		user:        "al",
+		batchesFile: filepath.Join(dir, "batches.csv"),
+		batchSize:   2,
+	}
+	if err := os.WriteFile(opts.in, []byte(recordsHeader+records), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(opts.batchesFile, []byte(batchesCSV), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return opts
+}
+
+func fiveRecords() string {
+	var b strings.Builder
+	for _, id := range []string{"A1", "A2", "A3", "A4", "A5"} {
+		b.WriteString(recordLine(id, "S"+id))
+	}
+	return b.String()
+}
+
+func TestDryRun(t *testing.T) {
+	opts := importFiles(t, recordLine("A1", "S1")+recordLine("A1", "S2")+strings.Replace(recordLine("A3", "S3"), "L1", "L9", 1))
+	opts.dryRun = true
+	l := &fakeLedger{}
+	var out bytes.Buffer
+	if err := run(opts, l, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(l.created) != 0 {
+		t.Errorf("dry run created %v", l.created)
+	}
+	if _, err := os.Stat(opts.in + ".checkpoint"); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote a checkpoint: %v", err)
+	}
+	report := out.String()
+	for _, want := range []string{"row 2 (A1): AssemblyId A1 already on row 1", "row 3 (A3): unknown LedBatchId L9", "3 rows read, 1 to import, 2 invalid"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report has no %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestInvalidRowsStopImport(t *testing.T) {
+	opts := importFiles(t, recordLine("A1", "S1")+recordLine("A2", "S1"))
+	l := &fakeLedger{}
+	var out bytes.Buffer
+	if err := run(opts, l, &out); err == nil || !strings.Contains(err.Error(), "1 invalid rows") {
+		t.Fatalf("err = %v, want the invalid rows reported", err)
+	}
+	if len(l.created) != 0 {
+		t.Errorf("created %v with an invalid row in the file", l.created)
+	}
+
+	opts.skipInvalid = true
+	if err := run(opts, l, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(l.created, []string{"A1"}) {
+		t.Errorf("created %v with -skip-invalid, want [A1]", l.created)
+	}
+}
+
+func TestResume(t *testing.T) {
+	opts := importFiles(t, fiveRecords())
+	l := &fakeLedger{failId: "A4"}
+	var out bytes.Buffer
+	err := run(opts, l, &out)
+	if err == nil || !strings.Contains(err.Error(), "row 4 (A4)") {
+		t.Fatalf("err = %v, want row 4 to fail", err)
+	}
+	cp, err := loadCheckpoint(opts.in + ".checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Source != opts.in || cp.NextRow != 4 || cp.Submitted != 3 {
+		t.Errorf("checkpoint = %+v, want row 4 next after 3 submitted", cp)
+	}
+
+	l.failId = ""
+	opts.resume = true
+	out.Reset()
+	if err := run(opts, l, &out); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"A1", "A2", "A3", "A4", "A5"}; !reflect.DeepEqual(l.created, want) {
+		t.Errorf("created %v, want %v each once", l.created, want)
+	}
+	if !strings.Contains(out.String(), "resuming at row 4, 3 rows already submitted") {
+		t.Errorf("report has no resume line:\n%s", out.String())
+	}
+	cp, _ = loadCheckpoint(opts.in + ".checkpoint")
+	if cp.NextRow != 6 || cp.Submitted != 5 {
+		t.Errorf("final checkpoint = %+v, want row 6 next after 5 submitted", cp)
+	}
+}
+
+func TestResumeChecksSubmittedRows(t *testing.T) {
+	opts := importFiles(t, fiveRecords()+recordLine("A2", "S9"))
+	if err := (checkpoint{Source: opts.in, NextRow: 6, Submitted: 5}).save(opts.in + ".checkpoint"); err != nil {
+		t.Fatal(err)
+	}
+	opts.resume = true
+	opts.dryRun = true
+	var out bytes.Buffer
+	if err := run(opts, &fakeLedger{}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "row 6 (A2): AssemblyId A2 already on row 2") {
+		t.Errorf("duplicate of a submitted row not reported:\n%s", out.String())
+	}
+}
+
+func TestResumeOtherSource(t *testing.T) {
+	opts := importFiles(t, fiveRecords())
+	if err := (checkpoint{Source: "other.csv", NextRow: 3}).save(opts.in + ".checkpoint"); err != nil {
+		t.Fatal(err)
+	}
+	opts.resume = true
+	l := &fakeLedger{}
+	if err := run(opts, l, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "other.csv") {
+		t.Errorf("err = %v, want the checkpoint of another file refused", err)
+	}
+	if len(l.created) != 0 {
+		t.Errorf("created %v", l.created)
+	}
+}
+
+func TestLoadCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	cp, err := loadCheckpoint(filepath.Join(dir, "missing"))
+	if err != nil || cp != (checkpoint{}) {
+		t.Errorf("missing checkpoint = %+v, %v, want empty", cp, err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt")
+	os.WriteFile(corrupt, []byte("{"), 0644)
+	if _, err := loadCheckpoint(corrupt); err == nil {
+		t.Error("corrupt checkpoint loaded")
+	}
+
+	path := filepath.Join(dir, "saved")
+	if err := (checkpoint{Source: "mes.csv", NextRow: 7, Submitted: 6}).save(path); err != nil {
+		t.Fatal(err)
+	}
+	cp, err = loadCheckpoint(path)
+	if err != nil || cp.Source != "mes.csv" || cp.NextRow != 7 || cp.Submitted != 6 || cp.UpdatedAt == "" {
+		t.Errorf("saved checkpoint = %+v, %v", cp, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("%d files in the checkpoint directory, want no temporary file left", len(entries))
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// assemblyRecord is one legacy production record. Field names are the AssemblyLine json
+// names, so files written by the CSV export can be imported back.
+type assemblyRecord struct {
+	AssemblyId          string `json:"assemblyId"`
+	DeviceSerialNo      string `json:"deviceSerialNo"`
+	DeviceType          string `json:"deviceType"`
+	FilamentBatchId     string `json:"filamentBatchId"`
+	LedBatchId          string `json:"ledBatchId"`
+	CircuitBoardBatchId string `json:"circuitBoardBatchId"`
+	WireBatchId         string `json:"wireBatchId"`
+	CasingBatchId       string `json:"casingBatchId"`
+	AdaptorBatchId      string `json:"adaptorBatchId"`
+	StickPodBatchId     string `json:"stickPodBatchId"`
+	ManufacturingPlant  string `json:"manufacturingPlant"`
+	AssemblyStatus      string `json:"assemblyStatus"`
+	AssemblyDate        string `json:"assemblyDate"`
+	AssemblyPackage     string `json:"assemblyPackage"`
+	AssemblyInfo1       string `json:"assemblyInfo1"`
+	AssemblyInfo2       string `json:"assemblyInfo2"`
+}
+
+// row is a record with its position in the source file (1 based, header excluded)
+type row struct {
+	Number int
+	Record assemblyRecord
+	Err    error // parse error
+}
+
+// createArgs returns the 17 createAssembly arguments
+func (r assemblyRecord) createArgs(user string) []string {
+	return []string{
+		r.AssemblyId, r.DeviceSerialNo, r.DeviceType,
+		r.FilamentBatchId, r.LedBatchId, r.CircuitBoardBatchId, r.WireBatchId,
+		r.CasingBatchId, r.AdaptorBatchId, r.StickPodBatchId,
+		r.ManufacturingPlant, r.AssemblyStatus, r.AssemblyDate,
+		r.AssemblyPackage, r.AssemblyInfo1, r.AssemblyInfo2,
+		user,
+	}
+}
+
+// batches returns the record's component batches keyed by batch type
+func (r assemblyRecord) batches() [][2]string {
+	return [][2]string{
+		{"FilamentBatchId", r.FilamentBatchId},
+		{"LedBatchId", r.LedBatchId},
+		{"CircuitBoardBatchId", r.CircuitBoardBatchId},
+		{"WireBatchId", r.WireBatchId},
+		{"CasingBatchId", r.CasingBatchId},
+		{"AdaptorBatchId", r.AdaptorBatchId},
+		{"StickPodBatchId", r.StickPodBatchId},
+	}
+}
+
+// readRows reads CSV (with a header row) or JSON lines
+func readRows(r io.Reader, format string) ([]row, error) {
+	switch format {
+	case "csv":
+		return readCSV(r)
+	case "jsonl":
+		return readJSONLines(r)
+	}
+	return nil, fmt.Errorf("unknown format %q, expecting csv or jsonl", format)
+}
+
+func readCSV(r io.Reader) ([]row, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %v", err)
+	}
+	setters := recordSetters()
+	columns := make([]func(*assemblyRecord, string), len(header))
+	for i, name := range header {
+		set, ok := setters[strings.TrimSpace(name)]
+		if !ok {
+			continue // extra columns of an export, e.g. assemblyHash, are ignored
+		}
+		columns[i] = set
+	}
+	for _, required := range []string{"assemblyId", "deviceSerialNo", "deviceType", "assemblyDate"} {
+		found := false
+		for _, name := range header {
+			if strings.TrimSpace(name) == required {
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("CSV header has no %s column", required)
+		}
+	}
+
+	var rows []row
+	for n := 1; ; n++ {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); ok {
+				rows = append(rows, row{Number: n, Err: err})
+				continue
+			}
+			return nil, err
+		}
+		var rec assemblyRecord
+		for i, value := range fields {
+			if i < len(columns) && columns[i] != nil {
+				columns[i](&rec, value)
+			}
+		}
+		rows = append(rows, row{Number: n, Record: rec})
+	}
+}
+
+func readJSONLines(r io.Reader) ([]row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var rows []row
+	n := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		n++
+		var rec assemblyRecord
+		err := json.Unmarshal([]byte(line), &rec)
+		rows = append(rows, row{Number: n, Record: rec, Err: err})
+	}
+	return rows, scanner.Err()
+}
+
+func recordSetters() map[string]func(*assemblyRecord, string) {
+	return map[string]func(*assemblyRecord, string){
+		"assemblyId":          func(r *assemblyRecord, v string) { r.AssemblyId = v },
+		"deviceSerialNo":      func(r *assemblyRecord, v string) { r.DeviceSerialNo = v },
+		"deviceType":          func(r *assemblyRecord, v string) { r.DeviceType = v },
+		"filamentBatchId":     func(r *assemblyRecord, v string) { r.FilamentBatchId = v },
+		"ledBatchId":          func(r *assemblyRecord, v string) { r.LedBatchId = v },
+		"circuitBoardBatchId": func(r *assemblyRecord, v string) { r.CircuitBoardBatchId = v },
+		"wireBatchId":         func(r *assemblyRecord, v string) { r.WireBatchId = v },
+		"casingBatchId":       func(r *assemblyRecord, v string) { r.CasingBatchId = v },
+		"adaptorBatchId":      func(r *assemblyRecord, v string) { r.AdaptorBatchId = v },
+		"stickPodBatchId":     func(r *assemblyRecord, v string) { r.StickPodBatchId = v },
+		"manufacturingPlant":  func(r *assemblyRecord, v string) { r.ManufacturingPlant = v },
+		"assemblyStatus":      func(r *assemblyRecord, v string) { r.AssemblyStatus = v },
+		"assemblyDate":        func(r *assemblyRecord, v string) { r.AssemblyDate = v },
+		"assemblyPackage":     func(r *assemblyRecord, v string) { r.AssemblyPackage = v },
+		"assemblyInfo1":       func(r *assemblyRecord, v string) { r.AssemblyInfo1 = v },
+		"assemblyInfo2":       func(r *assemblyRecord, v string) { r.AssemblyInfo2 = v },
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/GHSagarnil/TracknTrace3/client"
+)
+
+// batchChecker tells whether a component batch is known, from a local list or the ledger
+type batchChecker struct {
+	known  map[string]bool
+	client client.Client // nil when checking against the local list only
+	user   string
+}
+
+func batchKey(batchType, batchNo string) string {
+	return batchType + "|" + batchNo
+}
+
+// loadBatches reads a CSV of batchType,batchNo lines
+func loadBatches(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cr := csv.NewReader(bufio.NewReader(f))
+	cr.FieldsPerRecord = 2
+	lines, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %v", path, err)
+	}
+
+	known := make(map[string]bool, len(lines))
+	for _, l := range lines {
+		known[batchKey(strings.TrimSpace(l[0]), strings.TrimSpace(l[1]))] = true
+	}
+	return known, nil
+}
+
+// isKnown checks the local list, then asks the ledger whether any quantity was received
+func (b *batchChecker) isKnown(batchType, batchNo string) (bool, error) {
+	key := batchKey(batchType, batchNo)
+	if known, ok := b.known[key]; ok || b.client == nil {
+		return known, nil
+	}
+
+	payload, err := b.client.Query("getBatchReconciliation", []string{batchType, batchNo, b.user})
+	if err != nil {
+		return false, err
+	}
+	var reconciliation struct {
+		Received int `json:"received"`
+	}
+	if err := json.Unmarshal(payload, &reconciliation); err != nil {
+		return false, errors.New("unexpected getBatchReconciliation response")
+	}
+	b.known[key] = reconciliation.Received > 0
+	return b.known[key], nil
+}
+
+// validator checks the rows across the file for uniqueness and their batches, then runs
+// createAssembly's own rules through validateCreateAssembly
+type validator struct {
+	batches *batchChecker
+	client  client.Client // an empty in process ledger for offline validation
+	user    string
+
+	ids     map[string]int
+	serials map[string]int
+}
+
+func newValidator(batches *batchChecker, c client.Client, user string) *validator {
+	return &validator{
+		batches: batches,
+		client:  c,
+		user:    user,
+		ids:     make(map[string]int),
+		serials: make(map[string]int),
+	}
+}
+
+// register records the row's AssemblyId and DeviceSerialNo, reporting clashes with earlier rows
+func (v *validator) register(r row) []string {
+	rec := r.Record
+	var problems []string
+
+	if rec.AssemblyId == "" {
+		problems = append(problems, "AssemblyId supplied as empty")
+	} else if first, ok := v.ids[rec.AssemblyId]; ok {
+		problems = append(problems, fmt.Sprintf("AssemblyId %s already on row %d", rec.AssemblyId, first))
+	} else {
+		v.ids[rec.AssemblyId] = r.Number
+	}
+
+	if rec.DeviceSerialNo == "" {
+		problems = append(problems, "DeviceSerialNo supplied as empty")
+	} else {
+		key := rec.DeviceType + "|" + rec.DeviceSerialNo
+		if first, ok := v.serials[key]; ok {
+			problems = append(problems, fmt.Sprintf("DeviceSerialNo %s already on row %d", rec.DeviceSerialNo, first))
+		} else {
+			v.serials[key] = r.Number
+		}
+	}
+	return problems
+}
+
+// validate returns every problem found with the row
+func (v *validator) validate(r row) []string {
+	if r.Err != nil {
+		return []string{r.Err.Error()}
+	}
+	rec := r.Record
+	problems := v.register(r)
+
+	for _, batch := range rec.batches() {
+		if batch[1] == "" {
+			problems = append(problems, batch[0]+" supplied as empty")
+			continue
+		}
+		known, err := v.batches.isKnown(batch[0], batch[1])
+		if err != nil {
+			problems = append(problems, "checking "+batch[0]+" "+batch[1]+": "+err.Error())
+		} else if !known {
+			problems = append(problems, "unknown "+batch[0]+" "+batch[1])
+		}
+	}
+
+	// The chaincode has the final word on the date, the status, existing IDs and registered serial numbers
+	if len(problems) == 0 {
+		if _, err := v.client.Query("validateCreateAssembly", rec.createArgs(v.user)); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	return problems
+}
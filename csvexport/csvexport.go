@@ -0,0 +1,199 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Package csvexport renders ledger records as RFC 4180 CSV.
+// Columns are the json names of the record's fields, in struct order, so exports are
+// stable across releases; callers may select and reorder a subset. The chaincode export
+// queries return one Page at a time and Stream stitches the pages into a single file.
+package csvexport
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Page is one page of an export as returned by the chaincode export queries.
+// Only the first page (empty bookmark) carries the header row.
+type Page struct {
+	CSV          string `json:"csv"`
+	Rows         int    `json:"rows"`
+	NextBookmark string `json:"nextBookmark"` // empty on the last page
+}
+
+// Columns returns the json field names of a struct (or pointer to struct) in declaration order.
+// Fields without a json name or tagged "-" are skipped.
+func Columns(record interface{}) []string {
+	t := reflect.TypeOf(record)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var columns []string
+	for i := 0; i < t.NumField(); i++ {
+		if name := columnName(t.Field(i)); name != "" {
+			columns = append(columns, name)
+		}
+	}
+	return columns
+}
+
+func columnName(f reflect.StructField) string {
+	if f.PkgPath != "" { // unexported
+		return ""
+	}
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// ParseFields splits a comma separated field list; an empty list selects every column
+func ParseFields(fields string, record interface{}) ([]string, error) {
+	all := Columns(record)
+	if strings.TrimSpace(fields) == "" {
+		return all, nil
+	}
+
+	known := make(map[string]bool, len(all))
+	for _, c := range all {
+		known[c] = true
+	}
+
+	var selected []string
+	for _, f := range strings.Split(fields, ",") {
+		f = strings.TrimSpace(f)
+		if !known[f] {
+			return nil, errors.New("unknown field " + f + ", expecting one of " + strings.Join(all, ","))
+		}
+		selected = append(selected, f)
+	}
+	return selected, nil
+}
+
+// Writer writes records of one struct type as CSV rows with the selected columns
+type Writer struct {
+	csv    *csv.Writer
+	fields []string
+	index  map[string][]int
+}
+
+// NewWriter returns a Writer for records shaped like sample. Rows end in CRLF as RFC 4180 requires.
+func NewWriter(w io.Writer, sample interface{}, fields []string) (*Writer, error) {
+	t := reflect.TypeOf(sample)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.New("csvexport: sample must be a struct")
+	}
+
+	index := make(map[string][]int)
+	for i := 0; i < t.NumField(); i++ {
+		if name := columnName(t.Field(i)); name != "" {
+			index[name] = t.Field(i).Index
+		}
+	}
+	for _, f := range fields {
+		if _, ok := index[f]; !ok {
+			return nil, errors.New("csvexport: unknown field " + f)
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+	return &Writer{csv: cw, fields: fields, index: index}, nil
+}
+
+// WriteHeader writes the column names
+func (w *Writer) WriteHeader() error {
+	return w.csv.Write(w.fields)
+}
+
+// Write writes one record
+func (w *Writer) Write(record interface{}) error {
+	v := reflect.ValueOf(record)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return errors.New("csvexport: nil record")
+		}
+		v = v.Elem()
+	}
+
+	row := make([]string, len(w.fields))
+	for i, f := range w.fields {
+		row[i] = formatValue(v.FieldByIndex(w.index[f]))
+	}
+	return w.csv.Write(row)
+}
+
+// Flush writes any buffered rows and reports the first write error
+func (w *Writer) Flush() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Slice, reflect.Map, reflect.Struct:
+		if v.Kind() != reflect.Struct && v.IsNil() {
+			return ""
+		}
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// Stream fetches the pages of an export starting from an empty bookmark and writes them
+// to w as one CSV file, without holding more than one page in memory
+func Stream(w io.Writer, fetch func(bookmark string) (Page, error)) error {
+	bookmark := ""
+	for {
+		page, err := fetch(bookmark)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, page.CSV); err != nil {
+			return err
+		}
+		if page.NextBookmark == "" {
+			return nil
+		}
+		if page.NextBookmark == bookmark {
+			return errors.New("csvexport: bookmark did not advance")
+		}
+		bookmark = page.NextBookmark
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package csvexport
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type record struct {
+	Id       string            `json:"id"`
+	Name     string            `json:"name,omitempty"`
+	Count    int               `json:"count"`
+	Tags     []string          `json:"tags"`
+	Attrs    map[string]string `json:"attrs"`
+	Skipped  string            `json:"-"`
+	Untagged string
+	internal string
+}
+
+func TestColumns(t *testing.T) {
+	want := []string{"id", "name", "count", "tags", "attrs", "Untagged"}
+	for _, sample := range []interface{}{record{}, &record{}} {
+		if got := Columns(sample); !reflect.DeepEqual(got, want) {
+			t.Errorf("Columns(%T) = %v, want %v", sample, got, want)
+		}
+	}
+	if got := Columns("not a struct"); got != nil {
+		t.Errorf("Columns of a string = %v, want nil", got)
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		fields  string
+		want    []string
+		wantErr bool
+	}{
+		{"", Columns(record{}), false},
+		{"  ", Columns(record{}), false},
+		{"id", []string{"id"}, false},
+		{"count, id", []string{"count", "id"}, false},
+		{"id,Untagged", []string{"id", "Untagged"}, false},
+		{"id,Skipped", nil, true},
+		{"id,internal", nil, true},
+		{"id,", nil, true},
+	}
+	for _, tc := range tests {
+		got, err := ParseFields(tc.fields, record{})
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseFields(%q): err = %v, want error %v", tc.fields, err, tc.wantErr)
+			continue
+		}
+		if !tc.wantErr && !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseFields(%q) = %v, want %v", tc.fields, got, tc.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []string
+		record interface{}
+		want   string
+	}{
+		{"plain", []string{"id", "count"}, record{Id: "A1", Count: 2}, "A1,2\r\n"},
+		{"pointer", []string{"id"}, &record{Id: "A1"}, "A1\r\n"},
+		{"selected order", []string{"count", "id"}, record{Id: "A1", Count: 2}, "2,A1\r\n"},
+		{"comma quoted", []string{"id", "name"}, record{Id: "A1", Name: "Stick, large"}, "A1,\"Stick, large\"\r\n"},
+		{"quote doubled", []string{"name"}, record{Name: `12" case`}, "\"12\"\" case\"\r\n"},
+		{"newline quoted as CRLF", []string{"name"}, record{Name: "line1\nline2"}, "\"line1\r\nline2\"\r\n"},
+		{"leading space kept", []string{"name"}, record{Name: " x"}, "\" x\"\r\n"},
+		{"empty", []string{"id", "name"}, record{}, ",\r\n"},
+		{"slice as json", []string{"tags"}, record{Tags: []string{"a", "b"}}, "\"[\"\"a\"\",\"\"b\"\"]\"\r\n"},
+		{"nil slice empty", []string{"tags", "attrs"}, record{}, ",\r\n"},
+		{"map as json", []string{"attrs"}, record{Attrs: map[string]string{"k": "v"}}, "\"{\"\"k\"\":\"\"v\"\"}\"\r\n"},
+	}
+	for _, tc := range tests {
+		var out strings.Builder
+		w, err := NewWriter(&out, record{}, tc.fields)
+		if err != nil {
+			t.Fatalf("%s: NewWriter: %v", tc.name, err)
+		}
+		if err := w.Write(tc.record); err != nil {
+			t.Fatalf("%s: Write: %v", tc.name, err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatalf("%s: Flush: %v", tc.name, err)
+		}
+		if out.String() != tc.want {
+			t.Errorf("%s: wrote %q, want %q", tc.name, out.String(), tc.want)
+		}
+	}
+}
+
+func TestWriteHeader(t *testing.T) {
+	var out strings.Builder
+	w, _ := NewWriter(&out, &record{}, []string{"name", "id"})
+	w.WriteHeader()
+	w.Write(record{Id: "A1", Name: "n"})
+	w.Flush()
+	if want := "name,id\r\nn,A1\r\n"; out.String() != want {
+		t.Errorf("wrote %q, want %q", out.String(), want)
+	}
+}
+
+func TestWriterErrors(t *testing.T) {
+	if _, err := NewWriter(&strings.Builder{}, 42, nil); err == nil {
+		t.Error("NewWriter with a non struct sample should fail")
+	}
+	if _, err := NewWriter(&strings.Builder{}, record{}, []string{"id", "nope"}); err == nil {
+		t.Error("NewWriter with an unknown field should fail")
+	}
+	w, _ := NewWriter(&strings.Builder{}, record{}, []string{"id"})
+	if err := w.Write((*record)(nil)); err == nil {
+		t.Error("Write of a nil record should fail")
+	}
+}
+
+func TestStream(t *testing.T) {
+	fetchErr := errors.New("peer unavailable")
+	tests := []struct {
+		name      string
+		pages     map[string]Page
+		errAt     string
+		want      string
+		wantErr   string
+		wantCalls []string
+	}{
+		{
+			name:      "single page",
+			pages:     map[string]Page{"": {CSV: "id\r\nA1\r\n", Rows: 1}},
+			want:      "id\r\nA1\r\n",
+			wantCalls: []string{""},
+		},
+		{
+			name: "follows bookmarks",
+			pages: map[string]Page{
+				"":   {CSV: "id\r\nA1\r\n", Rows: 1, NextBookmark: "b1"},
+				"b1": {CSV: "A2\r\n", Rows: 1, NextBookmark: "b2"},
+				"b2": {CSV: "", Rows: 0},
+			},
+			want:      "id\r\nA1\r\nA2\r\n",
+			wantCalls: []string{"", "b1", "b2"},
+		},
+		{
+			name: "bookmark did not advance",
+			pages: map[string]Page{
+				"":   {CSV: "id\r\n", NextBookmark: "b1"},
+				"b1": {CSV: "A1\r\n", NextBookmark: "b1"},
+			},
+			want:      "id\r\nA1\r\n",
+			wantErr:   "bookmark did not advance",
+			wantCalls: []string{"", "b1"},
+		},
+		{
+			name: "fetch error",
+			pages: map[string]Page{
+				"": {CSV: "id\r\n", NextBookmark: "b1"},
+			},
+			errAt:     "b1",
+			want:      "id\r\n",
+			wantErr:   fetchErr.Error(),
+			wantCalls: []string{"", "b1"},
+		},
+	}
+	for _, tc := range tests {
+		var out strings.Builder
+		var calls []string
+		err := Stream(&out, func(bookmark string) (Page, error) {
+			calls = append(calls, bookmark)
+			if bookmark == tc.errAt && tc.errAt != "" {
+				return Page{}, fetchErr
+			}
+			return tc.pages[bookmark], nil
+		})
+		if tc.wantErr == "" && err != nil || tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Errorf("%s: err = %v, want %q", tc.name, err, tc.wantErr)
+		}
+		if out.String() != tc.want {
+			t.Errorf("%s: wrote %q, want %q", tc.name, out.String(), tc.want)
+		}
+		if !reflect.DeepEqual(calls, tc.wantCalls) {
+			t.Errorf("%s: fetched %q, want %q", tc.name, calls, tc.wantCalls)
+		}
+	}
+}
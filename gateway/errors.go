@@ -0,0 +1,63 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package gateway
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/GHSagarnil/TracknTrace3/client"
+)
+
+var codeStatuses = map[string]int{
+	client.NotFound:          http.StatusNotFound,
+	client.AlreadyExists:     http.StatusConflict,
+	client.Forbidden:         http.StatusForbidden,
+	client.InvalidArgument:   http.StatusBadRequest,
+	client.InvalidTransition: http.StatusConflict,
+	client.CorruptState:      http.StatusInternalServerError,
+}
+
+// StatusFor returns the HTTP status for an error from the chaincode or the transport.
+// Chaincode errors are mapped by their code; errors reaching the peer answer 502 Bad Gateway,
+// transient data the client can't pass 501 Not Implemented and anything else 500.
+func StatusFor(err error) int {
+	if err == client.ErrNoTransient {
+		return http.StatusNotImplemented
+	}
+	switch e := err.(type) {
+	case nil:
+		return http.StatusOK
+	case *client.ChaincodeError:
+		if status, ok := codeStatuses[e.Code]; ok {
+			return status
+		}
+	case *url.Error, net.Error:
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// errorFor builds the response error of a failed call
+func errorFor(err error) Error {
+	if ce, ok := err.(*client.ChaincodeError); ok {
+		return Error{Status: StatusFor(err), Code: ce.Code, Message: ce.Message, Field: ce.Field}
+	}
+	return Error{Status: StatusFor(err), Message: err.Error()}
+}
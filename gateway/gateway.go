@@ -0,0 +1,421 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Package gateway serves the TnT chaincode functions as a resource oriented HTTP/JSON API.
+//
+// Each Route maps a method and path to one chaincode function. Path, query and body values are
+// checked against the route's Params and laid out in chaincode argument order, or put in the
+// transient map for Transient params; the calling user comes from the X-TnT-User header. Chaincode errors are answered with an HTTP status derived
+// from the error code, see StatusFor. The OpenAPI document of the routes is served at /openapi.json.
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/GHSagarnil/TracknTrace3/client"
+)
+
+// UserHeader carries the name of the calling user
+const UserHeader = "X-TnT-User"
+
+// DryRunParam is the query parameter turning an invoke into a dry run, e.g. ?dryRun=true
+const DryRunParam = "dryRun"
+
+// maxBodyBytes bounds request bodies
+const maxBodyBytes = 1 << 20
+
+// datePattern matches the date forms the chaincode takes - YYYYMMDDHHMMSS (UTC) or ISO-8601 with an offset. The
+// chaincode checks they are real calendar dates
+var datePattern = regexp.MustCompile(`^([0-9]{14}|[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?(Z|[+-][0-9]{2}:?[0-9]{2})|[0-9]{8}T[0-9]{6}(Z|[+-][0-9]{4}))$`)
+
+// Gateway is an http.Handler calling the chaincode through a client.Client
+type Gateway struct {
+	Client client.Client
+	Routes []Route
+	Logger *log.Logger // nil logs nothing
+}
+
+// New returns a Gateway serving Routes
+func New(c client.Client) *Gateway {
+	return &Gateway{Client: c, Routes: Routes}
+}
+
+// Error is the body of every error response. Code is the chaincode error code (see package
+// client); the gateway's own checks use INVALID_ARGUMENT and NOT_FOUND as well.
+type Error struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"` // request parameter at fault, when known
+}
+
+type errorBody struct {
+	Error Error `json:"error"`
+}
+
+// InvokeResult is the body of a successful invoke
+type InvokeResult struct {
+	Function string `json:"function"`
+	Message  string `json:"message,omitempty"` // transaction ID on a peer
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" && r.URL.Path == "/openapi.json" {
+		writeJSON(w, http.StatusOK, OpenAPI(g.Routes))
+		return
+	}
+
+	route, pathValues, allowed := g.match(r)
+	if route == nil {
+		if len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			writeError(w, Error{Status: http.StatusMethodNotAllowed, Message: "method " + r.Method + " not allowed"})
+			return
+		}
+		writeError(w, Error{Status: http.StatusNotFound, Code: client.NotFound, Message: "no resource at " + r.URL.Path})
+		return
+	}
+
+	args, transient, verr := buildArgs(route, r, pathValues)
+	if verr != nil {
+		writeError(w, *verr)
+		return
+	}
+
+	if route.Invoke && r.URL.Query().Get(DryRunParam) == "true" {
+		g.dryRun(w, r, route, args, transient)
+		return
+	}
+
+	var payload []byte
+	var err error
+	if route.Invoke {
+		payload, err = client.Invoke(g.Client, route.Function, args, transient)
+	} else {
+		payload, err = client.Query(g.Client, route.Function, args, transient)
+	}
+	if err != nil {
+		e := errorFor(err)
+		g.logf("%s %s: %s: %d %v", r.Method, r.URL.Path, route.Function, e.Status, err)
+		writeError(w, e)
+		return
+	}
+	g.logf("%s %s: %s: ok", r.Method, r.URL.Path, route.Function)
+
+	if route.Invoke {
+		status := http.StatusOK
+		if route.Created {
+			status = http.StatusCreated
+		}
+		writeJSON(w, status, InvokeResult{Function: route.Function, Message: string(payload)})
+		return
+	}
+
+	payload = bytes.TrimSpace(payload)
+	if len(payload) == 0 || string(payload) == "null" {
+		if route.Single {
+			writeError(w, Error{Status: http.StatusNotFound, Code: client.NotFound, Message: "not found"})
+			return
+		}
+		payload = []byte("null")
+	}
+	if !json.Valid(payload) {
+		writeError(w, Error{Status: http.StatusBadGateway, Message: "chaincode returned a payload that is not JSON"})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(payload)
+}
+
+// dryRun checks an invoke with the chaincode's dryRun query instead of submitting it. The report
+// is returned with 200 whether or not the invoke would succeed.
+func (g *Gateway) dryRun(w http.ResponseWriter, r *http.Request, route *Route, args []string, transient map[string][]byte) {
+	encoded, _ := json.Marshal(args)
+	payload, err := client.Query(g.Client, "dryRun", []string{route.Function, string(encoded)}, transient)
+	if err != nil {
+		e := errorFor(err)
+		g.logf("%s %s: dry run %s: %d %v", r.Method, r.URL.Path, route.Function, e.Status, err)
+		writeError(w, e)
+		return
+	}
+	g.logf("%s %s: dry run %s: ok", r.Method, r.URL.Path, route.Function)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(bytes.TrimSpace(payload))
+}
+
+func (g *Gateway) logf(format string, v ...interface{}) {
+	if g.Logger != nil {
+		g.Logger.Printf(format, v...)
+	}
+}
+
+// match finds the route of a request. Routes sharing a path are told apart by their When query
+// parameters, the first route whose When parameters are all present wins.
+// allowed lists the methods of the path when only the method is wrong.
+func (g *Gateway) match(r *http.Request) (*Route, map[string]string, []string) {
+	segments := splitPath(r.URL.Path)
+	q := r.URL.Query()
+	var allowed []string
+
+	for i := range g.Routes {
+		route := &g.Routes[i]
+		values, ok := matchPath(splitPath(route.Path), segments)
+		if !ok {
+			continue
+		}
+		if route.Method != r.Method {
+			allowed = appendOnce(allowed, route.Method)
+			continue
+		}
+		selected := true
+		for _, name := range route.When {
+			if q.Get(name) == "" {
+				selected = false
+				break
+			}
+		}
+		if selected {
+			return route, values, nil
+		}
+	}
+	return nil, nil, allowed
+}
+
+func splitPath(p string) []string {
+	return strings.Split(strings.Trim(p, "/"), "/")
+}
+
+func matchPath(pattern, segments []string) (map[string]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+	values := make(map[string]string)
+	for i, p := range pattern {
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			values[p[1:len(p)-1]] = segments[i]
+		} else if p != segments[i] {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+func appendOnce(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// buildArgs reads and checks the route parameters, returning them in chaincode argument order and
+// the Transient ones in the transient map, their argument left empty
+func buildArgs(route *Route, r *http.Request, pathValues map[string]string) ([]string, map[string][]byte, *Error) {
+	var bodyValues map[string]json.RawMessage
+	if hasBodyParams(route) {
+		dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBodyBytes))
+		if err := dec.Decode(&bodyValues); err != nil {
+			return nil, nil, &Error{Status: http.StatusBadRequest, Code: client.InvalidArgument, Message: "request body must be a JSON object: " + err.Error()}
+		}
+		for name := range bodyValues {
+			if !hasParam(route, InBody, name) {
+				return nil, nil, &Error{Status: http.StatusBadRequest, Code: client.InvalidArgument, Message: "unknown field " + name, Field: name}
+			}
+		}
+	}
+
+	q := r.URL.Query()
+	args := make([]string, 0, len(route.Params))
+	transient := make(map[string][]byte)
+	for _, p := range route.Params {
+		var v string
+		var verr *Error
+		switch p.In {
+		case InPath:
+			v = pathValues[p.Name]
+		case InQuery:
+			v = q.Get(p.Name)
+		case InUser:
+			v = r.Header.Get(UserHeader)
+			if v == "" {
+				return nil, nil, &Error{Status: http.StatusBadRequest, Code: client.InvalidArgument, Message: UserHeader + " header is required", Field: UserHeader}
+			}
+		case InBody:
+			v, verr = bodyValue(p, bodyValues[p.Name])
+		}
+		if verr != nil {
+			return nil, nil, verr
+		}
+		if verr := checkValue(p, v); verr != nil {
+			return nil, nil, verr
+		}
+
+		if p.Transient {
+			if v != "" {
+				transient[p.Name] = []byte(v)
+			}
+			args = append(args, "")
+			continue
+		}
+
+		if p.Type == TypeBoolean {
+			if v == "true" {
+				args = append(args, "true")
+			} else if !p.Optional {
+				args = append(args, "false")
+			}
+			continue
+		}
+		if p.Optional && v == "" {
+			continue
+		}
+		args = append(args, v)
+	}
+	return args, transient, nil
+}
+
+func hasBodyParams(route *Route) bool {
+	for _, p := range route.Params {
+		if p.In == InBody {
+			return true
+		}
+	}
+	return false
+}
+
+func hasTransientParams(route *Route) bool {
+	for _, p := range route.Params {
+		if p.Transient {
+			return true
+		}
+	}
+	return false
+}
+
+func hasParam(route *Route, in, name string) bool {
+	for _, p := range route.Params {
+		if p.In == in && p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyValue turns a JSON body field into the chaincode argument text
+func bodyValue(p Param, raw json.RawMessage) (string, *Error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	if p.Type == TypeJSON {
+		var out bytes.Buffer
+		json.Compact(&out, raw)
+		return out.String(), nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	switch p.Type {
+	case TypeInteger:
+		var n json.Number
+		if json.Unmarshal(raw, &n) == nil {
+			return n.String(), nil
+		}
+	case TypeBoolean:
+		var b bool
+		if json.Unmarshal(raw, &b) == nil {
+			return strconv.FormatBool(b), nil
+		}
+	}
+	return "", &Error{Status: http.StatusBadRequest, Code: client.InvalidArgument, Message: p.Name + " must be a " + jsonType(p.Type), Field: p.Name}
+}
+
+func jsonType(typ string) string {
+	switch typ {
+	case TypeInteger:
+		return "number"
+	case TypeBoolean:
+		return "boolean"
+	}
+	return "string"
+}
+
+// checkValue validates a parameter value against its declaration
+func checkValue(p Param, v string) *Error {
+	invalid := func(format string, a ...interface{}) *Error {
+		return &Error{Status: http.StatusBadRequest, Code: client.InvalidArgument, Message: p.Name + " " + fmt.Sprintf(format, a...), Field: p.Name}
+	}
+	if v == "" {
+		if p.Required {
+			return invalid("is required")
+		}
+		return nil
+	}
+
+	switch p.Type {
+	case TypeDate:
+		if !datePattern.MatchString(v) {
+			return invalid("must be a date YYYYMMDDHHMMSS (UTC) or ISO-8601 with an offset, e.g. 2017-06-08T15:45:00+05:30")
+		}
+	case TypeInteger:
+		if _, err := strconv.Atoi(v); err != nil {
+			return invalid("must be an integer")
+		}
+	case TypeBoolean:
+		if v != "true" && v != "false" {
+			return invalid("must be true or false")
+		}
+	case TypeJSON:
+		if !json.Valid([]byte(v)) {
+			return invalid("must be JSON")
+		}
+	}
+	if len(p.Enum) > 0 {
+		for _, e := range p.Enum {
+			if v == e {
+				return nil
+			}
+		}
+		return invalid("must be one of %s", strings.Join(p.Enum, ", "))
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, e Error) {
+	writeJSON(w, e.Status, errorBody{Error: e})
+}
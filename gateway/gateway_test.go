@@ -0,0 +1,346 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GHSagarnil/TracknTrace3/client"
+)
+
+// fakeCall is one call a fakeClient received
+type fakeCall struct {
+	invoke    bool
+	function  string
+	args      []string
+	transient map[string][]byte
+}
+
+// fakeClient records its calls and answers them with payload and err
+type fakeClient struct {
+	calls   []fakeCall
+	payload []byte
+	err     error
+}
+
+func (c *fakeClient) Invoke(function string, args []string) ([]byte, error) {
+	return c.InvokeTransient(function, args, nil)
+}
+
+func (c *fakeClient) Query(function string, args []string) ([]byte, error) {
+	return c.QueryTransient(function, args, nil)
+}
+
+func (c *fakeClient) InvokeTransient(function string, args []string, transient map[string][]byte) ([]byte, error) {
+	c.calls = append(c.calls, fakeCall{true, function, args, transient})
+	return c.payload, c.err
+}
+
+func (c *fakeClient) QueryTransient(function string, args []string, transient map[string][]byte) ([]byte, error) {
+	c.calls = append(c.calls, fakeCall{false, function, args, transient})
+	return c.payload, c.err
+}
+
+// plainClient is a Client without a transient map
+type plainClient struct {
+	calls int
+}
+
+func (c *plainClient) Invoke(function string, args []string) ([]byte, error) {
+	c.calls++
+	return nil, nil
+}
+
+func (c *plainClient) Query(function string, args []string) ([]byte, error) {
+	c.calls++
+	return nil, nil
+}
+
+// serve sends a request to a gateway on c, user set as the calling user when not empty
+func serve(c client.Client, method, target, user, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if user != "" {
+		r.Header.Set(UserHeader, user)
+	}
+	w := httptest.NewRecorder()
+	New(c).ServeHTTP(w, r)
+	return w
+}
+
+// responseError decodes the error body of a response
+func responseError(t *testing.T, w *httptest.ResponseRecorder) Error {
+	t.Helper()
+	var body errorBody
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("error body %q: %v", w.Body.String(), err)
+	}
+	if body.Error.Status != w.Code {
+		t.Errorf("error body status = %d, response status %d", body.Error.Status, w.Code)
+	}
+	return body.Error
+}
+
+const assemblyJSON = `{"assemblyId":"A1","deviceSerialNo":"S1","deviceType":"HOLDER","assemblyStatus":"1","assemblyDate":"2017-06-08T15:45:00+05:30"}`
+
+func TestCreateAssembly(t *testing.T) {
+	c := &fakeClient{payload: []byte("tx1")}
+	w := serve(c, "POST", "/assemblies", "al", assemblyJSON)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body)
+	}
+	var res InvokeResult
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res != (InvokeResult{Function: "createAssembly", Message: "tx1"}) {
+		t.Errorf("result = %+v", res)
+	}
+
+	if len(c.calls) != 1 || !c.calls[0].invoke || c.calls[0].function != "createAssembly" {
+		t.Fatalf("calls = %+v, want one createAssembly invoke", c.calls)
+	}
+	want := []string{"A1", "S1", "HOLDER", "", "", "", "", "", "", "", "", "1", "2017-06-08T15:45:00+05:30", "", "", "", "al"}
+	if got := c.calls[0].args; !reflect.DeepEqual(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+	if len(c.calls[0].transient[client.SaltField]) == 0 {
+		t.Error("invoke through a TransientClient carries no salt")
+	}
+}
+
+func TestTransientParams(t *testing.T) {
+	c := &fakeClient{}
+	w := serve(c, "PUT", "/packages/C1/customer", "pl", `{"customerName":"Jane","shippingToAddress":{"city": "Pune"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	call := c.calls[0]
+	if want := []string{"C1", "", "", "", "", "pl"}; !reflect.DeepEqual(call.args, want) {
+		t.Errorf("args = %q, want the transient slots left empty %q", call.args, want)
+	}
+	if got := string(call.transient["customerName"]); got != "Jane" {
+		t.Errorf("transient customerName = %q", got)
+	}
+	if got := string(call.transient["shippingToAddress"]); got != `{"city":"Pune"}` {
+		t.Errorf("transient shippingToAddress = %q, want compact JSON", got)
+	}
+	if _, ok := call.transient["customerPhone"]; ok {
+		t.Error("unset transient field customerPhone is in the transient map")
+	}
+
+	plain := &plainClient{}
+	w = serve(plain, "PUT", "/packages/C1/customer", "pl", `{"customerName":"Jane"}`)
+	if w.Code != http.StatusNotImplemented || plain.calls != 0 {
+		t.Errorf("transient data through a plain client: status = %d, %d calls, want 501 and none", w.Code, plain.calls)
+	}
+}
+
+func TestQueryRoutes(t *testing.T) {
+	tests := []struct {
+		target   string
+		function string
+		args     []string
+	}{
+		{"/assemblies", "getAllAssemblies", []string{"al"}},
+		{"/assemblies?includeCancelled=true", "getAllAssemblies", []string{"al", "true"}},
+		{"/assemblies?serial=S1", "getAssembliesBySerialNo", nil},
+		{"/assemblies?batchType=LedBatchId&batch=B1", "getAssembliesByBatchNumber", []string{"LedBatchId", "B1", "al"}},
+		{"/assemblies?batchType=LedBatchId&batch=B1&from=20170101000000&to=20171231000000", "getAssembliesByBatchNumberAndByDate",
+			[]string{"LedBatchId", "B1", "20170101000000", "20171231000000", "al"}},
+		{"/assemblies/A1", "getAssemblyByID", nil},
+	}
+	for _, tc := range tests {
+		c := &fakeClient{payload: []byte(` [] `)}
+		w := serve(c, "GET", tc.target, "al", "")
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s: status = %d, want 200: %s", tc.target, w.Code, w.Body)
+			continue
+		}
+		if len(c.calls) != 1 || c.calls[0].invoke || c.calls[0].function != tc.function {
+			t.Errorf("GET %s: calls = %+v, want one %s query", tc.target, c.calls, tc.function)
+			continue
+		}
+		if tc.args != nil && !reflect.DeepEqual(c.calls[0].args, tc.args) {
+			t.Errorf("GET %s: args = %q, want %q", tc.target, c.calls[0].args, tc.args)
+		}
+		if got := w.Body.String(); got != "[]" {
+			t.Errorf("GET %s: body = %q, want the payload trimmed", tc.target, got)
+		}
+	}
+}
+
+func TestQueryPayloads(t *testing.T) {
+	tests := []struct {
+		target  string
+		payload string
+		status  int
+		body    string
+	}{
+		{"/assemblies/A1", "", http.StatusNotFound, ""},
+		{"/assemblies/A1", "null", http.StatusNotFound, ""},
+		{"/assemblies", "", http.StatusOK, "null"},
+		{"/assemblies", "not json", http.StatusBadGateway, ""},
+	}
+	for _, tc := range tests {
+		w := serve(&fakeClient{payload: []byte(tc.payload)}, "GET", tc.target, "al", "")
+		if w.Code != tc.status {
+			t.Errorf("GET %s returning %q: status = %d, want %d", tc.target, tc.payload, w.Code, tc.status)
+			continue
+		}
+		if tc.status != http.StatusOK {
+			e := responseError(t, w)
+			if tc.status == http.StatusNotFound && e.Code != client.NotFound {
+				t.Errorf("GET %s returning %q: code = %q, want %s", tc.target, tc.payload, e.Code, client.NotFound)
+			}
+		} else if got := w.Body.String(); got != tc.body {
+			t.Errorf("GET %s returning %q: body = %q, want %q", tc.target, tc.payload, got, tc.body)
+		}
+	}
+}
+
+func TestRequestErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		user   string
+		body   string
+		status int
+		field  string
+	}{
+		{"no user", "GET", "/assemblies", "", "", http.StatusBadRequest, UserHeader},
+		{"unknown field", "POST", "/assemblies", "al", `{"assemblyId":"A1","colour":"red"}`, http.StatusBadRequest, "colour"},
+		{"body not an object", "POST", "/assemblies", "al", `["A1"]`, http.StatusBadRequest, ""},
+		{"missing required", "POST", "/assemblies", "al", `{"assemblyId":"A1"}`, http.StatusBadRequest, "deviceSerialNo"},
+		{"bad date", "POST", "/assemblies", "al", strings.Replace(assemblyJSON, "2017-06-08T15:45:00+05:30", "08/06/2017", 1), http.StatusBadRequest, "assemblyDate"},
+		{"number for a string", "POST", "/assemblies", "al", strings.Replace(assemblyJSON, `"S1"`, `7`, 1), http.StatusBadRequest, "deviceSerialNo"},
+		{"not in enum", "GET", "/assemblies?batchType=Glue&batch=B1", "al", "", http.StatusBadRequest, "batchType"},
+		{"bad boolean", "GET", "/assemblies?includeCancelled=yes", "al", "", http.StatusBadRequest, "includeCancelled"},
+	}
+	for _, tc := range tests {
+		c := &fakeClient{}
+		w := serve(c, tc.method, tc.target, tc.user, tc.body)
+		if w.Code != tc.status {
+			t.Errorf("%s: status = %d, want %d: %s", tc.name, w.Code, tc.status, w.Body)
+			continue
+		}
+		e := responseError(t, w)
+		if e.Code != client.InvalidArgument || e.Field != tc.field {
+			t.Errorf("%s: error = %+v, want %s on field %q", tc.name, e, client.InvalidArgument, tc.field)
+		}
+		if len(c.calls) != 0 {
+			t.Errorf("%s: chaincode called with a bad request: %+v", tc.name, c.calls)
+		}
+	}
+}
+
+func TestUnknownRoutes(t *testing.T) {
+	w := serve(&fakeClient{}, "GET", "/widgets", "al", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown path: status = %d, want 404", w.Code)
+	} else if e := responseError(t, w); e.Code != client.NotFound {
+		t.Errorf("unknown path: code = %q, want %s", e.Code, client.NotFound)
+	}
+
+	w = serve(&fakeClient{}, "DELETE", "/assemblies/A1", "al", "")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("wrong method: status = %d, want 405", w.Code)
+	}
+	if got := w.Header().Get("Allow"); got != "GET, PUT" {
+		t.Errorf("Allow = %q, want \"GET, PUT\"", got)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	c := &fakeClient{payload: []byte(`{"valid":true}`)}
+	w := serve(c, "POST", "/assemblies?dryRun=true", "al", assemblyJSON)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	if got := w.Body.String(); got != `{"valid":true}` {
+		t.Errorf("body = %q, want the dry run report", got)
+	}
+	if len(c.calls) != 1 || c.calls[0].invoke || c.calls[0].function != "dryRun" {
+		t.Fatalf("calls = %+v, want one dryRun query", c.calls)
+	}
+	args := c.calls[0].args
+	if len(args) != 2 || args[0] != "createAssembly" {
+		t.Fatalf("dryRun args = %q", args)
+	}
+	var inner []string
+	if err := json.Unmarshal([]byte(args[1]), &inner); err != nil || len(inner) != 17 || inner[0] != "A1" {
+		t.Errorf("dryRun function args = %q (%v)", args[1], err)
+	}
+}
+
+func TestChaincodeErrors(t *testing.T) {
+	c := &fakeClient{err: &client.ChaincodeError{Code: client.InvalidTransition, Message: "assembly is packed", Field: "assemblyStatus"}}
+	w := serve(c, "PUT", "/assemblies/A1", "al", strings.Replace(assemblyJSON, `"assemblyId":"A1",`, "", 1))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409: %s", w.Code, w.Body)
+	}
+	want := Error{Status: http.StatusConflict, Code: client.InvalidTransition, Message: "assembly is packed", Field: "assemblyStatus"}
+	if e := responseError(t, w); e != want {
+		t.Errorf("error = %+v, want %+v", e, want)
+	}
+}
+
+func TestStatusFor(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, http.StatusOK},
+		{&client.ChaincodeError{Code: client.NotFound}, http.StatusNotFound},
+		{&client.ChaincodeError{Code: client.AlreadyExists}, http.StatusConflict},
+		{&client.ChaincodeError{Code: client.Forbidden}, http.StatusForbidden},
+		{&client.ChaincodeError{Code: client.InvalidArgument}, http.StatusBadRequest},
+		{&client.ChaincodeError{Code: client.InvalidTransition}, http.StatusConflict},
+		{&client.ChaincodeError{Code: client.CorruptState}, http.StatusInternalServerError},
+		{&client.ChaincodeError{Code: "SOMETHING_NEW"}, http.StatusInternalServerError},
+		{client.ErrNoTransient, http.StatusNotImplemented},
+		{&url.Error{Op: "Post", URL: "http://peer", Err: errors.New("connection refused")}, http.StatusBadGateway},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tc := range tests {
+		if got := StatusFor(tc.err); got != tc.want {
+			t.Errorf("StatusFor(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestOpenAPIServed(t *testing.T) {
+	w := serve(&fakeClient{}, "GET", "/openapi.json", "", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := doc["paths"]; !ok {
+		t.Error("OpenAPI document has no paths")
+	}
+}
@@ -0,0 +1,301 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package gateway
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/GHSagarnil/TracknTrace3/chaincode/tnt"
+	"github.com/GHSagarnil/TracknTrace3/client"
+)
+
+// OpenAPI returns the OpenAPI 3.0 document of routes, ready to be marshalled as JSON
+func OpenAPI(routes []Route) map[string]interface{} {
+	schemas := map[string]interface{}{
+		"Error": object(map[string]interface{}{
+			"error": object(map[string]interface{}{
+				"status": map[string]interface{}{"type": "integer"},
+				"code": map[string]interface{}{"type": "string", "enum": []string{client.NotFound, client.AlreadyExists, client.Forbidden,
+					client.InvalidArgument, client.InvalidTransition, client.CorruptState}},
+				"message": map[string]interface{}{"type": "string"},
+				"field":   map[string]interface{}{"type": "string"},
+			}, []string{"status", "message"}),
+		}, []string{"error"}),
+		"InvokeResult": schemaOf(reflect.TypeOf(InvokeResult{}), nil),
+	}
+
+	paths := make(map[string]interface{})
+	operationIds := make(map[string]bool)
+	for _, ops := range groupRoutes(routes) {
+		item, ok := paths[ops[0].Path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[ops[0].Path] = item
+		}
+		op := operation(ops, schemas)
+		// a function served at two paths gets the tag of the second appended
+		if id := op["operationId"].(string); operationIds[id] {
+			op["operationId"] = id + "_" + ops[0].Tag
+		}
+		operationIds[op["operationId"].(string)] = true
+		item[strings.ToLower(ops[0].Method)] = op
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "TnT gateway",
+			"version": "1.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"user": map[string]interface{}{"type": "apiKey", "in": "header", "name": UserHeader},
+			},
+		},
+	}
+}
+
+// groupRoutes collects the routes sharing a method and path, in route order
+func groupRoutes(routes []Route) [][]Route {
+	var groups [][]Route
+	index := make(map[string]int)
+	for _, r := range routes {
+		key := r.Method + " " + r.Path
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], r)
+	}
+	return groups
+}
+
+// operation describes one method and path. Routes told apart by query parameters are merged,
+// their query parameters becoming optional and the description listing which selects what.
+func operation(ops []Route, schemas map[string]interface{}) map[string]interface{} {
+	first := ops[0]
+	op := map[string]interface{}{
+		"operationId": first.Function,
+		"summary":     first.Summary,
+		"tags":        []string{first.Tag},
+	}
+
+	var params []interface{}
+	seen := make(map[string]bool)
+	var lines []string
+	for _, r := range ops {
+		if len(ops) > 1 {
+			selector := "no selecting parameters"
+			if len(r.When) > 0 {
+				selector = strings.Join(r.When, ", ")
+			}
+			lines = append(lines, "- "+selector+": "+r.Summary+" ("+r.Function+")")
+		}
+		for _, p := range r.Params {
+			if (p.In != InPath && p.In != InQuery) || seen[p.In+p.Name] {
+				continue
+			}
+			seen[p.In+p.Name] = true
+			params = append(params, map[string]interface{}{
+				"name":        p.Name,
+				"in":          p.In,
+				"required":    p.In == InPath || (p.Required && len(ops) == 1),
+				"description": p.Doc,
+				"schema":      paramSchema(p),
+			})
+		}
+	}
+	if len(lines) > 0 {
+		op["operationId"] = ops[len(ops)-1].Function
+		op["summary"] = ops[len(ops)-1].Summary
+		op["description"] = "Selected by the query parameters present:\n" + strings.Join(lines, "\n")
+	}
+	if first.Invoke {
+		params = append(params, map[string]interface{}{
+			"name":        DryRunParam,
+			"in":          InQuery,
+			"required":    false,
+			"description": "run every check of the invoke without submitting it, returning a Validation_Report",
+			"schema":      map[string]interface{}{"type": "boolean"},
+		})
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	if hasParam(&first, InUser, "user") {
+		op["security"] = []interface{}{map[string]interface{}{"user": []string{}}}
+	}
+
+	if hasBodyParams(&first) {
+		props := make(map[string]interface{})
+		var required []string
+		for _, p := range first.Params {
+			if p.In != InBody {
+				continue
+			}
+			props[p.Name] = paramSchema(p)
+			if p.Required {
+				required = append(required, p.Name)
+			}
+		}
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": object(props, required)},
+			},
+		}
+	}
+
+	responses := map[string]interface{}{}
+	errorResponse := map[string]interface{}{
+		"description": "error",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": ref("Error")},
+		},
+	}
+	for _, status := range []int{400, 403, 404, 409, 500, 502} {
+		responses[strconv.Itoa(status)] = errorResponse
+	}
+	if hasTransientParams(&first) {
+		responses["501"] = errorResponse
+	}
+	if first.Invoke {
+		status := http.StatusOK
+		if first.Created {
+			status = http.StatusCreated
+		}
+		responses[strconv.Itoa(status)] = jsonResponse("submitted", ref("InvokeResult"))
+		report := schemaOf(reflect.TypeOf(tnt.Validation_Report{}), schemas)
+		if status == http.StatusOK {
+			responses["200"] = jsonResponse("submitted, or the report of a dry run",
+				map[string]interface{}{"oneOf": []interface{}{ref("InvokeResult"), report}})
+		} else {
+			responses["200"] = jsonResponse("report of a dry run", report)
+		}
+	} else {
+		var schema interface{} = map[string]interface{}{}
+		if first.Result != nil {
+			schema = schemaOf(reflect.TypeOf(first.Result), schemas)
+		}
+		responses["200"] = jsonResponse("result", schema)
+	}
+	op["responses"] = responses
+	return op
+}
+
+func jsonResponse(description string, schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		},
+	}
+}
+
+func paramSchema(p Param) map[string]interface{} {
+	s := map[string]interface{}{}
+	switch p.Type {
+	case TypeDate:
+		s["type"] = "string"
+		s["pattern"] = datePattern.String()
+	case TypeInteger:
+		s["type"] = "integer"
+	case TypeBoolean:
+		s["type"] = "boolean"
+	case TypeJSON:
+		// any JSON value
+	default:
+		s["type"] = "string"
+	}
+	if len(p.Enum) > 0 {
+		s["enum"] = p.Enum
+	}
+	return s
+}
+
+func object(props map[string]interface{}, required []string) map[string]interface{} {
+	s := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// schemaOf describes a Go type by its JSON encoding. Named structs are added to schemas and
+// referenced; a nil schemas inlines them.
+func schemaOf(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem(), schemas)
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Struct:
+		if schemas != nil && t.Name() != "" {
+			if _, ok := schemas[t.Name()]; !ok {
+				schemas[t.Name()] = nil // placeholder, stops recursion
+				schemas[t.Name()] = structSchema(t, schemas)
+			}
+			return ref(t.Name())
+		}
+		return structSchema(t, schemas)
+	}
+	return map[string]interface{}{}
+}
+
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	props := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" { // unexported
+			continue
+		}
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		props[name] = schemaOf(f.Type, schemas)
+	}
+	return object(props, nil)
+}
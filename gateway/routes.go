@@ -0,0 +1,359 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package gateway
+
+import (
+	"github.com/GHSagarnil/TracknTrace3/chaincode/tnt"
+)
+
+// Where a parameter is read from
+const (
+	InPath  = "path"
+	InQuery = "query"
+	InBody  = "body"
+	InUser  = "user" // the X-TnT-User header
+)
+
+// Parameter types, checked before the chaincode is called
+const (
+	TypeString  = "string"
+	TypeDate    = "date"    // YYYYMMDDHHMMSS in UTC or ISO-8601 with an offset - a + in a query is sent as %2B
+	TypeInteger = "integer" // sent to the chaincode as decimal text
+	TypeBoolean = "boolean" // sent as "true", left out when false and Optional
+	TypeJSON    = "json"    // any JSON value, sent as compact JSON text
+)
+
+// Param is one chaincode argument
+type Param struct {
+	Name     string
+	In       string
+	Type     string
+	Required bool
+	Optional bool // trailing argument left out when not set
+	Enum     []string
+	Doc      string
+	// Transient body fields are sent in the transient map rather than as arguments, so they stay
+	// out of the transaction - customer details the chaincode keeps in private data. Their argument
+	// is sent empty, it is the slot the field had before
+	Transient bool
+}
+
+// Route maps an HTTP method and path to a chaincode function.
+// Params are listed in chaincode argument order.
+type Route struct {
+	Method   string
+	Path     string   // e.g. /assemblies/{id}
+	When     []string // query parameters that select this route among routes of the same path
+	Function string
+	Invoke   bool
+	Summary  string
+	Tag      string
+	Params   []Param
+	Result   interface{} // sample of the result, for the OpenAPI schema
+	Created  bool        // answers 201 Created
+	Single   bool        // answers 404 when the chaincode returns nothing
+}
+
+var user = Param{Name: "user", In: InUser, Type: TypeString, Required: true}
+
+func path(name, doc string) Param {
+	return Param{Name: name, In: InPath, Type: TypeString, Required: true, Doc: doc}
+}
+
+func query(name, typ string, required bool, doc string) Param {
+	return Param{Name: name, In: InQuery, Type: typ, Required: required, Doc: doc}
+}
+
+func body(name, typ string, required bool, doc string) Param {
+	return Param{Name: name, In: InBody, Type: typ, Required: required, Doc: doc}
+}
+
+func transientBody(name, typ string, required bool, doc string) Param {
+	p := body(name, typ, required, doc)
+	p.Transient = true
+	return p
+}
+
+var includeCancelled = Param{Name: "includeCancelled", In: InQuery, Type: TypeBoolean, Optional: true, Doc: "include cancelled records"}
+
+var cancelReasons = []string{tnt.CANCEL_DUPLICATE, tnt.CANCEL_DATA_ERROR, tnt.CANCEL_DAMAGED, tnt.CANCEL_ORDER_WITHDRAWN, tnt.CANCEL_OTHER}
+
+var packageStatuses = []string{tnt.PACKAGESTATUS_PKG, tnt.PACKAGESTATUS_RET}
+
+var batchTypes = []string{"FilamentBatchId", "LedBatchId", "CircuitBoardBatchId", "WireBatchId", "CasingBatchId", "AdaptorBatchId", "StickPodBatchId"}
+
+// assemblyBody returns the createAssembly / updateAssemblyByID arguments, the ID taken from idIn
+func assemblyBody(idIn string) []Param {
+	id := body("assemblyId", TypeString, true, "assembly ID")
+	if idIn == InPath {
+		id = path("assemblyId", "assembly ID")
+	}
+	return []Param{
+		id,
+		body("deviceSerialNo", TypeString, true, "device serial number"),
+		body("deviceType", TypeString, true, "device type, e.g. HOLDER or CHARGER"),
+		body("filamentBatchId", TypeString, false, ""),
+		body("ledBatchId", TypeString, false, ""),
+		body("circuitBoardBatchId", TypeString, false, ""),
+		body("wireBatchId", TypeString, false, ""),
+		body("casingBatchId", TypeString, false, ""),
+		body("adaptorBatchId", TypeString, false, ""),
+		body("stickPodBatchId", TypeString, false, ""),
+		body("manufacturingPlant", TypeString, false, ""),
+		body("assemblyStatus", TypeString, true, ""),
+		body("assemblyDate", TypeDate, true, ""),
+		body("assemblyPackage", TypeString, false, "case ID of the package"),
+		body("assemblyInfo1", TypeString, false, ""),
+		body("assemblyInfo2", TypeString, false, ""),
+		user,
+	}
+}
+
+// packageBody returns the createPackage / updatePackage arguments, the case ID taken from idIn
+func packageBody(idIn string) []Param {
+	id := body("caseId", TypeString, true, "case ID")
+	if idIn == InPath {
+		id = path("caseId", "case ID")
+	}
+	return []Param{
+		id,
+		body("holderAssemblyId", TypeString, false, ""),
+		body("chargerAssemblyId", TypeString, false, ""),
+		{Name: "packageStatus", In: InBody, Type: TypeString, Required: true, Enum: packageStatuses, Doc: "7 Packaged, 9 Returned (update only)"},
+		body("packagingDate", TypeDate, true, "not before the AssemblyDate of the packed assemblies"),
+		transientBody("shippingToAddress", TypeJSON, false, "address object with line1, line2, city, region, postalCode and country (ISO 3166-1 alpha-2), "+
+			"sent in the transient map and kept in the private data collection; required on create, left out on update keeps the stored one"),
+		body("assemblyStatus", TypeString, true, "status set on the packed assemblies"),
+		body("packageInfo1", TypeString, false, ""),
+		body("packageInfo2", TypeString, false, ""),
+		user,
+	}
+}
+
+var cancelBody = []Param{
+	{Name: "reasonCode", In: InBody, Type: TypeString, Required: true, Enum: cancelReasons},
+	body("comment", TypeString, false, ""),
+	user,
+}
+
+func withID(id Param, params []Param) []Param {
+	return append([]Param{id}, params...)
+}
+
+// Routes is the HTTP API of the gateway
+var Routes = []Route{
+	// Assemblies
+	{Method: "POST", Path: "/assemblies", Function: "createAssembly", Invoke: true, Created: true, Tag: "assemblies",
+		Summary: "Create an assembly", Params: assemblyBody(InBody)},
+	{Method: "GET", Path: "/assemblies", When: []string{"batchType", "batch", "from", "to"}, Function: "getAssembliesByBatchNumberAndByDate", Tag: "assemblies",
+		Summary: "Assemblies built with a component batch between two dates",
+		Params: []Param{{Name: "batchType", In: InQuery, Type: TypeString, Required: true, Enum: batchTypes},
+			query("batch", TypeString, true, "batch number"), query("from", TypeDate, true, ""), query("to", TypeDate, true, ""), user, includeCancelled},
+		Result: []tnt.AssemblyLine{}},
+	{Method: "GET", Path: "/assemblies", When: []string{"batchType", "batch"}, Function: "getAssembliesByBatchNumber", Tag: "assemblies",
+		Summary: "Assemblies built with a component batch",
+		Params: []Param{{Name: "batchType", In: InQuery, Type: TypeString, Required: true, Enum: batchTypes},
+			query("batch", TypeString, true, "batch number"), user, includeCancelled},
+		Result: []tnt.AssemblyLine{}},
+	{Method: "GET", Path: "/assemblies", When: []string{"from", "to"}, Function: "getAssembliesByDate", Tag: "assemblies",
+		Summary: "Assemblies built between two dates",
+		Params:  []Param{query("from", TypeDate, true, ""), query("to", TypeDate, true, ""), user, includeCancelled},
+		Result:  []tnt.AssemblyLine{}},
+	{Method: "GET", Path: "/assemblies", When: []string{"serial"}, Function: "getAssembliesBySerialNo", Tag: "assemblies",
+		Summary: "Assemblies registered with a serial number",
+		Params:  []Param{query("serial", TypeString, true, "device serial number"), query("deviceType", TypeString, false, "all device types when empty"), user, includeCancelled},
+		Result:  []tnt.AssemblyLine{}},
+	{Method: "GET", Path: "/assemblies", Function: "getAllAssemblies", Tag: "assemblies",
+		Summary: "All assemblies", Params: []Param{user, includeCancelled}, Result: []tnt.AssemblyLine{}},
+	{Method: "GET", Path: "/assemblies/{assemblyId}", Function: "getAssemblyByID", Single: true, Tag: "assemblies",
+		Summary: "An assembly", Params: []Param{path("assemblyId", "assembly ID"), user}, Result: tnt.AssemblyLine{}},
+	{Method: "PUT", Path: "/assemblies/{assemblyId}", Function: "updateAssemblyByID", Invoke: true, Tag: "assemblies",
+		Summary: "Update an assembly", Params: assemblyBody(InPath)},
+	{Method: "PUT", Path: "/assemblies/{assemblyId}/serial", Function: "reassignDeviceSerialNo", Invoke: true, Tag: "assemblies",
+		Summary: "Give an assembly a new serial number",
+		Params:  []Param{path("assemblyId", "assembly ID"), body("deviceSerialNo", TypeString, true, ""), body("reason", TypeString, true, ""), user}},
+	{Method: "GET", Path: "/assemblies/{assemblyId}/history", Function: "getAssemblyLineHistoryByID", Tag: "assemblies",
+		Summary: "Every version of an assembly", Params: []Param{path("assemblyId", "assembly ID"), user}, Result: tnt.AssemblyLine_Holder{}},
+	{Method: "GET", Path: "/assemblies/{assemblyId}/serial-history", Function: "getSerialNoHistoryByID", Tag: "assemblies",
+		Summary: "Serial number changes of an assembly", Params: []Param{path("assemblyId", "assembly ID"), user}, Result: tnt.SerialNo_History{}},
+	{Method: "GET", Path: "/assemblies/{assemblyId}/verification", Function: "verifyAssembly", Tag: "assemblies",
+		Summary: "Check an assembly against its content hash",
+		Params:  []Param{path("assemblyId", "assembly ID"), query("record", TypeJSON, false, "exported assembly JSON to compare"), user},
+		Result:  tnt.Hash_Verification{}},
+	{Method: "POST", Path: "/assemblies/{assemblyId}/cancellation", Function: "cancelAssembly", Invoke: true, Created: true, Tag: "assemblies",
+		Summary: "Cancel an assembly", Params: withID(path("assemblyId", "assembly ID"), cancelBody)},
+	{Method: "GET", Path: "/assemblies/{assemblyId}/cancellation", Function: "getCancellationByID", Single: true, Tag: "assemblies",
+		Summary: "Cancellation of an assembly", Params: []Param{path("assemblyId", "assembly ID"), user}, Result: tnt.Cancellation{}},
+	{Method: "POST", Path: "/assemblies/{assemblyId}/scrap", Function: "scrapAssembly", Invoke: true, Created: true, Tag: "assemblies",
+		Summary: "Record the destruction of an assembly",
+		Params: []Param{path("assemblyId", "assembly ID"), body("method", TypeString, true, "destruction method"), body("witness", TypeString, true, "witnessing user"),
+			body("scrapDate", TypeDate, true, ""), body("comment", TypeString, false, ""), user}},
+	{Method: "GET", Path: "/assemblies/{assemblyId}/scrap", Function: "getScrapByAssemblyID", Single: true, Tag: "assemblies",
+		Summary: "Scrap record of an assembly", Params: []Param{path("assemblyId", "assembly ID"), user}, Result: tnt.Scrap_Record{}},
+	{Method: "POST", Path: "/assemblies/{assemblyId}/inspections", Function: "createQAInspection", Invoke: true, Created: true, Tag: "quality",
+		Summary: "Record a QA inspection",
+		Params: []Param{path("assemblyId", "assembly ID"), body("testStation", TypeString, true, ""), body("measuredValues", TypeJSON, false, ""),
+			body("inspectionResult", TypeString, true, ""), body("defectCodes", TypeJSON, false, ""), body("inspectionDate", TypeDate, true, ""), user}},
+	{Method: "GET", Path: "/assemblies/{assemblyId}/inspections", Function: "getQAInspectionsByAssemblyID", Tag: "quality",
+		Summary: "QA inspections of an assembly", Params: []Param{path("assemblyId", "assembly ID"), user}, Result: tnt.QA_Inspection_Holder{}},
+	{Method: "POST", Path: "/assemblies/{assemblyId}/reworks", Function: "reworkAssembly", Invoke: true, Created: true, Tag: "quality",
+		Summary: "Rework an assembly with replacement batches",
+		Params: []Param{path("assemblyId", "assembly ID"), body("replacementBatches", TypeJSON, true, "batch type to replacement batch number"),
+			body("reason", TypeString, true, ""), user}},
+	{Method: "GET", Path: "/assemblies/{assemblyId}/reworks", Function: "getReworksByAssemblyID", Tag: "quality",
+		Summary: "Reworks of an assembly", Params: []Param{path("assemblyId", "assembly ID"), user}, Result: tnt.Assembly_Rework_Holder{}},
+
+	// Packages
+	{Method: "POST", Path: "/packages", Function: "createPackage", Invoke: true, Created: true, Tag: "packages",
+		Summary: "Pack assemblies into a case", Params: packageBody(InBody)},
+	{Method: "GET", Path: "/packages", When: []string{"assemblyType", "assemblyId"}, Function: "getPackagesByAssemblyId", Tag: "packages",
+		Summary: "Packages of an assembly",
+		Params: []Param{{Name: "assemblyType", In: InQuery, Type: TypeString, Required: true, Enum: []string{"HolderAssemblyId", "ChargerAssemblyId"}},
+			query("assemblyId", TypeString, true, ""), user, includeCancelled},
+		Result: []tnt.PackageLine{}},
+	{Method: "GET", Path: "/packages", When: []string{"from", "to"}, Function: "getPackagesByDate", Tag: "packages",
+		Summary: "Packages packed between two dates",
+		Params:  []Param{query("from", TypeDate, true, ""), query("to", TypeDate, true, ""), user, includeCancelled},
+		Result:  []tnt.PackageLine{}},
+	{Method: "GET", Path: "/packages", Function: "getAllPackages", Tag: "packages",
+		Summary: "All packages", Params: []Param{user, includeCancelled}, Result: []tnt.PackageLine{}},
+	{Method: "GET", Path: "/packages/{caseId}", Function: "getPackageByID", Single: true, Tag: "packages",
+		Summary: "A package", Params: []Param{path("caseId", "case ID")}, Result: tnt.PackageLine{}},
+	{Method: "PUT", Path: "/packages/{caseId}", Function: "updatePackage", Invoke: true, Tag: "packages",
+		Summary: "Update a package", Params: packageBody(InPath)},
+	{Method: "GET", Path: "/packages/{caseId}/history", Function: "getPackageLineHistoryByID", Tag: "packages",
+		Summary: "Every version of a package", Params: []Param{path("caseId", "case ID"), user}, Result: tnt.PackageLine_Holder{}},
+	{Method: "GET", Path: "/packages/{caseId}/verification", Function: "verifyPackage", Tag: "packages",
+		Summary: "Check a package against its content hash",
+		Params:  []Param{path("caseId", "case ID"), query("record", TypeJSON, false, "exported package JSON to compare"), user},
+		Result:  tnt.Hash_Verification{}},
+	{Method: "GET", Path: "/packages/{caseId}/proof", Function: "getPackageInclusionProof", Tag: "packages",
+		Summary: "Merkle proof that a device is in the case",
+		Params:  []Param{path("caseId", "case ID"), query("serial", TypeString, true, "device serial number"), user},
+		Result:  tnt.Inclusion_Proof{}},
+	{Method: "POST", Path: "/packages/{caseId}/cancellation", Function: "cancelPackage", Invoke: true, Created: true, Tag: "packages",
+		Summary: "Cancel a package and free its assemblies", Params: withID(path("caseId", "case ID"), cancelBody)},
+	{Method: "GET", Path: "/packages/{caseId}/cancellation", Function: "getCancellationByID", Single: true, Tag: "packages",
+		Summary: "Cancellation of a package", Params: []Param{path("caseId", "case ID"), user}, Result: tnt.Cancellation{}},
+	{Method: "PUT", Path: "/packages/{caseId}/customer", Function: "updatePackageCustomerDetails", Invoke: true, Tag: "packages",
+		Summary: "Set the private customer details of a package",
+		Params: []Param{path("caseId", "case ID"), transientBody("customerName", TypeString, false, ""), transientBody("customerPhone", TypeString, false, ""),
+			transientBody("customerEmail", TypeString, false, ""), transientBody("shippingToAddress", TypeJSON, false, "address object as on packages"), user}},
+	{Method: "GET", Path: "/packages/{caseId}/customer", Function: "getPackageCustomerDetails", Tag: "packages",
+		Summary: "Private customer details of a package, for its shipper and recipients", Params: []Param{path("caseId", "case ID"), user}, Result: tnt.Customer_Details{}},
+	{Method: "GET", Path: "/packages/{caseId}/custody", Function: "getCustodyChainByCaseId", Tag: "shipments",
+		Summary: "Shipments and custody transfers of a package", Params: []Param{path("caseId", "case ID"), user}, Result: []tnt.Shipment_Line{}},
+
+	// Products
+	{Method: "GET", Path: "/products/{serial}/authenticity", Function: "getProductAuthenticity", Tag: "products",
+		Summary: "Authenticity check by serial number", Params: []Param{path("serial", "device serial number"), user}, Result: []tnt.Product_Authenticity{}},
+
+	// Component batches
+	{Method: "POST", Path: "/batches/{batchType}/{batch}/receipts", Function: "receiveComponentBatch", Invoke: true, Created: true, Tag: "batches",
+		Summary: "Record a goods receipt of a component batch",
+		Params: []Param{path("batchType", "e.g. LedBatchId"), path("batch", "batch number"), body("quantity", TypeInteger, true, ""),
+			body("receivedDate", TypeDate, true, ""), user}},
+	{Method: "POST", Path: "/batches/{batchType}/{batch}/scraps", Function: "scrapComponentBatch", Invoke: true, Created: true, Tag: "batches",
+		Summary: "Record the destruction of loose components",
+		Params: []Param{path("batchType", "e.g. LedBatchId"), path("batch", "batch number"), body("quantity", TypeInteger, true, ""),
+			body("method", TypeString, true, "destruction method"), body("witness", TypeString, true, "witnessing user"),
+			body("scrapDate", TypeDate, true, ""), body("comment", TypeString, false, ""), user}},
+	{Method: "POST", Path: "/batches/{batchType}/{batch}/recall", Function: "recallBatch", Invoke: true, Created: true, Tag: "batches",
+		Summary: "Recall a component batch",
+		Params:  []Param{path("batchType", "e.g. LedBatchId"), path("batch", "batch number"), body("reason", TypeString, true, ""), user}},
+	{Method: "GET", Path: "/batches/{batchType}/{batch}/reconciliation", Function: "getBatchReconciliation", Tag: "batches",
+		Summary: "Received vs consumed vs scrapped vs remaining",
+		Params:  []Param{path("batchType", "e.g. LedBatchId"), path("batch", "batch number"), user}, Result: tnt.Batch_Reconciliation{}},
+
+	// RMAs and shipments
+	{Method: "POST", Path: "/rmas", Function: "createRMA", Invoke: true, Created: true, Tag: "rmas",
+		Summary: "Open a return",
+		Params: []Param{body("rmaId", TypeString, true, ""), body("caseId", TypeString, true, ""), body("deviceSerialNo", TypeString, false, ""),
+			body("returnReason", TypeString, true, ""), user}},
+	{Method: "GET", Path: "/rmas", Function: "getAllRMAs", Tag: "rmas",
+		Summary: "All returns", Params: []Param{user}, Result: []tnt.RMA_Line{}},
+	{Method: "GET", Path: "/rmas/{rmaId}", Function: "getRMAByID", Single: true, Tag: "rmas",
+		Summary: "A return", Params: []Param{path("rmaId", ""), user}, Result: tnt.RMA_Line{}},
+	{Method: "PUT", Path: "/rmas/{rmaId}/status", Function: "updateRMAStatus", Invoke: true, Tag: "rmas",
+		Summary: "Move a return to its next status",
+		Params: []Param{path("rmaId", ""), body("rmaStatus", TypeString, true, ""), body("replacementCaseId", TypeString, false, ""),
+			body("comment", TypeString, false, ""), user}},
+	{Method: "POST", Path: "/rmas/{rmaId}/break-case", Function: "breakRMACase", Invoke: true, Tag: "rmas",
+		Summary: "Unpack the case of a return", Params: []Param{path("rmaId", ""), user}},
+	{Method: "POST", Path: "/shipments", Function: "createShipment", Invoke: true, Created: true, Tag: "shipments",
+		Summary: "Create a shipment of cases",
+		Params: []Param{body("shipmentId", TypeString, true, ""), body("caseIds", TypeJSON, true, "array of case IDs"), body("carrier", TypeString, true, ""),
+			body("trackingNumber", TypeString, false, ""), body("originPlant", TypeString, false, ""), body("destination", TypeString, false, ""), user}},
+	{Method: "GET", Path: "/shipments/{shipmentId}", Function: "getShipmentByID", Single: true, Tag: "shipments",
+		Summary: "A shipment", Params: []Param{path("shipmentId", ""), user}, Result: tnt.Shipment_Line{}},
+	{Method: "POST", Path: "/shipments/{shipmentId}/handover", Function: "handOverShipment", Invoke: true, Tag: "shipments",
+		Summary: "Hand a shipment over to the next custodian",
+		Params:  []Param{path("shipmentId", ""), body("toUser", TypeString, true, ""), body("location", TypeString, false, ""), user}},
+	{Method: "POST", Path: "/shipments/{shipmentId}/acceptance", Function: "acceptShipment", Invoke: true, Tag: "shipments",
+		Summary: "Accept a handed over shipment",
+		Params:  []Param{path("shipmentId", ""), body("location", TypeString, false, ""), user}},
+
+	// Statistics
+	{Method: "GET", Path: "/stats/assemblies", Function: "getAssemblyCounts", Tag: "stats",
+		Summary: "Assembly counts by plant, deviceType, status, day or week",
+		Params: []Param{query("groupBy", TypeString, false, "comma separated"), query("from", TypeDate, false, ""), query("to", TypeDate, false, ""),
+			user, includeCancelled},
+		Result: []tnt.Assembly_Count{}},
+	{Method: "GET", Path: "/stats/packages", Function: "getPackageCounts", Tag: "stats",
+		Summary: "Package counts by status, day or week",
+		Params: []Param{query("groupBy", TypeString, false, "comma separated"), query("from", TypeDate, false, ""), query("to", TypeDate, false, ""),
+			user, includeCancelled},
+		Result: []tnt.Package_Count{}},
+	{Method: "GET", Path: "/stats/cycle-times", Function: "getCycleTimes", Tag: "stats",
+		Summary: "Cycle time percentiles between statuses",
+		Params: []Param{query("groupBy", TypeString, false, "comma separated"), query("from", TypeDate, false, ""), query("to", TypeDate, false, ""),
+			user, includeCancelled},
+		Result: []tnt.Cycle_Time_Stats{}},
+	{Method: "GET", Path: "/stats/stuck-assemblies", Function: "getStuckAssemblies", Tag: "stats",
+		Summary: "Assemblies in their status longer than a threshold",
+		Params:  []Param{query("status", TypeString, false, "all statuses when empty"), query("thresholdHours", TypeInteger, true, ""), user},
+		Result:  []tnt.Stuck_Assembly{}},
+
+	// Plant time zones
+	{Method: "PUT", Path: "/plants/{plant}/timezone", Function: "setPlantTimeZone", Invoke: true, Tag: "plants",
+		Summary: "Set the time zone the local dates of a plant are shown in",
+		Params:  []Param{path("plant", ""), body("timeZone", TypeString, false, "IANA time zone e.g. Asia/Kolkata, or UTC offset e.g. +05:30; empty removes it"), user}},
+	{Method: "GET", Path: "/plants/timezones", Function: "getPlantTimeZones", Tag: "plants",
+		Summary: "Time zones of the plants", Params: []Param{user}, Result: tnt.Plant_TimeZones{}},
+
+	// Schema migration
+	{Method: "POST", Path: "/schema/migration", Function: "migrateSchema", Invoke: true, Tag: "schema",
+		Summary: "Move the next page of stored records to the current schema version",
+		Params:  []Param{body("pageSize", TypeInteger, true, "assemblies, packages, RMAs or shipments per run, at most 100"), user}},
+	{Method: "GET", Path: "/schema/migration", Function: "getSchemaMigration", Tag: "schema",
+		Summary: "Progress of the schema migration", Params: []Param{user}, Result: tnt.Schema_Migration{}},
+
+	// Users
+	{Method: "PUT", Path: "/users/{name}", Function: "registerUser", Invoke: true, Tag: "users",
+		Summary: "Register a user, or change its role - ledger administrators only",
+		Params: []Param{path("name", ""), body("role", TypeString, true, ""),
+			body("identity", TypeString, true, "the identity the user submits with, as GET /identity answers it to the user")}},
+	{Method: "GET", Path: "/identity", Function: "getCallerIdentity", Tag: "users",
+		Summary: "The identity submitting the request", Result: tnt.Caller_Identity{}},
+
+	// Discovery
+	{Method: "GET", Path: "/functions", Function: "listFunctions", Tag: "functions",
+		Summary: "The chaincode functions with their arguments and roles", Result: []tnt.Function_Def{}},
+	{Method: "GET", Path: "/functions/metrics", Function: "getFunctionMetrics", Tag: "functions",
+		Summary: "Call counts and latencies per function on the peer", Result: []tnt.Function_Metrics{}},
+}
@@ -0,0 +1,8 @@
+module github.com/GHSagarnil/TracknTrace3
+
+go 1.22.0
+
+require (
+	github.com/hyperledger/fabric-chaincode-go/v2 v2.3.0
+	github.com/hyperledger/fabric-contract-api-go/v2 v2.0.0
+)
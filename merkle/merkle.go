@@ -0,0 +1,179 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Package merkle builds the Merkle tree stored against a shipped case (PackageLine)
+// and verifies inclusion proofs offline, without access to the ledger.
+// The chaincode and any client verifying proofs must use this same package.
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+)
+
+// Prefixes keep leaf and node hashes apart so a node can never be passed off as a leaf
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+// AssemblyLeaf is the canonical record of an assembly as it was packed into a case.
+// Only fields that don't change after packaging are part of the leaf.
+type AssemblyLeaf struct {
+	AssemblyId          string `json:"assemblyId"`
+	DeviceSerialNo      string `json:"deviceSerialNo"`
+	DeviceType          string `json:"deviceType"`
+	FilamentBatchId     string `json:"filamentBatchId"`
+	LedBatchId          string `json:"ledBatchId"`
+	CircuitBoardBatchId string `json:"circuitBoardBatchId"`
+	WireBatchId         string `json:"wireBatchId"`
+	CasingBatchId       string `json:"casingBatchId"`
+	AdaptorBatchId      string `json:"adaptorBatchId"`
+	StickPodBatchId     string `json:"stickPodBatchId"`
+	ManufacturingPlant  string `json:"manufacturingPlant"`
+	AssemblyDate        string `json:"assemblyDate"`
+	CaseId              string `json:"caseId"`
+}
+
+// ProofStep is one sibling hash on the path from a leaf to the root
+type ProofStep struct {
+	Hash string `json:"hash"` // hex encoded sibling hash
+	Left bool   `json:"left"` // true when the sibling is on the left
+}
+
+// Hash returns the leaf hash of the assembly record.
+// Field order is fixed and must never change, otherwise stored roots can't be verified.
+func (l AssemblyLeaf) Hash() []byte {
+	var buf []byte
+	for _, field := range []string{
+		l.AssemblyId,
+		l.DeviceSerialNo,
+		l.DeviceType,
+		l.FilamentBatchId,
+		l.LedBatchId,
+		l.CircuitBoardBatchId,
+		l.WireBatchId,
+		l.CasingBatchId,
+		l.AdaptorBatchId,
+		l.StickPodBatchId,
+		l.ManufacturingPlant,
+		l.AssemblyDate,
+		l.CaseId,
+	} {
+		// length:value; so that adjacent fields can't be shifted into each other
+		buf = append(buf, strconv.Itoa(len(field))...)
+		buf = append(buf, ':')
+		buf = append(buf, field...)
+		buf = append(buf, ';')
+	}
+	return hashLeaf(buf)
+}
+
+func hashLeaf(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func hashNode(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// levels builds every level of the tree, leaves first and root last.
+// An odd node at the end of a level is carried up unchanged rather than duplicated.
+func levels(leaves [][]byte) [][][]byte {
+	tree := [][][]byte{leaves}
+	for level := leaves; len(level) > 1; {
+		var next [][]byte
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+			} else {
+				next = append(next, hashNode(level[i], level[i+1]))
+			}
+		}
+		tree = append(tree, next)
+		level = next
+	}
+	return tree
+}
+
+// Root returns the hex encoded Merkle root over the leaves, in the given order
+func Root(leaves []AssemblyLeaf) (string, error) {
+	if len(leaves) == 0 {
+		return "", errors.New("No assemblies to build the Merkle root")
+	}
+	hashes := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		hashes[i] = leaf.Hash()
+	}
+	tree := levels(hashes)
+	return hex.EncodeToString(tree[len(tree)-1][0]), nil
+}
+
+// Proof returns the inclusion proof for the leaf at index
+func Proof(leaves []AssemblyLeaf, index int) ([]ProofStep, error) {
+	if index < 0 || index >= len(leaves) {
+		return nil, errors.New("Leaf index out of range")
+	}
+	hashes := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		hashes[i] = leaf.Hash()
+	}
+
+	proof := []ProofStep{}
+	tree := levels(hashes)
+	for _, level := range tree[:len(tree)-1] {
+		if index%2 == 1 {
+			proof = append(proof, ProofStep{Hash: hex.EncodeToString(level[index-1]), Left: true})
+		} else if index+1 < len(level) {
+			proof = append(proof, ProofStep{Hash: hex.EncodeToString(level[index+1]), Left: false})
+		}
+		index = index / 2
+	}
+	return proof, nil
+}
+
+// Verify checks that leaf is included under the hex encoded root using proof
+func Verify(root string, leaf AssemblyLeaf, proof []ProofStep) (bool, error) {
+	expected, err := hex.DecodeString(root)
+	if err != nil {
+		return false, errors.New("Merkle root is not valid hex")
+	}
+
+	current := leaf.Hash()
+	for _, step := range proof {
+		sibling, err := hex.DecodeString(step.Hash)
+		if err != nil {
+			return false, errors.New("Proof hash is not valid hex")
+		}
+		if step.Left {
+			current = hashNode(sibling, current)
+		} else {
+			current = hashNode(current, sibling)
+		}
+	}
+	return bytes.Equal(current, expected), nil
+}
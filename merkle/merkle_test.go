@@ -0,0 +1,193 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+  http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package merkle
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strconv"
+	"testing"
+)
+
+func testLeaves(n int) []AssemblyLeaf {
+	leaves := make([]AssemblyLeaf, n)
+	for i := range leaves {
+		id := strconv.Itoa(i + 1)
+		leaves[i] = AssemblyLeaf{
+			AssemblyId:         "A" + id,
+			DeviceSerialNo:     "SN" + id,
+			DeviceType:         "Stick",
+			FilamentBatchId:    "F1",
+			ManufacturingPlant: "Plant1",
+			AssemblyDate:       "2017-12-01T10:00:00Z",
+			CaseId:             "C1",
+		}
+	}
+	return leaves
+}
+
+func TestProofVerifies(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 4, 5, 7, 8, 13} {
+		leaves := testLeaves(n)
+		root, err := Root(leaves)
+		if err != nil {
+			t.Fatalf("%d leaves: Root: %v", n, err)
+		}
+		for i, leaf := range leaves {
+			proof, err := Proof(leaves, i)
+			if err != nil {
+				t.Fatalf("%d leaves: Proof(%d): %v", n, i, err)
+			}
+			ok, err := Verify(root, leaf, proof)
+			if err != nil || !ok {
+				t.Errorf("%d leaves: leaf %d does not verify (%v)", n, i, err)
+			}
+		}
+	}
+}
+
+func TestSingleLeaf(t *testing.T) {
+	leaves := testLeaves(1)
+	root, _ := Root(leaves)
+	if root != hex.EncodeToString(leaves[0].Hash()) {
+		t.Errorf("root of a single leaf should be the leaf hash, got %s", root)
+	}
+	proof, _ := Proof(leaves, 0)
+	if len(proof) != 0 {
+		t.Errorf("proof of a single leaf should be empty, got %d steps", len(proof))
+	}
+}
+
+func TestOddLeafCarriedUp(t *testing.T) {
+	leaves := testLeaves(3)
+	root, _ := Root(leaves)
+	want := hashNode(hashNode(leaves[0].Hash(), leaves[1].Hash()), leaves[2].Hash())
+	if root != hex.EncodeToString(want) {
+		t.Errorf("odd leaf should be carried up unchanged, got root %s", root)
+	}
+	proof, _ := Proof(leaves, 2)
+	if len(proof) != 1 || !proof[0].Left {
+		t.Errorf("odd leaf proof should be one left sibling, got %+v", proof)
+	}
+}
+
+func TestTamperedProofs(t *testing.T) {
+	leaves := testLeaves(5)
+	root, _ := Root(leaves)
+	proof, _ := Proof(leaves, 1)
+	other, _ := Root(testLeaves(4))
+
+	changedLeaf := leaves[1]
+	changedLeaf.DeviceSerialNo = "SN99"
+	shiftedLeaf := leaves[1]
+	shiftedLeaf.DeviceSerialNo, shiftedLeaf.DeviceType = leaves[1].DeviceSerialNo+"Stick", ""
+
+	flipped := append([]ProofStep(nil), proof...)
+	flipped[0].Left = !flipped[0].Left
+	swapped := append([]ProofStep(nil), proof...)
+	swapped[0].Hash = proof[1].Hash
+	corrupted := append([]ProofStep(nil), proof...)
+	corrupted[1].Hash = hex.EncodeToString(leaves[3].Hash())
+
+	tests := []struct {
+		name    string
+		root    string
+		leaf    AssemblyLeaf
+		proof   []ProofStep
+		wantErr bool
+	}{
+		{"changed leaf", root, changedLeaf, proof, false},
+		{"field shifted into neighbour", root, shiftedLeaf, proof, false},
+		{"other leaf", root, leaves[2], proof, false},
+		{"flipped side", root, leaves[1], flipped, false},
+		{"swapped sibling", root, leaves[1], swapped, false},
+		{"corrupted sibling", root, leaves[1], corrupted, false},
+		{"missing step", root, leaves[1], proof[:len(proof)-1], false},
+		{"other root", other, leaves[1], proof, false},
+		{"root not hex", "zz", leaves[1], proof, true},
+		{"sibling not hex", root, leaves[1], []ProofStep{{Hash: "zz"}}, true},
+	}
+	for _, tc := range tests {
+		ok, err := Verify(tc.root, tc.leaf, tc.proof)
+		if ok {
+			t.Errorf("%s: tampered proof verified", tc.name)
+		}
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: err = %v, want error %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+// A node must never hash the same as a leaf over the same bytes, otherwise an inner node
+// could be presented as a leaf with a shortened proof
+func TestLeafAndNodePrefixes(t *testing.T) {
+	leaves := testLeaves(4)
+	left, right := leaves[0].Hash(), leaves[1].Hash()
+	concat := append(append([]byte(nil), left...), right...)
+
+	tests := []struct {
+		name string
+		a, b []byte
+	}{
+		{"node vs leaf over the same bytes", hashNode(left, right), hashLeaf(concat)},
+		{"leaf vs leaf with the node prefix", hashLeaf(concat), hashLeaf(append([]byte{nodePrefix}, concat...))},
+		{"leaf vs its data", hashLeaf(concat), concat},
+	}
+	for _, tc := range tests {
+		if bytes.Equal(tc.a, tc.b) {
+			t.Errorf("%s: hashes collide", tc.name)
+		}
+	}
+
+	root, _ := Root(leaves)
+	inner := hashNode(left, right)
+	proof, _ := Proof(leaves, 0)
+	ok, _ := Verify(root, AssemblyLeaf{AssemblyId: hex.EncodeToString(inner)}, proof[1:])
+	if ok {
+		t.Error("inner node verified as a leaf")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := Root(nil); err == nil {
+		t.Error("Root of no leaves should fail")
+	}
+	for _, index := range []int{-1, 3} {
+		if _, err := Proof(testLeaves(3), index); err == nil {
+			t.Errorf("Proof(%d) of 3 leaves should fail", index)
+		}
+	}
+}
+
+// Stored roots must keep verifying; a change to the field order, the length framing or the
+// leaf/node prefixes shows up here
+func TestKnownRoots(t *testing.T) {
+	tests := []struct {
+		leaves int
+		root   string
+	}{
+		{1, "b02e0f857538ec65f8579be6fd57a3351951cb61912911ec673962b0b6bd2949"},
+		{3, "597584a13fcbc8d1eaa7755e30771f0840ea00c4a06cd0ad1e868ec33ce5e6e8"},
+	}
+	for _, tc := range tests {
+		root, err := Root(testLeaves(tc.leaves))
+		if err != nil || root != tc.root {
+			t.Errorf("%d leaves: root %s (%v), want %s", tc.leaves, root, err, tc.root)
+		}
+	}
+}